@@ -9,6 +9,7 @@ import (
 
 	"github.com/Lutefd/challenge-bravo/internal/commons"
 	"github.com/Lutefd/challenge-bravo/internal/model"
+	"github.com/Lutefd/challenge-bravo/internal/repository"
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
@@ -47,17 +48,13 @@ func run(ctx context.Context, deps dependencies) error {
 		return fmt.Errorf("error loading config: %w", err)
 	}
 
-	db, err := deps.openDB("postgres", config.PostgresConn)
+	users, err := openUserRepository(ctx, config, deps)
 	if err != nil {
-		return fmt.Errorf("error opening database connection: %w", err)
-	}
-	defer db.Close()
-
-	if err := db.PingContext(ctx); err != nil {
-		return fmt.Errorf("error connecting to the database: %w", err)
+		return err
 	}
+	defer users.Close()
 
-	if err := createAdminUser(ctx, db, deps); err != nil {
+	if err := createAdminUser(ctx, users, deps); err != nil {
 		return fmt.Errorf("error creating admin user: %w", err)
 	}
 
@@ -65,7 +62,29 @@ func run(ctx context.Context, deps dependencies) error {
 	return nil
 }
 
-func createAdminUser(ctx context.Context, db *sql.DB, deps dependencies) error {
+func openUserRepository(ctx context.Context, config commons.Config, deps dependencies) (repository.UserRepository, error) {
+	if config.StorageDriver == commons.StorageDriverSQLite {
+		users, err := repository.NewSQLiteUserRepository(config.SQLitePath, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error opening database connection: %w", err)
+		}
+		return users, nil
+	}
+
+	db, err := deps.openDB("postgres", config.PostgresConn)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database connection: %w", err)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error connecting to the database: %w", err)
+	}
+
+	return repository.NewPostgresUserRepository(config.PostgresConn, db)
+}
+
+func createAdminUser(ctx context.Context, users repository.UserRepository, deps dependencies) error {
 	adminUser := model.UserDB{
 		ID:        deps.newUUID(),
 		Username:  "admin",
@@ -82,11 +101,7 @@ func createAdminUser(ctx context.Context, db *sql.DB, deps dependencies) error {
 	}
 	adminUser.Password = string(hashedPassword)
 
-	_, err = db.ExecContext(ctx, `
-		INSERT INTO users (id, username, password, role, api_key, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`, adminUser.ID, adminUser.Username, adminUser.Password, adminUser.Role, adminUser.APIKey, adminUser.CreatedAt, adminUser.UpdatedAt)
-
+	err = users.Create(ctx, &adminUser)
 	if err != nil {
 		return fmt.Errorf("error inserting admin user: %w", err)
 	}
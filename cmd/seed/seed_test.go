@@ -4,12 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/Lutefd/challenge-bravo/internal/commons"
 	"github.com/Lutefd/challenge-bravo/internal/model"
+	"github.com/Lutefd/challenge-bravo/internal/repository"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -56,6 +58,31 @@ func TestRun(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestRun_SQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seed.db")
+	mockDeps := dependencies{
+		loadConfig: func() (commons.Config, error) {
+			return commons.Config{StorageDriver: commons.StorageDriverSQLite, SQLitePath: path}, nil
+		},
+		newUUID: uuid.New,
+		timeNow: func() time.Time {
+			return time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		},
+		loadEnv: func(...string) error {
+			return nil
+		},
+	}
+
+	require.NoError(t, run(context.Background(), mockDeps))
+
+	users, err := repository.NewSQLiteUserRepository(path, nil)
+	require.NoError(t, err)
+	defer users.Close()
+	admin, err := users.GetByUsername(context.Background(), "admin")
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleAdmin, admin.Role)
+}
+
 func TestCreateAdminUser(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
@@ -80,7 +107,10 @@ func TestCreateAdminUser(t *testing.T) {
 		mockDeps.timeNow(),
 	).WillReturnResult(sqlmock.NewResult(1, 1))
 
-	err = createAdminUser(context.Background(), db, mockDeps)
+	users, err := repository.NewPostgresUserRepository("", db)
+	require.NoError(t, err)
+
+	err = createAdminUser(context.Background(), users, mockDeps)
 	assert.NoError(t, err)
 	fmt.Println("success creating an admin user")
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"sync"
@@ -12,27 +15,43 @@ import (
 
 	"github.com/Lutefd/challenge-bravo/internal/cache"
 	"github.com/Lutefd/challenge-bravo/internal/commons"
+	"github.com/Lutefd/challenge-bravo/internal/handler"
 	"github.com/Lutefd/challenge-bravo/internal/logger"
+	"github.com/Lutefd/challenge-bravo/internal/model"
 	"github.com/Lutefd/challenge-bravo/internal/repository"
+	"github.com/Lutefd/challenge-bravo/internal/storage"
 	"github.com/Lutefd/challenge-bravo/internal/worker"
+	"github.com/go-chi/chi/v5"
 	"github.com/joho/godotenv"
 )
 
 type dependencies struct {
+	storage      repository.Config
 	currencyRepo repository.CurrencyRepository
 	cache        cache.Cache
 	logRepo      repository.LogRepository
+	statusRepo   repository.ProviderStatusRepository
+	leaderLock   repository.LeaderLockRepository
 	externalAPI  worker.ExternalAPIClient
 	rateUpdater  RateUpdater
 	partitionMgr PartitionManager
+	elector      LeaderElector
 }
 
 type RateUpdater interface {
 	Start(ctx context.Context)
+	Backfill(ctx context.Context, from, to time.Time) error
+	Status() model.RateUpdaterStatus
 }
 
 type PartitionManager interface {
 	Start(ctx context.Context) error
+	Status() model.PartitionManagerStatus
+}
+
+type LeaderElector interface {
+	Run(ctx context.Context, lead func(ctx context.Context) error) error
+	IsLeader() bool
 }
 
 func main() {
@@ -45,6 +64,23 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "backfill" {
+		from, to, err := parseBackfillArgs(os.Args[2:], time.Now())
+		if err != nil {
+			log.Fatalf("Invalid backfill arguments: %v", err)
+		}
+		deps, err := initDependencies(config)
+		if err != nil {
+			log.Fatalf("Failed to initialize dependencies: %v", err)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+		if err := runBackfill(ctx, deps, from, to); err != nil {
+			log.Fatalf("Backfill failed: %v", err)
+		}
+		return
+	}
+
 	deps, err := initDependencies(config)
 	if err != nil {
 		log.Fatalf("Failed to initialize dependencies: %v", err)
@@ -53,6 +89,15 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	statusServer := newStatusServer(config.WorkerPort, newWorkerMonitor(deps, time.Now()))
+	go func() {
+		log.Printf("Worker status server started on port %d", config.WorkerPort)
+		if err := statusServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Worker status server failed: %v", err)
+		}
+	}()
+	defer shutdownStatusServer(statusServer)
+
 	errChan := make(chan error, 1)
 	go func() {
 		errChan <- runWorker(ctx, deps)
@@ -79,33 +124,121 @@ func main() {
 	}
 }
 
+type workerMonitor struct {
+	deps      *dependencies
+	startedAt time.Time
+	now       func() time.Time
+}
+
+func newWorkerMonitor(deps *dependencies, startedAt time.Time) *workerMonitor {
+	return &workerMonitor{
+		deps:      deps,
+		startedAt: startedAt.UTC(),
+		now:       time.Now,
+	}
+}
+
+func (m *workerMonitor) Status() model.WorkerStatus {
+	status := model.WorkerStatus{
+		Leader:           m.deps.elector.IsLeader(),
+		StartedAt:        m.startedAt,
+		RateUpdater:      m.deps.rateUpdater.Status(),
+		PartitionManager: m.deps.partitionMgr.Status(),
+	}
+	if !status.Leader {
+		status.Ready = true
+		return status
+	}
+
+	if state := status.PartitionManager.State; state != model.PartitionManagerStateRunning {
+		status.Reasons = append(status.Reasons, fmt.Sprintf("partition manager is %s", state))
+	}
+	if !status.RateUpdater.Started {
+		status.Reasons = append(status.Reasons, "rate updater has not completed its first run")
+	}
+	if lastFetch := status.RateUpdater.LastFetchAt; lastFetch == nil {
+		status.Reasons = append(status.Reasons, "rates have not been fetched yet")
+	} else if m.now().Sub(*lastFetch) > commons.WorkerMaxRateAge {
+		status.Reasons = append(status.Reasons, fmt.Sprintf("rates have not been fetched since %s", lastFetch.Format(time.RFC3339)))
+	}
+	status.Ready = len(status.Reasons) == 0
+	return status
+}
+
+func newStatusServer(port uint16, source handler.WorkerStatusSource) *http.Server {
+	statusHandler := handler.NewWorkerStatusHandler(source)
+	router := chi.NewRouter()
+	router.Get("/healthz", handler.HandlerReadiness)
+	router.Get("/readyz", statusHandler.Readiness)
+	router.Get("/status", statusHandler.Status)
+
+	return &http.Server{
+		Addr:         fmt.Sprintf(":%d", port),
+		Handler:      router,
+		IdleTimeout:  commons.ServerIdleTimeout,
+		ReadTimeout:  commons.ServerReadTimeout,
+		WriteTimeout: commons.ServerWriteTimeout,
+	}
+}
+
+func shutdownStatusServer(server *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Error shutting down worker status server: %v", err)
+	}
+}
+
 func initDependencies(config commons.Config) (*dependencies, error) {
-	currencyRepo, err := repository.NewPostgresCurrencyRepository(config.PostgresConn, nil)
+	storageConfig, err := storage.Open(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	currencyRepo, err := repository.NewCurrencyRepository(storageConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize repository: %w", err)
 	}
 
-	redisCache, err := cache.NewRedisCache(config.RedisAddr, config.RedisPass)
+	rateCache, err := cache.NewRateCache(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize cache: %w", err)
 	}
 
-	logRepo, err := repository.NewPostgresLogRepository(config.PostgresConn, nil)
+	logRepo, err := repository.NewLogRepository(storageConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize log repository: %w", err)
 	}
 
-	externalAPI := worker.NewOpenExchangeRatesClient(config.APIKey)
-	rateUpdater := worker.NewRateUpdater(currencyRepo, redisCache, externalAPI, commons.RateUpdaterInterval)
+	statusRepo, err := repository.NewProviderStatusRepository(storageConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize provider status repository: %w", err)
+	}
+
+	leaderLock, err := repository.NewLeaderLockRepository(storageConfig, commons.WorkerLeaderLockKey, commons.SQLiteLeaderLockTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize leader lock repository: %w", err)
+	}
+
+	providers, err := worker.NewProviders(config, statusRepo)
+	if err != nil {
+		return nil, err
+	}
+	externalAPI := worker.NewRateAggregator(providers, config.RateTolerance)
+	rateUpdater := worker.NewRateUpdater(currencyRepo, rateCache, externalAPI, commons.RateUpdaterInterval,
+		worker.WithJumpThreshold(config.RateJumpThreshold), worker.WithRateChangePublisher(rateCache))
 	partManager := logger.NewPartitionManager(logRepo)
 
 	return &dependencies{
+		storage:      storageConfig,
 		currencyRepo: currencyRepo,
-		cache:        redisCache,
+		cache:        rateCache,
 		logRepo:      logRepo,
+		statusRepo:   statusRepo,
+		leaderLock:   leaderLock,
 		externalAPI:  externalAPI,
 		rateUpdater:  rateUpdater,
 		partitionMgr: partManager,
+		elector:      worker.NewLeaderElector(leaderLock, commons.LeaderElectionInterval),
 	}, nil
 }
 
@@ -120,7 +253,7 @@ func runWorker(ctx context.Context, deps *dependencies) error {
 			case <-ctx.Done():
 				return
 			case <-heartbeat.C:
-				log.Println("worker heartbeat")
+				log.Printf("worker heartbeat, leader: %t", deps.elector.IsLeader())
 			}
 		}
 	}()
@@ -130,14 +263,13 @@ func runWorker(ctx context.Context, deps *dependencies) error {
 
 		logger.InitLogger(deps.logRepo)
 
-		if err := deps.partitionMgr.Start(ctx); err != nil {
-			errChan <- fmt.Errorf("failed to start partition manager: %w", err)
+		if err := deps.elector.Run(ctx, func(ctx context.Context) error {
+			return lead(ctx, deps)
+		}); err != nil {
+			errChan <- err
 			return
 		}
 
-		deps.rateUpdater.Start(ctx)
-
-		<-ctx.Done()
 		log.Println("Worker shutting down...")
 	}()
 
@@ -146,17 +278,7 @@ func runWorker(ctx context.Context, deps *dependencies) error {
 		close(errChan)
 	}()
 
-	defer func() {
-		if err := deps.currencyRepo.Close(); err != nil {
-			log.Printf("Error closing currency repository: %v", err)
-		}
-		if err := deps.cache.Close(); err != nil {
-			log.Printf("Error closing cache: %v", err)
-		}
-		if err := deps.logRepo.Close(); err != nil {
-			log.Printf("Error closing log repository: %v", err)
-		}
-	}()
+	defer closeDependencies(deps)
 
 	select {
 	case err := <-errChan:
@@ -165,3 +287,78 @@ func runWorker(ctx context.Context, deps *dependencies) error {
 		return ctx.Err()
 	}
 }
+
+func lead(ctx context.Context, deps *dependencies) error {
+	if err := deps.partitionMgr.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start partition manager: %w", err)
+	}
+
+	deps.rateUpdater.Start(ctx)
+
+	<-ctx.Done()
+	return nil
+}
+
+func runBackfill(ctx context.Context, deps *dependencies, from, to time.Time) error {
+	defer closeDependencies(deps)
+	logger.InitLogger(deps.logRepo)
+
+	log.Printf("Backfilling rates from %s to %s", from.Format(time.DateOnly), to.Format(time.DateOnly))
+	if err := deps.rateUpdater.Backfill(ctx, from, to); err != nil {
+		return err
+	}
+	log.Println("Backfill complete")
+	return nil
+}
+
+func parseBackfillArgs(args []string, now time.Time) (time.Time, time.Time, error) {
+	flags := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	fromFlag := flags.String("from", "", "first day to backfill (YYYY-MM-DD)")
+	toFlag := flags.String("to", "", "last day to backfill (YYYY-MM-DD)")
+	if err := flags.Parse(args); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if *fromFlag == "" || *toFlag == "" {
+		return time.Time{}, time.Time{}, errors.New("--from and --to are required")
+	}
+
+	from, err := time.Parse(time.DateOnly, *fromFlag)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid --from, must be YYYY-MM-DD: %w", err)
+	}
+	to, err := time.Parse(time.DateOnly, *toFlag)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid --to, must be YYYY-MM-DD: %w", err)
+	}
+	if from.After(to) {
+		return time.Time{}, time.Time{}, errors.New("--from must not be after --to")
+	}
+	if to.After(now.UTC()) {
+		return time.Time{}, time.Time{}, errors.New("--to must not be in the future")
+	}
+	if days := int(to.Sub(from).Hours()/24) + 1; days > commons.MaxBackfillDays {
+		return time.Time{}, time.Time{}, fmt.Errorf("backfill range must not exceed %d days", commons.MaxBackfillDays)
+	}
+	return from, to, nil
+}
+
+func closeDependencies(deps *dependencies) {
+	if err := deps.currencyRepo.Close(); err != nil {
+		log.Printf("Error closing currency repository: %v", err)
+	}
+	if err := deps.cache.Close(); err != nil {
+		log.Printf("Error closing cache: %v", err)
+	}
+	if err := deps.logRepo.Close(); err != nil {
+		log.Printf("Error closing log repository: %v", err)
+	}
+	if err := deps.statusRepo.Close(); err != nil {
+		log.Printf("Error closing provider status repository: %v", err)
+	}
+	if err := deps.leaderLock.Close(); err != nil {
+		log.Printf("Error closing leader lock repository: %v", err)
+	}
+	if err := deps.storage.Close(); err != nil {
+		log.Printf("Error closing storage: %v", err)
+	}
+}
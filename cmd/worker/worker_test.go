@@ -3,10 +3,14 @@ package main
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/Lutefd/challenge-bravo/internal/cache"
+	"github.com/Lutefd/challenge-bravo/internal/model"
 	"github.com/Lutefd/challenge-bravo/internal/repository"
 )
 
@@ -43,22 +47,76 @@ func (m *mockLogRepository) Close() error {
 	return m.closeErr
 }
 
+type mockProviderStatusRepository struct {
+	repository.ProviderStatusRepository
+	closeCalled bool
+}
+
+func (m *mockProviderStatusRepository) Close() error {
+	m.closeCalled = true
+	return nil
+}
+
+type mockLeaderLockRepository struct {
+	repository.LeaderLockRepository
+	closeCalled bool
+}
+
+func (m *mockLeaderLockRepository) Close() error {
+	m.closeCalled = true
+	return nil
+}
+
+type mockLeaderElector struct {
+	follower bool
+}
+
+func (m *mockLeaderElector) Run(ctx context.Context, lead func(ctx context.Context) error) error {
+	if m.follower || ctx.Err() != nil {
+		<-ctx.Done()
+		return nil
+	}
+	return lead(ctx)
+}
+
+func (m *mockLeaderElector) IsLeader() bool {
+	return !m.follower
+}
+
 type mockRateUpdater struct {
-	startCalled bool
+	startCalled  bool
+	backfillFrom time.Time
+	backfillTo   time.Time
+	backfillErr  error
+	status       model.RateUpdaterStatus
 }
 
 func (m *mockRateUpdater) Start(ctx context.Context) {
 	m.startCalled = true
 }
 
+func (m *mockRateUpdater) Backfill(ctx context.Context, from, to time.Time) error {
+	m.backfillFrom, m.backfillTo = from, to
+	return m.backfillErr
+}
+
+func (m *mockRateUpdater) Status() model.RateUpdaterStatus {
+	return m.status
+}
+
 type mockPartitionManager struct {
 	startErr error
+	status   model.PartitionManagerStatus
 }
 
 func (m *mockPartitionManager) Start(ctx context.Context) error {
 	return m.startErr
 }
 
+func (m *mockPartitionManager) Status() model.PartitionManagerStatus {
+	return m.status
+}
+
 func TestRunWorker(t *testing.T) {
 	tests := []struct {
 		name                   string
@@ -74,8 +132,11 @@ func TestRunWorker(t *testing.T) {
 				currencyRepo: &mockCurrencyRepository{},
 				cache:        &mockCache{},
 				logRepo:      &mockLogRepository{},
+				statusRepo:   &mockProviderStatusRepository{},
+				leaderLock:   &mockLeaderLockRepository{},
 				rateUpdater:  &mockRateUpdater{},
 				partitionMgr: &mockPartitionManager{},
+				elector:      &mockLeaderElector{},
 			},
 			expectedErrMsg:         "context deadline exceeded",
 			timeout:                100 * time.Millisecond,
@@ -90,8 +151,11 @@ func TestRunWorker(t *testing.T) {
 				currencyRepo: &mockCurrencyRepository{},
 				cache:        &mockCache{},
 				logRepo:      &mockLogRepository{},
+				statusRepo:   &mockProviderStatusRepository{},
+				leaderLock:   &mockLeaderLockRepository{},
 				rateUpdater:  &mockRateUpdater{},
 				partitionMgr: &mockPartitionManager{startErr: errors.New("partition manager error")},
+				elector:      &mockLeaderElector{},
 			},
 			expectedErrMsg:         "failed to start partition manager: partition manager error",
 			timeout:                100 * time.Millisecond,
@@ -100,14 +164,36 @@ func TestRunWorker(t *testing.T) {
 				return context.WithTimeout(context.Background(), 100*time.Millisecond)
 			},
 		},
+		{
+			name: "Standby replica",
+			deps: &dependencies{
+				currencyRepo: &mockCurrencyRepository{},
+				cache:        &mockCache{},
+				logRepo:      &mockLogRepository{},
+				statusRepo:   &mockProviderStatusRepository{},
+				leaderLock:   &mockLeaderLockRepository{},
+				rateUpdater:  &mockRateUpdater{},
+				partitionMgr: &mockPartitionManager{},
+				elector:      &mockLeaderElector{follower: true},
+			},
+			expectedErrMsg:         "context deadline exceeded",
+			timeout:                100 * time.Millisecond,
+			expectRateUpdaterStart: false,
+			setupContext: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 100*time.Millisecond)
+			},
+		},
 		{
 			name: "Context cancelled immediately",
 			deps: &dependencies{
 				currencyRepo: &mockCurrencyRepository{},
 				cache:        &mockCache{},
 				logRepo:      &mockLogRepository{},
+				statusRepo:   &mockProviderStatusRepository{},
+				leaderLock:   &mockLeaderLockRepository{},
 				rateUpdater:  &mockRateUpdater{},
 				partitionMgr: &mockPartitionManager{},
+				elector:      &mockLeaderElector{},
 			},
 			expectedErrMsg:         "context canceled",
 			expectRateUpdaterStart: false,
@@ -158,6 +244,14 @@ func TestRunWorker(t *testing.T) {
 			if !ok {
 				t.Fatal("logRepo is not a mockLogRepository")
 			}
+			mockStatusRepo, ok := tt.deps.statusRepo.(*mockProviderStatusRepository)
+			if !ok {
+				t.Fatal("statusRepo is not a mockProviderStatusRepository")
+			}
+			mockLeaderLock, ok := tt.deps.leaderLock.(*mockLeaderLockRepository)
+			if !ok {
+				t.Fatal("leaderLock is not a mockLeaderLockRepository")
+			}
 
 			if !mockCurrencyRepo.closeCalled {
 				t.Error("Expected currency repository Close to be called")
@@ -168,6 +262,188 @@ func TestRunWorker(t *testing.T) {
 			if !mockLogRepo.closeCalled {
 				t.Error("Expected log repository Close to be called")
 			}
+			if !mockStatusRepo.closeCalled {
+				t.Error("Expected provider status repository Close to be called")
+			}
+			if !mockLeaderLock.closeCalled {
+				t.Error("Expected leader lock repository Close to be called")
+			}
+		})
+	}
+}
+
+func TestParseBackfillArgs(t *testing.T) {
+	now := time.Date(2024, 8, 20, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		args           []string
+		expectedErrMsg string
+	}{
+		{name: "Valid range", args: []string{"--from", "2024-08-01", "--to", "2024-08-10"}},
+		{name: "Single day", args: []string{"--from=2024-08-19", "--to=2024-08-19"}},
+		{name: "Missing to", args: []string{"--from", "2024-08-01"}, expectedErrMsg: "--from and --to are required"},
+		{name: "Invalid date", args: []string{"--from", "08/01/2024", "--to", "2024-08-10"}, expectedErrMsg: "invalid --from, must be YYYY-MM-DD"},
+		{name: "Reversed range", args: []string{"--from", "2024-08-10", "--to", "2024-08-01"}, expectedErrMsg: "--from must not be after --to"},
+		{name: "Future date", args: []string{"--from", "2024-08-10", "--to", "2024-08-21"}, expectedErrMsg: "--to must not be in the future"},
+		{name: "Range too long", args: []string{"--from", "2022-01-01", "--to", "2024-08-10"}, expectedErrMsg: "backfill range must not exceed 366 days"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := parseBackfillArgs(tt.args, now)
+			if tt.expectedErrMsg == "" {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				if from.After(to) {
+					t.Errorf("Expected from %s not to be after to %s", from, to)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErrMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectedErrMsg, err)
+			}
+		})
+	}
+}
+
+func TestRunBackfill(t *testing.T) {
+	from := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 8, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		backfillErr error
+	}{
+		{name: "Success"},
+		{name: "Backfill error", backfillErr: errors.New("failed to backfill 1 of 10 days")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updater := &mockRateUpdater{backfillErr: tt.backfillErr}
+			currencyRepo := &mockCurrencyRepository{}
+			deps := &dependencies{
+				currencyRepo: currencyRepo,
+				cache:        &mockCache{},
+				logRepo:      &mockLogRepository{},
+				statusRepo:   &mockProviderStatusRepository{},
+				leaderLock:   &mockLeaderLockRepository{},
+				rateUpdater:  updater,
+				partitionMgr: &mockPartitionManager{},
+				elector:      &mockLeaderElector{},
+			}
+
+			err := runBackfill(context.Background(), deps, from, to)
+
+			if !errors.Is(err, tt.backfillErr) {
+				t.Errorf("Expected error %v, got %v", tt.backfillErr, err)
+			}
+			if !updater.backfillFrom.Equal(from) || !updater.backfillTo.Equal(to) {
+				t.Errorf("Expected backfill from %s to %s, got %s to %s", from, to, updater.backfillFrom, updater.backfillTo)
+			}
+			if !currencyRepo.closeCalled {
+				t.Error("Expected currency repository Close to be called")
+			}
+		})
+	}
+}
+
+func TestWorkerMonitor_Status(t *testing.T) {
+	now := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+	recent := now.Add(-time.Hour)
+	stale := now.Add(-4 * time.Hour)
+
+	tests := []struct {
+		name            string
+		follower        bool
+		rateUpdater     model.RateUpdaterStatus
+		partitionStatus model.PartitionManagerStatus
+		expectedReasons []string
+	}{
+		{
+			name:            "Ready",
+			rateUpdater:     model.RateUpdaterStatus{Started: true, LastFetchAt: &recent},
+			partitionStatus: model.PartitionManagerStatus{State: model.PartitionManagerStateRunning},
+		},
+		{
+			name:            "Starting",
+			partitionStatus: model.PartitionManagerStatus{State: model.PartitionManagerStateStarting},
+			expectedReasons: []string{
+				"partition manager is starting",
+				"rate updater has not completed its first run",
+				"rates have not been fetched yet",
+			},
+		},
+		{
+			name:            "Standby replica",
+			follower:        true,
+			partitionStatus: model.PartitionManagerStatus{State: model.PartitionManagerStateStarting},
+		},
+		{
+			name:            "Stale rates",
+			rateUpdater:     model.RateUpdaterStatus{Started: true, LastFetchAt: &stale, LastError: "failed to fetch rates: all rate providers failed"},
+			partitionStatus: model.PartitionManagerStatus{State: model.PartitionManagerStateRunning},
+			expectedReasons: []string{"rates have not been fetched since 2024-08-10T08:00:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitor := newWorkerMonitor(&dependencies{
+				rateUpdater:  &mockRateUpdater{status: tt.rateUpdater},
+				partitionMgr: &mockPartitionManager{status: tt.partitionStatus},
+				elector:      &mockLeaderElector{follower: tt.follower},
+			}, now.Add(-2*time.Hour))
+			monitor.now = func() time.Time { return now }
+
+			status := monitor.Status()
+
+			if status.Leader == tt.follower {
+				t.Errorf("Expected leader to be %v, got %v", !tt.follower, status.Leader)
+			}
+			if status.Ready != (len(tt.expectedReasons) == 0) {
+				t.Errorf("Expected ready to be %v, got %v", len(tt.expectedReasons) == 0, status.Ready)
+			}
+			if strings.Join(status.Reasons, "; ") != strings.Join(tt.expectedReasons, "; ") {
+				t.Errorf("Expected reasons %q, got %q", tt.expectedReasons, status.Reasons)
+			}
+			if !status.StartedAt.Equal(now.Add(-2 * time.Hour)) {
+				t.Errorf("Expected started at %s, got %s", now.Add(-2*time.Hour), status.StartedAt)
+			}
+		})
+	}
+}
+
+func TestStatusServer(t *testing.T) {
+	monitor := newWorkerMonitor(&dependencies{
+		rateUpdater:  &mockRateUpdater{},
+		partitionMgr: &mockPartitionManager{status: model.PartitionManagerStatus{State: model.PartitionManagerStateRunning}},
+		elector:      &mockLeaderElector{},
+	}, time.Now())
+	server := newStatusServer(8081, monitor)
+
+	if server.Addr != ":8081" {
+		t.Errorf("Expected address :8081, got %s", server.Addr)
+	}
+
+	tests := []struct {
+		path           string
+		expectedStatus int
+	}{
+		{path: "/healthz", expectedStatus: http.StatusOK},
+		{path: "/readyz", expectedStatus: http.StatusServiceUnavailable},
+		{path: "/status", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
 		})
 	}
 }
@@ -3,11 +3,63 @@ package cache
 import (
 	"context"
 	"time"
+
+	"github.com/Lutefd/challenge-bravo/internal/commons"
+	"github.com/Lutefd/challenge-bravo/internal/model"
 )
 
 type Cache interface {
-	Get(ctx context.Context, key string) (float64, error)
-	Set(ctx context.Context, key string, value float64, expiration time.Duration) error
-	Delete(ctx context.Context, key string) error
+	Get(ctx context.Context, code string) (model.Currency, error)
+	GetMany(ctx context.Context, codes []string) (map[string]model.Currency, error)
+	GetSnapshot(ctx context.Context, codes []string) (model.RateSnapshot, error)
+	Set(ctx context.Context, currency model.Currency, expiration time.Duration) error
+	SetMany(ctx context.Context, currencies []model.Currency, expiration time.Duration) error
+	Delete(ctx context.Context, code string) error
+	UpdateSnapshot(ctx context.Context, currencies []model.Currency, deleted []string, expiration time.Duration) (uint64, error)
+	FillSnapshot(ctx context.Context, version uint64, currencies []model.Currency) (bool, error)
 	Close() error
 }
+
+type RateChangePublisher interface {
+	PublishRateChanges(ctx context.Context, events []model.RateChangeEvent) error
+}
+
+type RateChangeHandler interface {
+	ApplyRateChanges(events []model.RateChangeEvent)
+	Flush()
+}
+
+type RateChangeSubscriber interface {
+	SubscribeRateChanges(ctx context.Context, handler RateChangeHandler) error
+}
+
+type RateCache interface {
+	Cache
+	RateChangePublisher
+	RateChangeSubscriber
+}
+
+func NewRateCache(config commons.Config) (RateCache, error) {
+	if config.CacheDriver == commons.CacheDriverMemory {
+		return NewMemoryCache(), nil
+	}
+	redisCache, err := NewRedisCache(RedisConfig{
+		Addr:             config.RedisAddr,
+		Password:         config.RedisPass,
+		DB:               config.RedisDB,
+		SentinelMaster:   config.RedisSentinelMaster,
+		SentinelAddrs:    config.RedisSentinelAddrs,
+		SentinelPassword: config.RedisSentinelPass,
+		ClusterAddrs:     config.RedisClusterAddrs,
+		TLS:              config.RedisTLS,
+		TLSCACert:        config.RedisTLSCACert,
+		TLSCert:          config.RedisTLSCert,
+		TLSKey:           config.RedisTLSKey,
+		PoolSize:         config.RedisPoolSize,
+		MinIdleConns:     config.RedisMinIdleConns,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return redisCache, nil
+}
@@ -0,0 +1,296 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Lutefd/challenge-bravo/internal/commons"
+	"github.com/Lutefd/challenge-bravo/internal/model"
+	"golang.org/x/sync/singleflight"
+)
+
+type localEntry struct {
+	key       string
+	value     model.Currency
+	expiresAt time.Time
+}
+
+type LocalCache struct {
+	next       Cache
+	capacity   int
+	expiration time.Duration
+	now        func() time.Time
+	flights    singleflight.Group
+
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	order      *list.List
+	version    uint64
+	generation uint64
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	coalesced atomic.Uint64
+	evictions atomic.Uint64
+}
+
+func NewLocalCache(next Cache, capacity int, expiration time.Duration) *LocalCache {
+	return &LocalCache{
+		next:       next,
+		capacity:   capacity,
+		expiration: expiration,
+		now:        time.Now,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+func (c *LocalCache) Get(ctx context.Context, code string) (model.Currency, error) {
+	snapshot, err := c.GetSnapshot(ctx, []string{code})
+	if err != nil {
+		return model.Currency{}, err
+	}
+	currency, ok := snapshot.Currencies[code]
+	if !ok {
+		return model.Currency{}, fmt.Errorf("key not found")
+	}
+	return currency, nil
+}
+
+func (c *LocalCache) GetMany(ctx context.Context, codes []string) (map[string]model.Currency, error) {
+	snapshot, err := c.GetSnapshot(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.Currencies, nil
+}
+
+func (c *LocalCache) GetSnapshot(ctx context.Context, codes []string) (model.RateSnapshot, error) {
+	snapshot, missing := c.loadSnapshot(codes)
+	c.hits.Add(uint64(len(snapshot.Currencies)))
+	if missing == 0 {
+		return snapshot, nil
+	}
+	c.misses.Add(uint64(missing))
+
+	sorted := append([]string(nil), codes...)
+	sort.Strings(sorted)
+	generation := c.currentGeneration()
+	var leader atomic.Bool
+	flight := c.flights.DoChan("snapshot:"+strings.Join(sorted, ","), func() (interface{}, error) {
+		leader.Store(true)
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), commons.LocalCacheFetchTimeout)
+		defer cancel()
+		remote, err := c.next.GetSnapshot(fetchCtx, sorted)
+		if err != nil {
+			return model.RateSnapshot{}, err
+		}
+		c.storeIfUnchanged(generation, remote)
+		return remote, nil
+	})
+
+	var result singleflight.Result
+	select {
+	case result = <-flight:
+	case <-ctx.Done():
+		return model.RateSnapshot{}, ctx.Err()
+	}
+	if !leader.Load() {
+		c.coalesced.Add(1)
+	}
+	if result.Err != nil {
+		return model.RateSnapshot{}, result.Err
+	}
+
+	snapshot = result.Val.(model.RateSnapshot)
+	currencies := make(map[string]model.Currency, len(codes))
+	for _, code := range codes {
+		if currency, ok := snapshot.Currencies[code]; ok {
+			currencies[code] = currency
+		}
+	}
+	return model.RateSnapshot{Version: snapshot.Version, Currencies: currencies}, nil
+}
+
+func (c *LocalCache) Set(ctx context.Context, currency model.Currency, expiration time.Duration) error {
+	return c.SetMany(ctx, []model.Currency{currency}, expiration)
+}
+
+func (c *LocalCache) SetMany(ctx context.Context, currencies []model.Currency, expiration time.Duration) error {
+	_, err := c.UpdateSnapshot(ctx, currencies, nil, expiration)
+	return err
+}
+
+func (c *LocalCache) Delete(ctx context.Context, code string) error {
+	_, err := c.UpdateSnapshot(ctx, nil, []string{code}, 0)
+	return err
+}
+
+func (c *LocalCache) UpdateSnapshot(ctx context.Context, currencies []model.Currency, deleted []string, expiration time.Duration) (uint64, error) {
+	version, err := c.next.UpdateSnapshot(ctx, currencies, deleted, expiration)
+	if err != nil {
+		c.Flush()
+		return 0, err
+	}
+	c.apply(version, currencies, deleted)
+	return version, nil
+}
+
+func (c *LocalCache) FillSnapshot(ctx context.Context, version uint64, currencies []model.Currency) (bool, error) {
+	filled, err := c.next.FillSnapshot(ctx, version, currencies)
+	if err != nil || !filled {
+		return filled, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.version == version {
+		missing := make([]model.Currency, 0, len(currencies))
+		for _, currency := range currencies {
+			if _, ok := c.entries[currency.Code]; !ok {
+				missing = append(missing, currency)
+			}
+		}
+		c.put(missing)
+	}
+	return true, nil
+}
+
+func (c *LocalCache) ApplyRateChanges(events []model.RateChangeEvent) {
+	if len(events) == 0 {
+		return
+	}
+	changed := make([]string, 0, len(events))
+	for _, event := range events {
+		changed = append(changed, event.Code)
+	}
+	c.apply(events[len(events)-1].Version, nil, changed)
+}
+
+func (c *LocalCache) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reset(0)
+}
+
+func (c *LocalCache) Stats() model.CacheStats {
+	c.mu.Lock()
+	size := c.order.Len()
+	c.mu.Unlock()
+	return model.CacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Coalesced: c.coalesced.Load(),
+		Evictions: c.evictions.Load(),
+		Size:      size,
+		Capacity:  c.capacity,
+	}
+}
+
+func (c *LocalCache) Close() error {
+	return c.next.Close()
+}
+
+func (c *LocalCache) loadSnapshot(codes []string) (model.RateSnapshot, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.version == 0 {
+		return model.RateSnapshot{}, len(codes)
+	}
+	currencies := make(map[string]model.Currency, len(codes))
+	for _, code := range codes {
+		element, ok := c.entries[code]
+		if !ok {
+			continue
+		}
+		entry := element.Value.(*localEntry)
+		if !c.now().Before(entry.expiresAt) {
+			c.remove(element)
+			continue
+		}
+		c.order.MoveToFront(element)
+		currencies[code] = entry.value
+	}
+	return model.RateSnapshot{Version: c.version, Currencies: currencies}, len(codes) - len(currencies)
+}
+
+func (c *LocalCache) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+func (c *LocalCache) storeIfUnchanged(generation uint64, snapshot model.RateSnapshot) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != generation || snapshot.Version == 0 {
+		return
+	}
+	if snapshot.Version != c.version {
+		c.reset(snapshot.Version)
+	}
+	missing := make([]model.Currency, 0, len(snapshot.Currencies))
+	for code, currency := range snapshot.Currencies {
+		if _, ok := c.entries[code]; !ok {
+			missing = append(missing, currency)
+		}
+	}
+	c.put(missing)
+}
+
+func (c *LocalCache) apply(version uint64, currencies []model.Currency, deleted []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case version == 0:
+		c.reset(0)
+		return
+	case version <= c.version:
+		return
+	case version != c.version+1:
+		c.reset(version)
+	}
+	c.generation++
+	c.version = version
+	c.put(currencies)
+	for _, code := range deleted {
+		if element, ok := c.entries[code]; ok {
+			c.remove(element)
+		}
+	}
+}
+
+func (c *LocalCache) reset(version uint64) {
+	c.generation++
+	c.version = version
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+}
+
+func (c *LocalCache) put(currencies []model.Currency) {
+	expiresAt := c.now().Add(c.expiration)
+	for _, currency := range currencies {
+		if element, ok := c.entries[currency.Code]; ok {
+			entry := element.Value.(*localEntry)
+			entry.value = currency
+			entry.expiresAt = expiresAt
+			c.order.MoveToFront(element)
+			continue
+		}
+		c.entries[currency.Code] = c.order.PushFront(&localEntry{key: currency.Code, value: currency, expiresAt: expiresAt})
+		if c.capacity > 0 && c.order.Len() > c.capacity {
+			c.remove(c.order.Back())
+			c.evictions.Add(1)
+		}
+	}
+}
+
+func (c *LocalCache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*localEntry).key)
+}
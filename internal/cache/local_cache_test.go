@@ -0,0 +1,247 @@
+package cache_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Lutefd/challenge-bravo/internal/cache"
+	"github.com/Lutefd/challenge-bravo/internal/model"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalCache(t *testing.T) {
+	redisCache, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	localCache := cache.NewLocalCache(redisCache, 10, time.Minute)
+	defer localCache.Close()
+	ctx := context.Background()
+
+	require.NoError(t, redisCache.Set(ctx, currency("EUR", "0.85"), time.Hour))
+
+	t.Run("Reads through and keeps the value locally", func(t *testing.T) {
+		value, err := localCache.Get(ctx, "EUR")
+		require.NoError(t, err)
+		assert.Equal(t, "0.85", value.Rate.String())
+
+		require.NoError(t, redisCache.Set(ctx, currency("EUR", "0.9"), time.Hour))
+		snapshot, err := localCache.GetSnapshot(ctx, []string{"EUR"})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), snapshot.Version)
+		assert.Equal(t, "0.85", snapshot.Currencies["EUR"].Rate.String())
+	})
+
+	t.Run("Rate change events refresh the local value", func(t *testing.T) {
+		localCache.ApplyRateChanges([]model.RateChangeEvent{{Code: "EUR", Rate: decimal.RequireFromString("0.9"), Version: 2}})
+
+		snapshot, err := localCache.GetSnapshot(ctx, []string{"EUR", "BRL"})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2), snapshot.Version)
+		assert.Equal(t, "0.9", snapshot.Currencies["EUR"].Rate.String())
+		assert.NotContains(t, snapshot.Currencies, "BRL")
+
+		localCache.ApplyRateChanges([]model.RateChangeEvent{{Code: "EUR", Rate: decimal.RequireFromString("0.5"), Version: 1}})
+		value, err := localCache.Get(ctx, "EUR")
+		require.NoError(t, err)
+		assert.Equal(t, "0.9", value.Rate.String())
+
+		require.NoError(t, redisCache.Delete(ctx, "EUR"))
+		localCache.ApplyRateChanges([]model.RateChangeEvent{{Code: "EUR", Deleted: true, Version: 3}})
+		_, err = localCache.Get(ctx, "EUR")
+		assert.Error(t, err)
+	})
+
+	t.Run("A version gap drops every local value", func(t *testing.T) {
+		require.NoError(t, redisCache.Set(ctx, currency("BRL", "5.4"), time.Hour))
+		require.NoError(t, redisCache.Set(ctx, currency("GBP", "0.75"), time.Hour))
+		localCache.ApplyRateChanges([]model.RateChangeEvent{{Code: "GBP", Rate: decimal.RequireFromString("0.75"), Version: 5}})
+
+		snapshot, err := localCache.GetSnapshot(ctx, []string{"BRL", "GBP"})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(5), snapshot.Version)
+		assert.Equal(t, "5.4", snapshot.Currencies["BRL"].Rate.String())
+		assert.Equal(t, "0.75", snapshot.Currencies["GBP"].Rate.String())
+	})
+
+	t.Run("Flush drops every local value", func(t *testing.T) {
+		require.NoError(t, redisCache.Set(ctx, currency("GBP", "0.8"), time.Hour))
+		localCache.Flush()
+
+		value, err := localCache.Get(ctx, "GBP")
+		require.NoError(t, err)
+		assert.Equal(t, "0.8", value.Rate.String())
+	})
+
+	t.Run("Writes go through to Redis", func(t *testing.T) {
+		require.NoError(t, localCache.SetMany(ctx, []model.Currency{currency("BRL", "5.5")}, time.Hour))
+		stored, err := redisCache.Get(ctx, "BRL")
+		require.NoError(t, err)
+		assert.Equal(t, "5.5", stored.Rate.String())
+
+		snapshot, err := localCache.GetSnapshot(ctx, []string{"BRL", "GBP"})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(7), snapshot.Version)
+		assert.Len(t, snapshot.Currencies, 2)
+
+		require.NoError(t, localCache.Delete(ctx, "BRL"))
+		_, err = localCache.Get(ctx, "BRL")
+		assert.Error(t, err)
+	})
+}
+
+func TestLocalCache_Expiration(t *testing.T) {
+	redisCache, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	localCache := cache.NewLocalCache(redisCache, 10, 20*time.Millisecond)
+	defer localCache.Close()
+	ctx := context.Background()
+
+	require.NoError(t, localCache.Set(ctx, currency("EUR", "0.85"), time.Hour))
+	require.NoError(t, redisCache.Set(ctx, currency("EUR", "0.9"), time.Hour))
+
+	time.Sleep(30 * time.Millisecond)
+
+	value, err := localCache.Get(ctx, "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, "0.9", value.Rate.String())
+}
+
+func TestLocalCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	redisCache, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	localCache := cache.NewLocalCache(redisCache, 2, time.Minute)
+	defer localCache.Close()
+	ctx := context.Background()
+
+	require.NoError(t, localCache.SetMany(ctx, []model.Currency{currency("EUR", "0.85")}, time.Hour))
+	require.NoError(t, localCache.Set(ctx, currency("BRL", "5.4"), time.Hour))
+	_, err := localCache.Get(ctx, "EUR")
+	require.NoError(t, err)
+	require.NoError(t, localCache.Set(ctx, currency("GBP", "0.75"), time.Hour))
+
+	values, err := localCache.GetMany(ctx, []string{"EUR", "BRL", "GBP"})
+	require.NoError(t, err)
+	assert.Equal(t, "0.85", values["EUR"].Rate.String())
+	assert.Equal(t, "5.4", values["BRL"].Rate.String())
+	assert.Equal(t, "0.75", values["GBP"].Rate.String())
+
+	stats := localCache.Stats()
+	assert.Equal(t, uint64(3), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, uint64(2), stats.Evictions)
+	assert.Equal(t, 2, stats.Size)
+	assert.Equal(t, 2, stats.Capacity)
+}
+
+type blockingCache struct {
+	cache.Cache
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (c *blockingCache) GetSnapshot(ctx context.Context, keys []string) (model.RateSnapshot, error) {
+	c.calls.Add(1)
+	select {
+	case <-c.release:
+	case <-ctx.Done():
+		return model.RateSnapshot{}, ctx.Err()
+	}
+	return model.RateSnapshot{Version: 1, Currencies: map[string]model.Currency{"EUR": currency("EUR", "0.85")}}, nil
+}
+
+func TestLocalCache_CoalescesMisses(t *testing.T) {
+	next := &blockingCache{release: make(chan struct{})}
+	localCache := cache.NewLocalCache(next, 10, time.Minute)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	results := make([]model.Currency, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			value, err := localCache.Get(ctx, "EUR")
+			assert.NoError(t, err)
+			results[i] = value
+		}(i)
+	}
+	require.Eventually(t, func() bool { return localCache.Stats().Misses == 5 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(next.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), next.calls.Load())
+	for _, value := range results {
+		assert.Equal(t, "0.85", value.Rate.String())
+	}
+	stats := localCache.Stats()
+	assert.Equal(t, uint64(4), stats.Coalesced)
+	assert.Equal(t, 1, stats.Size)
+
+	value, err := localCache.Get(ctx, "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, "0.85", value.Rate.String())
+	assert.Equal(t, int32(1), next.calls.Load())
+	assert.Equal(t, uint64(1), localCache.Stats().Hits)
+}
+
+func TestLocalCache_CanceledLeaderDoesNotFailFollowers(t *testing.T) {
+	next := &blockingCache{release: make(chan struct{})}
+	localCache := cache.NewLocalCache(next, 10, time.Minute)
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := localCache.Get(leaderCtx, "EUR")
+		leaderErr <- err
+	}()
+	require.Eventually(t, func() bool { return next.calls.Load() == 1 }, time.Second, time.Millisecond)
+
+	followerErr := make(chan error, 1)
+	var followerValue model.Currency
+	go func() {
+		value, err := localCache.Get(context.Background(), "EUR")
+		followerValue = value
+		followerErr <- err
+	}()
+	require.Eventually(t, func() bool { return localCache.Stats().Misses == 2 }, time.Second, time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-leaderErr, context.Canceled)
+
+	close(next.release)
+	require.NoError(t, <-followerErr)
+	assert.Equal(t, "0.85", followerValue.Rate.String())
+	assert.Equal(t, int32(1), next.calls.Load())
+}
+
+func TestLocalCache_FillSnapshot(t *testing.T) {
+	redisCache, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	localCache := cache.NewLocalCache(redisCache, 10, time.Minute)
+	defer localCache.Close()
+	ctx := context.Background()
+
+	version, err := redisCache.UpdateSnapshot(ctx, []model.Currency{currency("EUR", "0.85")}, nil, time.Hour)
+	require.NoError(t, err)
+	_, err = localCache.Get(ctx, "EUR")
+	require.NoError(t, err)
+
+	filled, err := localCache.FillSnapshot(ctx, version, []model.Currency{currency("BRL", "5.43")})
+	require.NoError(t, err)
+	assert.True(t, filled)
+
+	mr.Close()
+	snapshot, err := localCache.GetSnapshot(ctx, []string{"EUR", "BRL"})
+	require.NoError(t, err)
+	assert.Equal(t, version, snapshot.Version)
+	assert.Equal(t, "5.43", snapshot.Currencies["BRL"].Rate.String())
+}
@@ -0,0 +1,153 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Lutefd/challenge-bravo/internal/model"
+)
+
+type MemoryCache struct {
+	now func() time.Time
+
+	mu          sync.Mutex
+	version     uint64
+	currencies  map[string]model.Currency
+	expiresAt   time.Time
+	subscribers map[*memorySubscriber]struct{}
+}
+
+type memorySubscriber struct {
+	handler RateChangeHandler
+}
+
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		now:         time.Now,
+		currencies:  make(map[string]model.Currency),
+		subscribers: make(map[*memorySubscriber]struct{}),
+	}
+}
+
+func (c *MemoryCache) Get(ctx context.Context, code string) (model.Currency, error) {
+	snapshot, err := c.GetSnapshot(ctx, []string{code})
+	if err != nil {
+		return model.Currency{}, err
+	}
+	currency, ok := snapshot.Currencies[code]
+	if !ok {
+		return model.Currency{}, fmt.Errorf("key not found")
+	}
+	return currency, nil
+}
+
+func (c *MemoryCache) GetMany(ctx context.Context, codes []string) (map[string]model.Currency, error) {
+	snapshot, err := c.GetSnapshot(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.Currencies, nil
+}
+
+func (c *MemoryCache) GetSnapshot(ctx context.Context, codes []string) (model.RateSnapshot, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	snapshot := model.RateSnapshot{Currencies: make(map[string]model.Currency, len(codes))}
+	if !c.expiresAt.IsZero() && !c.now().Before(c.expiresAt) {
+		return snapshot, nil
+	}
+	snapshot.Version = c.version
+	for _, code := range codes {
+		if currency, ok := c.currencies[code]; ok {
+			snapshot.Currencies[code] = currency
+		}
+	}
+	return snapshot, nil
+}
+
+func (c *MemoryCache) Set(ctx context.Context, currency model.Currency, expiration time.Duration) error {
+	return c.SetMany(ctx, []model.Currency{currency}, expiration)
+}
+
+func (c *MemoryCache) SetMany(ctx context.Context, currencies []model.Currency, expiration time.Duration) error {
+	_, err := c.UpdateSnapshot(ctx, currencies, nil, expiration)
+	return err
+}
+
+func (c *MemoryCache) Delete(ctx context.Context, code string) error {
+	_, err := c.UpdateSnapshot(ctx, nil, []string{code}, 0)
+	return err
+}
+
+func (c *MemoryCache) UpdateSnapshot(ctx context.Context, currencies []model.Currency, deleted []string, expiration time.Duration) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.expiresAt.IsZero() && !c.now().Before(c.expiresAt) {
+		c.currencies = make(map[string]model.Currency)
+		c.expiresAt = time.Time{}
+	}
+	for _, currency := range currencies {
+		c.currencies[currency.Code] = currency
+	}
+	for _, code := range deleted {
+		delete(c.currencies, code)
+	}
+	if expiration > 0 {
+		c.expiresAt = c.now().Add(expiration)
+	}
+	c.version++
+	return c.version, nil
+}
+
+func (c *MemoryCache) FillSnapshot(ctx context.Context, version uint64, currencies []model.Currency) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if version == 0 || version != c.version || (!c.expiresAt.IsZero() && !c.now().Before(c.expiresAt)) {
+		return false, nil
+	}
+	for _, currency := range currencies {
+		if _, ok := c.currencies[currency.Code]; !ok {
+			c.currencies[currency.Code] = currency
+		}
+	}
+	return true, nil
+}
+
+func (c *MemoryCache) PublishRateChanges(ctx context.Context, events []model.RateChangeEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	handlers := make([]RateChangeHandler, 0, len(c.subscribers))
+	for subscriber := range c.subscribers {
+		handlers = append(handlers, subscriber.handler)
+	}
+	c.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler.ApplyRateChanges(events)
+	}
+	return nil
+}
+
+func (c *MemoryCache) SubscribeRateChanges(ctx context.Context, handler RateChangeHandler) error {
+	subscriber := &memorySubscriber{handler: handler}
+	c.mu.Lock()
+	c.subscribers[subscriber] = struct{}{}
+	c.mu.Unlock()
+	handler.Flush()
+
+	<-ctx.Done()
+
+	c.mu.Lock()
+	delete(c.subscribers, subscriber)
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *MemoryCache) Close() error {
+	return nil
+}
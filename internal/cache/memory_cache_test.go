@@ -0,0 +1,139 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Lutefd/challenge-bravo/internal/cache"
+	"github.com/Lutefd/challenge-bravo/internal/commons"
+	"github.com/Lutefd/challenge-bravo/internal/model"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryCache(t *testing.T) {
+	memoryCache := cache.NewMemoryCache()
+	defer memoryCache.Close()
+	ctx := context.Background()
+
+	t.Run("Set and get", func(t *testing.T) {
+		require.NoError(t, memoryCache.Set(ctx, currency("EUR", "0.85"), time.Minute))
+
+		value, err := memoryCache.Get(ctx, "EUR")
+		require.NoError(t, err)
+		assert.Equal(t, "0.85", value.Rate.String())
+
+		_, err = memoryCache.Get(ctx, "BRL")
+		assert.EqualError(t, err, "key not found")
+	})
+
+	t.Run("Many values", func(t *testing.T) {
+		require.NoError(t, memoryCache.SetMany(ctx, []model.Currency{
+			currency("USD", "1"),
+			currency("JPY", "150.5"),
+		}, 0))
+
+		values, err := memoryCache.GetMany(ctx, []string{"USD", "JPY", "BRL"})
+		require.NoError(t, err)
+		assert.Len(t, values, 2)
+		assert.Equal(t, "150.5", values["JPY"].Rate.String())
+	})
+
+	t.Run("Expired values are dropped", func(t *testing.T) {
+		require.NoError(t, memoryCache.Set(ctx, currency("GBP", "0.78"), 10*time.Millisecond))
+		time.Sleep(20 * time.Millisecond)
+
+		_, err := memoryCache.Get(ctx, "GBP")
+		assert.EqualError(t, err, "key not found")
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, memoryCache.Delete(ctx, "EUR"))
+
+		_, err := memoryCache.Get(ctx, "EUR")
+		assert.EqualError(t, err, "key not found")
+	})
+}
+
+func TestMemoryCache_Snapshots(t *testing.T) {
+	memoryCache := cache.NewMemoryCache()
+	ctx := context.Background()
+
+	snapshot, err := memoryCache.GetSnapshot(ctx, []string{"EUR"})
+	require.NoError(t, err)
+	assert.Zero(t, snapshot.Version)
+
+	version, err := memoryCache.UpdateSnapshot(ctx, []model.Currency{
+		currency("EUR", "0.85"),
+		currency("BRL", "5.43"),
+	}, nil, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), version)
+
+	version, err = memoryCache.UpdateSnapshot(ctx, []model.Currency{currency("EUR", "0.86")}, []string{"BRL"}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), version)
+
+	snapshot, err = memoryCache.GetSnapshot(ctx, []string{"EUR", "BRL"})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), snapshot.Version)
+	assert.Len(t, snapshot.Currencies, 1)
+	assert.Equal(t, "0.86", snapshot.Currencies["EUR"].Rate.String())
+
+	filled, err := memoryCache.FillSnapshot(ctx, 1, []model.Currency{currency("BRL", "5.43")})
+	require.NoError(t, err)
+	assert.False(t, filled)
+
+	filled, err = memoryCache.FillSnapshot(ctx, 2, []model.Currency{currency("BRL", "5.44"), currency("EUR", "0.8")})
+	require.NoError(t, err)
+	assert.True(t, filled)
+
+	snapshot, err = memoryCache.GetSnapshot(ctx, []string{"EUR", "BRL"})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), snapshot.Version)
+	assert.Equal(t, "0.86", snapshot.Currencies["EUR"].Rate.String())
+	assert.Equal(t, "5.44", snapshot.Currencies["BRL"].Rate.String())
+}
+
+func TestMemoryCache_RateChanges(t *testing.T) {
+	memoryCache := cache.NewMemoryCache()
+	handler := &recordingHandler{events: make(chan []model.RateChangeEvent, 1), flushed: make(chan struct{}, 1)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- memoryCache.SubscribeRateChanges(ctx, handler)
+	}()
+	select {
+	case <-handler.flushed:
+	case <-time.After(time.Second):
+		t.Fatal("expected the subscription to flush the handler")
+	}
+
+	events := []model.RateChangeEvent{{Code: "EUR", Rate: decimal.RequireFromString("0.9")}}
+	require.NoError(t, memoryCache.PublishRateChanges(context.Background(), events))
+	assert.Equal(t, events, <-handler.events)
+
+	cancel()
+	require.NoError(t, <-done)
+
+	require.NoError(t, memoryCache.PublishRateChanges(context.Background(), events))
+	assert.Empty(t, handler.events)
+}
+
+func TestNewRateCache(t *testing.T) {
+	rateCache, err := cache.NewRateCache(commons.Config{CacheDriver: commons.CacheDriverMemory})
+	require.NoError(t, err)
+	assert.IsType(t, &cache.MemoryCache{}, rateCache)
+
+	redisCache, mr := setupTestRedis(t)
+	defer mr.Close()
+	defer redisCache.Close()
+
+	rateCache, err = cache.NewRateCache(commons.Config{CacheDriver: commons.CacheDriverRedis, RedisAddr: mr.Addr()})
+	require.NoError(t, err)
+	defer rateCache.Close()
+	assert.IsType(t, &cache.RedisCache{}, rateCache)
+}
@@ -2,65 +2,363 @@ package cache
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
 	"fmt"
+	"os"
 	"strconv"
 	"time"
 
+	"github.com/Lutefd/challenge-bravo/internal/commons"
+	"github.com/Lutefd/challenge-bravo/internal/logger"
+	"github.com/Lutefd/challenge-bravo/internal/model"
 	"github.com/redis/go-redis/v9"
 )
 
 type RedisCache struct {
-	client *redis.Client
+	client redis.UniversalClient
 }
 
-func NewRedisCache(addr, password string) (*RedisCache, error) {
-	client := redis.NewClient(&redis.Options{
-		Addr:     addr,
-		Password: password,
-		DB:       0,
-	})
+type RedisConfig struct {
+	Addr             string
+	Password         string
+	DB               int
+	SentinelMaster   string
+	SentinelAddrs    []string
+	SentinelPassword string
+	ClusterAddrs     []string
+	TLS              bool
+	TLSCACert        string
+	TLSCert          string
+	TLSKey           string
+	PoolSize         int
+	MinIdleConns     int
+}
 
-	_, err := client.Ping(context.Background()).Result()
+func NewRedisCache(config RedisConfig) (*RedisCache, error) {
+	tlsConfig, err := redisTLSConfig(config)
 	if err != nil {
+		return nil, err
+	}
+	client := newRedisClient(config, tlsConfig)
+
+	_, err = client.Ping(context.Background()).Result()
+	if err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
 	return &RedisCache{client: client}, nil
 }
 
-func (c *RedisCache) Get(ctx context.Context, key string) (float64, error) {
-	val, err := c.client.Get(ctx, key).Result()
-	if err != nil {
-		if err == redis.Nil {
-			return 0, fmt.Errorf("key not found")
+func newRedisClient(config RedisConfig, tlsConfig *tls.Config) redis.UniversalClient {
+	switch {
+	case config.SentinelMaster != "":
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       config.SentinelMaster,
+			SentinelAddrs:    config.SentinelAddrs,
+			SentinelPassword: config.SentinelPassword,
+			Password:         config.Password,
+			DB:               config.DB,
+			TLSConfig:        tlsConfig,
+			PoolSize:         config.PoolSize,
+			MinIdleConns:     config.MinIdleConns,
+		})
+	case len(config.ClusterAddrs) > 0:
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        config.ClusterAddrs,
+			Password:     config.Password,
+			TLSConfig:    tlsConfig,
+			PoolSize:     config.PoolSize,
+			MinIdleConns: config.MinIdleConns,
+		})
+	default:
+		return redis.NewClient(&redis.Options{
+			Addr:         config.Addr,
+			Password:     config.Password,
+			DB:           config.DB,
+			TLSConfig:    tlsConfig,
+			PoolSize:     config.PoolSize,
+			MinIdleConns: config.MinIdleConns,
+		})
+	}
+}
+
+func redisTLSConfig(config RedisConfig) (*tls.Config, error) {
+	if !config.TLS {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if config.TLSCACert != "" {
+		caCert, err := os.ReadFile(config.TLSCACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Redis CA certificate: %w", err)
 		}
-		return 0, fmt.Errorf("failed to get from cache: %w", err)
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("failed to parse Redis CA certificate %s", config.TLSCACert)
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+	if config.TLSCert != "" || config.TLSKey != "" {
+		certificate, err := tls.LoadX509KeyPair(config.TLSCert, config.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Redis client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
 	}
+	return tlsConfig, nil
+}
 
-	rate, err := strconv.ParseFloat(val, 64)
+type cacheEntry struct {
+	Schema   int            `json:"schema"`
+	Currency model.Currency `json:"currency"`
+}
+
+func encodeCacheEntry(currency model.Currency) (string, error) {
+	encoded, err := json.Marshal(cacheEntry{Schema: commons.CacheSchemaVersion, Currency: currency})
 	if err != nil {
-		return 0, fmt.Errorf("failed to parse cached value: %w", err)
+		return "", err
 	}
+	return string(encoded), nil
+}
 
-	return rate, nil
+func decodeCacheEntry(value string) (model.Currency, bool) {
+	var entry cacheEntry
+	if err := json.Unmarshal([]byte(value), &entry); err != nil || entry.Schema != commons.CacheSchemaVersion {
+		return model.Currency{}, false
+	}
+	return entry.Currency, true
 }
 
-func (c *RedisCache) Set(ctx context.Context, key string, value float64, expiration time.Duration) error {
-	err := c.client.Set(ctx, key, value, expiration).Err()
+func cacheKey(name string) string {
+	return fmt.Sprintf("%s:v%d:{rates}:%s", commons.CacheKeyPrefix, commons.CacheSchemaVersion, name)
+}
+
+var (
+	snapshotPointerKey = cacheKey("current")
+	snapshotVersionKey = cacheKey("version")
+	snapshotKeyPrefix  = cacheKey("snapshot:")
+)
+
+var readSnapshotScript = redis.NewScript(`
+local version = redis.call('GET', KEYS[1])
+if not version then
+	return {0, {}}
+end
+if #ARGV < 2 then
+	return {tonumber(version), {}}
+end
+return {tonumber(version), redis.call('HMGET', ARGV[1] .. version, unpack(ARGV, 2))}
+`)
+
+var updateSnapshotScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+local version = redis.call('INCR', KEYS[2])
+local key = ARGV[1] .. version
+local ttl = tonumber(ARGV[2])
+if current then
+	local previous = ARGV[1] .. current
+	local fields = redis.call('HGETALL', previous)
+	if #fields > 0 then
+		redis.call('HSET', key, unpack(fields))
+	end
+	if ttl == 0 then
+		ttl = math.max(redis.call('PTTL', previous), 0)
+	end
+	redis.call('DEL', previous)
+end
+local count = tonumber(ARGV[3])
+if count > 0 then
+	redis.call('HSET', key, unpack(ARGV, 4, 3 + count * 2))
+end
+for i = 4 + count * 2, #ARGV do
+	redis.call('HDEL', key, ARGV[i])
+end
+if ttl > 0 then
+	redis.call('SET', KEYS[1], version, 'PX', ttl)
+	redis.call('PEXPIRE', key, ttl)
+else
+	redis.call('SET', KEYS[1], version)
+end
+return version
+`)
+
+var fillSnapshotScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if current ~= ARGV[2] then
+	return 0
+end
+local key = ARGV[1] .. current
+for i = 3, #ARGV, 2 do
+	redis.call('HSETNX', key, ARGV[i], ARGV[i + 1])
+end
+return 1
+`)
+
+func (c *RedisCache) Get(ctx context.Context, code string) (model.Currency, error) {
+	snapshot, err := c.GetSnapshot(ctx, []string{code})
 	if err != nil {
-		return fmt.Errorf("failed to set in cache: %w", err)
+		return model.Currency{}, err
 	}
-	return nil
+	currency, ok := snapshot.Currencies[code]
+	if !ok {
+		return model.Currency{}, fmt.Errorf("key not found")
+	}
+	return currency, nil
+}
+
+func (c *RedisCache) GetMany(ctx context.Context, codes []string) (map[string]model.Currency, error) {
+	snapshot, err := c.GetSnapshot(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.Currencies, nil
+}
+
+func (c *RedisCache) GetSnapshot(ctx context.Context, codes []string) (model.RateSnapshot, error) {
+	args := make([]interface{}, 0, len(codes)+1)
+	args = append(args, snapshotKeyPrefix)
+	for _, code := range codes {
+		args = append(args, code)
+	}
+	result, err := readSnapshotScript.Run(ctx, c.client, []string{snapshotPointerKey}, args...).Slice()
+	if err != nil {
+		return model.RateSnapshot{}, fmt.Errorf("failed to get from cache: %w", err)
+	}
+
+	version, _ := result[0].(int64)
+	vals, _ := result[1].([]interface{})
+	snapshot := model.RateSnapshot{Version: uint64(version), Currencies: make(map[string]model.Currency, len(codes))}
+	for i, val := range vals {
+		str, ok := val.(string)
+		if !ok {
+			continue
+		}
+		currency, ok := decodeCacheEntry(str)
+		if !ok || currency.Code != codes[i] {
+			logger.Infof("ignoring cached entry for %s written by another cache schema", codes[i])
+			continue
+		}
+		snapshot.Currencies[codes[i]] = currency
+	}
+
+	return snapshot, nil
+}
+
+func (c *RedisCache) Set(ctx context.Context, currency model.Currency, expiration time.Duration) error {
+	return c.SetMany(ctx, []model.Currency{currency}, expiration)
 }
 
-func (c *RedisCache) Delete(ctx context.Context, key string) error {
-	err := c.client.Del(ctx, key).Err()
+func (c *RedisCache) SetMany(ctx context.Context, currencies []model.Currency, expiration time.Duration) error {
+	if len(currencies) == 0 {
+		return nil
+	}
+	_, err := c.UpdateSnapshot(ctx, currencies, nil, expiration)
+	return err
+}
+
+func (c *RedisCache) Delete(ctx context.Context, code string) error {
+	_, err := c.UpdateSnapshot(ctx, nil, []string{code}, 0)
+	return err
+}
+
+func (c *RedisCache) UpdateSnapshot(ctx context.Context, currencies []model.Currency, deleted []string, expiration time.Duration) (uint64, error) {
+	args := make([]interface{}, 0, 3+len(currencies)*2+len(deleted))
+	args = append(args, snapshotKeyPrefix, expiration.Milliseconds(), len(currencies))
+	for _, currency := range currencies {
+		entry, err := encodeCacheEntry(currency)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode cached value for %s: %w", currency.Code, err)
+		}
+		args = append(args, currency.Code, entry)
+	}
+	for _, code := range deleted {
+		args = append(args, code)
+	}
+
+	version, err := updateSnapshotScript.Run(ctx, c.client, []string{snapshotPointerKey, snapshotVersionKey}, args...).Uint64()
 	if err != nil {
-		return fmt.Errorf("failed to delete from cache: %w", err)
+		return 0, fmt.Errorf("failed to set in cache: %w", err)
+	}
+	return version, nil
+}
+
+func (c *RedisCache) FillSnapshot(ctx context.Context, version uint64, currencies []model.Currency) (bool, error) {
+	if version == 0 || len(currencies) == 0 {
+		return false, nil
+	}
+	args := make([]interface{}, 0, 2+len(currencies)*2)
+	args = append(args, snapshotKeyPrefix, strconv.FormatUint(version, 10))
+	for _, currency := range currencies {
+		entry, err := encodeCacheEntry(currency)
+		if err != nil {
+			return false, fmt.Errorf("failed to encode cached value for %s: %w", currency.Code, err)
+		}
+		args = append(args, currency.Code, entry)
+	}
+
+	filled, err := fillSnapshotScript.Run(ctx, c.client, []string{snapshotPointerKey}, args...).Bool()
+	if err != nil {
+		return false, fmt.Errorf("failed to fill cache: %w", err)
+	}
+	return filled, nil
+}
+
+func (c *RedisCache) PublishRateChanges(ctx context.Context, events []model.RateChangeEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("failed to encode rate changes: %w", err)
+	}
+	if err := c.client.Publish(ctx, commons.RateChangeChannel, payload).Err(); err != nil {
+		return fmt.Errorf("failed to publish rate changes: %w", err)
 	}
 	return nil
 }
 
+func (c *RedisCache) SubscribeRateChanges(ctx context.Context, handler RateChangeHandler) error {
+	pubsub := c.client.Subscribe(ctx, commons.RateChangeChannel)
+	go func() {
+		<-ctx.Done()
+		pubsub.Close()
+	}()
+
+	for {
+		msg, err := pubsub.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			logger.Errorf("rate change subscription interrupted: %v", err)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			handler.Flush()
+		case *redis.Message:
+			var events []model.RateChangeEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &events); err != nil {
+				logger.Errorf("failed to decode rate changes: %v", err)
+				handler.Flush()
+				continue
+			}
+			handler.ApplyRateChanges(events)
+		}
+	}
+}
+
 func (c *RedisCache) Close() error {
 	return c.client.Close()
 }
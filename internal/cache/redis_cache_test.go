@@ -2,12 +2,26 @@ package cache_test
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/Lutefd/challenge-bravo/internal/cache"
+	"github.com/Lutefd/challenge-bravo/internal/model"
 	"github.com/alicebob/miniredis/v2"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func setupTestRedis(t *testing.T) (*cache.RedisCache, *miniredis.Miniredis) {
@@ -16,7 +30,7 @@ func setupTestRedis(t *testing.T) (*cache.RedisCache, *miniredis.Miniredis) {
 		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
 	}
 
-	redisCache, err := cache.NewRedisCache(mr.Addr(), "")
+	redisCache, err := cache.NewRedisCache(cache.RedisConfig{Addr: mr.Addr()})
 	if err != nil {
 		t.Fatalf("failed to create Redis cache: %v", err)
 	}
@@ -24,6 +38,10 @@ func setupTestRedis(t *testing.T) (*cache.RedisCache, *miniredis.Miniredis) {
 	return redisCache, mr
 }
 
+func currency(code, rate string) model.Currency {
+	return model.Currency{Code: code, Rate: decimal.RequireFromString(rate)}
+}
+
 func TestNewRedisCache(t *testing.T) {
 	redisCache, mr := setupTestRedis(t)
 	defer mr.Close()
@@ -32,6 +50,116 @@ func TestNewRedisCache(t *testing.T) {
 	assert.NotNil(t, redisCache)
 }
 
+func TestNewRedisCache_Database(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisCache, err := cache.NewRedisCache(cache.RedisConfig{Addr: mr.Addr(), DB: 3, PoolSize: 4, MinIdleConns: 1})
+	require.NoError(t, err)
+	defer redisCache.Close()
+
+	require.NoError(t, redisCache.Set(context.Background(), currency("EUR", "0.86"), time.Minute))
+
+	assert.True(t, mr.DB(3).Exists("challenge-bravo:v3:{rates}:current"))
+	assert.False(t, mr.DB(0).Exists("challenge-bravo:v3:{rates}:current"))
+}
+
+func TestNewRedisCache_Cluster(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisCache, err := cache.NewRedisCache(cache.RedisConfig{ClusterAddrs: []string{mr.Addr()}})
+	require.NoError(t, err)
+	defer redisCache.Close()
+
+	ctx := context.Background()
+	require.NoError(t, redisCache.Set(ctx, currency("EUR", "0.86"), time.Minute))
+
+	value, err := redisCache.Get(ctx, "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, "0.86", value.Rate.String())
+}
+
+func TestNewRedisCache_TLS(t *testing.T) {
+	certFile, keyFile, certificate := writeTestCertificate(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(certificate.Leaf)
+
+	mr := miniredis.NewMiniRedis()
+	require.NoError(t, mr.StartTLS(&tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}))
+	defer mr.Close()
+
+	redisCache, err := cache.NewRedisCache(cache.RedisConfig{
+		Addr:      mr.Addr(),
+		TLS:       true,
+		TLSCACert: certFile,
+		TLSCert:   certFile,
+		TLSKey:    keyFile,
+	})
+	require.NoError(t, err)
+	defer redisCache.Close()
+
+	ctx := context.Background()
+	require.NoError(t, redisCache.Set(ctx, currency("EUR", "0.86"), time.Minute))
+	value, err := redisCache.Get(ctx, "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, "0.86", value.Rate.String())
+
+	_, err = cache.NewRedisCache(cache.RedisConfig{Addr: mr.Addr(), TLS: true, TLSCACert: certFile})
+	assert.ErrorContains(t, err, "failed to connect to Redis")
+}
+
+func TestNewRedisCache_InvalidTLSFiles(t *testing.T) {
+	certFile, keyFile, _ := writeTestCertificate(t)
+	invalidFile := filepath.Join(t.TempDir(), "invalid.pem")
+	require.NoError(t, os.WriteFile(invalidFile, []byte("not a certificate"), 0o600))
+
+	_, err := cache.NewRedisCache(cache.RedisConfig{TLS: true, TLSCACert: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.ErrorContains(t, err, "failed to read Redis CA certificate")
+
+	_, err = cache.NewRedisCache(cache.RedisConfig{TLS: true, TLSCACert: invalidFile})
+	assert.ErrorContains(t, err, "failed to parse Redis CA certificate")
+
+	_, err = cache.NewRedisCache(cache.RedisConfig{TLS: true, TLSCert: certFile, TLSKey: invalidFile})
+	assert.ErrorContains(t, err, "failed to load Redis client certificate")
+
+	_, err = cache.NewRedisCache(cache.RedisConfig{TLS: true, TLSCert: keyFile})
+	assert.ErrorContains(t, err, "failed to load Redis client certificate")
+}
+
+func writeTestCertificate(t *testing.T) (string, string, tls.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "redis"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	certFile, keyFile := filepath.Join(dir, "redis.crt"), filepath.Join(dir, "redis.key")
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+	require.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	certificate.Leaf, err = x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return certFile, keyFile, certificate
+}
+
 func TestGet(t *testing.T) {
 	redisCache, mr := setupTestRedis(t)
 	defer mr.Close()
@@ -43,17 +171,88 @@ func TestGet(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "key not found")
 
-	err = redisCache.Set(ctx, "test_key", 123.45, time.Minute)
+	err = redisCache.Set(ctx, currency("test_key", "123.45"), time.Minute)
 	assert.NoError(t, err)
 
 	value, err := redisCache.Get(ctx, "test_key")
 	assert.NoError(t, err)
-	assert.Equal(t, 123.45, value)
+	assert.Equal(t, "123.45", value.Rate.String())
 
-	mr.Set("invalid_key", "not_a_float")
+	mr.HSet("challenge-bravo:v3:{rates}:snapshot:1", "invalid_key", "not_a_float")
 	_, err = redisCache.Get(ctx, "invalid_key")
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "failed to parse cached value")
+	assert.Contains(t, err.Error(), "key not found")
+}
+
+func TestGetMany(t *testing.T) {
+	redisCache, mr := setupTestRedis(t)
+	defer mr.Close()
+	defer redisCache.Close()
+
+	ctx := context.Background()
+
+	rates, err := redisCache.GetMany(ctx, []string{})
+	assert.NoError(t, err)
+	assert.Empty(t, rates)
+
+	assert.NoError(t, redisCache.Set(ctx, currency("USD", "1"), time.Minute))
+	assert.NoError(t, redisCache.Set(ctx, currency("BTC", "0.000016"), time.Minute))
+
+	rates, err = redisCache.GetMany(ctx, []string{"USD", "EUR", "BTC"})
+	assert.NoError(t, err)
+	assert.Len(t, rates, 2)
+	assert.Equal(t, "1", rates["USD"].Rate.String())
+	assert.Equal(t, "0.000016", rates["BTC"].Rate.String())
+	assert.NotContains(t, rates, "EUR")
+
+	mr.HSet("challenge-bravo:v3:{rates}:snapshot:2", "invalid_key", "not_a_float")
+	rates, err = redisCache.GetMany(ctx, []string{"USD", "invalid_key"})
+	assert.NoError(t, err)
+	assert.Len(t, rates, 1)
+}
+
+func TestCacheEntries(t *testing.T) {
+	redisCache, mr := setupTestRedis(t)
+	defer mr.Close()
+	defer redisCache.Close()
+
+	ctx := context.Background()
+	updatedAt := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+	brl := model.Currency{
+		Code:      "BRL",
+		Rate:      decimal.RequireFromString("5.43"),
+		UpdatedAt: updatedAt,
+		Source:    model.CurrencySourceProvider,
+		Providers: []string{"ecb"},
+		CurrencyMetadata: model.CurrencyMetadata{
+			Name:       "Brazilian Real",
+			Symbol:     "R$",
+			MinorUnits: model.MinorUnits(2),
+			Countries:  []string{"BR"},
+			Kind:       model.CurrencyKindFiat,
+		},
+	}
+
+	t.Run("Stores the whole currency under a versioned key", func(t *testing.T) {
+		assert.NoError(t, redisCache.Set(ctx, brl, time.Minute))
+
+		stored := mr.HGet("challenge-bravo:v3:{rates}:snapshot:1", "BRL")
+		assert.Contains(t, stored, `"schema":3`)
+		assert.Contains(t, stored, `"source":"provider"`)
+
+		value, err := redisCache.Get(ctx, "BRL")
+		assert.NoError(t, err)
+		assert.Equal(t, brl, value)
+	})
+
+	t.Run("Ignores entries written by older deployments", func(t *testing.T) {
+		assert.NoError(t, mr.Set("EUR", "0.85"))
+		mr.HSet("challenge-bravo:v3:{rates}:snapshot:1", "EUR", `{"schema":1,"currency":{"code":"EUR","rate":"0.85"}}`)
+
+		_, err := redisCache.Get(ctx, "EUR")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "key not found")
+	})
 }
 
 func TestSet(t *testing.T) {
@@ -63,19 +262,174 @@ func TestSet(t *testing.T) {
 
 	ctx := context.Background()
 
-	err := redisCache.Set(ctx, "test_key", 123.45, time.Minute)
+	err := redisCache.Set(ctx, currency("test_key", "123.45"), time.Minute)
 	assert.NoError(t, err)
 
 	value, err := redisCache.Get(ctx, "test_key")
 	assert.NoError(t, err)
-	assert.Equal(t, 123.45, value)
+	assert.Equal(t, "123.45", value.Rate.String())
 
-	err = redisCache.Set(ctx, "no_expiration_key", 678.90, 0)
+	err = redisCache.Set(ctx, currency("no_expiration_key", "678.90"), 0)
 	assert.NoError(t, err)
 
 	value, err = redisCache.Get(ctx, "no_expiration_key")
 	assert.NoError(t, err)
-	assert.Equal(t, 678.90, value)
+	assert.Equal(t, "678.9", value.Rate.String())
+
+	err = redisCache.Set(ctx, currency("precise_key", "0.000015873412345678901234"), 0)
+	assert.NoError(t, err)
+
+	value, err = redisCache.Get(ctx, "precise_key")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.000015873412345678901234", value.Rate.String())
+}
+
+func TestSetMany(t *testing.T) {
+	redisCache, mr := setupTestRedis(t)
+	defer mr.Close()
+	defer redisCache.Close()
+
+	ctx := context.Background()
+
+	err := redisCache.SetMany(ctx, []model.Currency{
+		currency("EUR", "0.85"),
+		currency("BRL", "5.43"),
+	}, time.Minute)
+	assert.NoError(t, err)
+
+	values, err := redisCache.GetMany(ctx, []string{"EUR", "BRL"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0.85", values["EUR"].Rate.String())
+	assert.Equal(t, "5.43", values["BRL"].Rate.String())
+	assert.Equal(t, time.Minute, mr.TTL("challenge-bravo:v3:{rates}:snapshot:1"))
+	assert.Equal(t, time.Minute, mr.TTL("challenge-bravo:v3:{rates}:current"))
+
+	assert.NoError(t, redisCache.SetMany(ctx, nil, time.Minute))
+}
+
+func TestSnapshots(t *testing.T) {
+	redisCache, mr := setupTestRedis(t)
+	defer mr.Close()
+	defer redisCache.Close()
+
+	ctx := context.Background()
+
+	snapshot, err := redisCache.GetSnapshot(ctx, []string{"EUR"})
+	assert.NoError(t, err)
+	assert.Zero(t, snapshot.Version)
+	assert.Empty(t, snapshot.Currencies)
+
+	version, err := redisCache.UpdateSnapshot(ctx, []model.Currency{
+		currency("EUR", "0.85"),
+		currency("BRL", "5.43"),
+	}, nil, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), version)
+
+	version, err = redisCache.UpdateSnapshot(ctx, []model.Currency{currency("EUR", "0.86")}, []string{"BRL"}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), version)
+	assert.False(t, mr.Exists("challenge-bravo:v3:{rates}:snapshot:1"))
+	assert.Equal(t, time.Minute, mr.TTL("challenge-bravo:v3:{rates}:snapshot:2"))
+
+	snapshot, err = redisCache.GetSnapshot(ctx, []string{"EUR", "BRL"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), snapshot.Version)
+	assert.Equal(t, map[string]model.Currency{"EUR": currency("EUR", "0.86")}, snapshot.Currencies)
+
+	snapshot, err = redisCache.GetSnapshot(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), snapshot.Version)
+	assert.Empty(t, snapshot.Currencies)
+}
+
+func TestFillSnapshot(t *testing.T) {
+	redisCache, mr := setupTestRedis(t)
+	defer mr.Close()
+	defer redisCache.Close()
+
+	ctx := context.Background()
+
+	filled, err := redisCache.FillSnapshot(ctx, 1, []model.Currency{currency("EUR", "0.85")})
+	assert.NoError(t, err)
+	assert.False(t, filled)
+	assert.False(t, mr.Exists("challenge-bravo:v3:{rates}:snapshot:1"))
+
+	version, err := redisCache.UpdateSnapshot(ctx, []model.Currency{currency("EUR", "0.86")}, nil, time.Minute)
+	require.NoError(t, err)
+
+	filled, err = redisCache.FillSnapshot(ctx, version, []model.Currency{currency("EUR", "0.85"), currency("BRL", "5.43")})
+	assert.NoError(t, err)
+	assert.True(t, filled)
+
+	snapshot, err := redisCache.GetSnapshot(ctx, []string{"EUR", "BRL"})
+	assert.NoError(t, err)
+	assert.Equal(t, version, snapshot.Version)
+	assert.Equal(t, "0.86", snapshot.Currencies["EUR"].Rate.String())
+	assert.Equal(t, "5.43", snapshot.Currencies["BRL"].Rate.String())
+	stored, err := mr.Get("challenge-bravo:v3:{rates}:version")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", stored)
+
+	filled, err = redisCache.FillSnapshot(ctx, version-1, []model.Currency{currency("GBP", "0.78")})
+	assert.NoError(t, err)
+	assert.False(t, filled)
+	snapshot, err = redisCache.GetSnapshot(ctx, []string{"GBP"})
+	assert.NoError(t, err)
+	assert.Empty(t, snapshot.Currencies)
+}
+
+type recordingHandler struct {
+	events  chan []model.RateChangeEvent
+	flushed chan struct{}
+}
+
+func (h *recordingHandler) ApplyRateChanges(events []model.RateChangeEvent) {
+	h.events <- events
+}
+
+func (h *recordingHandler) Flush() {
+	h.flushed <- struct{}{}
+}
+
+func TestRateChanges(t *testing.T) {
+	redisCache, mr := setupTestRedis(t)
+	defer mr.Close()
+	defer redisCache.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := &recordingHandler{events: make(chan []model.RateChangeEvent, 1), flushed: make(chan struct{}, 1)}
+	done := make(chan error)
+	go func() {
+		done <- redisCache.SubscribeRateChanges(ctx, handler)
+	}()
+
+	select {
+	case <-handler.flushed:
+	case <-time.After(time.Second):
+		t.Fatal("expected the subscription to flush the handler")
+	}
+
+	changedAt := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+	err := redisCache.PublishRateChanges(context.Background(), []model.RateChangeEvent{
+		{Code: "EUR", Rate: decimal.RequireFromString("0.85"), ChangedAt: changedAt},
+		{Code: "HURB", Deleted: true, ChangedAt: changedAt},
+	})
+	assert.NoError(t, err)
+
+	select {
+	case events := <-handler.events:
+		assert.Len(t, events, 2)
+		assert.Equal(t, "EUR", events[0].Code)
+		assert.Equal(t, "0.85", events[0].Rate.String())
+		assert.True(t, events[1].Deleted)
+		assert.True(t, events[1].ChangedAt.Equal(changedAt))
+	case <-time.After(time.Second):
+		t.Fatal("expected the published rate changes to be received")
+	}
+
+	cancel()
+	assert.NoError(t, <-done)
 }
 
 func TestDelete(t *testing.T) {
@@ -85,7 +439,7 @@ func TestDelete(t *testing.T) {
 
 	ctx := context.Background()
 
-	err := redisCache.Set(ctx, "test_key", 123.45, time.Minute)
+	err := redisCache.Set(ctx, currency("test_key", "123.45"), time.Minute)
 	assert.NoError(t, err)
 
 	err = redisCache.Delete(ctx, "test_key")
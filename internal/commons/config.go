@@ -3,15 +3,44 @@ package commons
 import (
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 type Config struct {
-	PostgresConn string
-	RedisAddr    string
-	RedisPass    string
-	ServerPort   uint16
-	APIKey       string
+	Standalone                     bool
+	StorageDriver                  string
+	CacheDriver                    string
+	SQLitePath                     string
+	PostgresConn                   string
+	RedisAddr                      string
+	RedisPass                      string
+	RedisDB                        int
+	RedisSentinelMaster            string
+	RedisSentinelAddrs             []string
+	RedisSentinelPass              string
+	RedisClusterAddrs              []string
+	RedisTLS                       bool
+	RedisTLSCACert                 string
+	RedisTLSCert                   string
+	RedisTLSKey                    string
+	RedisPoolSize                  int
+	RedisMinIdleConns              int
+	ServerPort                     uint16
+	WorkerPort                     uint16
+	APIKey                         string
+	RateProviders                  []string
+	RateProviderURLs               map[string]string
+	RateTolerance                  decimal.Decimal
+	RateJumpThreshold              decimal.Decimal
+	CircuitBreakerFailureThreshold int
+	CircuitBreakerCoolDown         time.Duration
+	RateMaxAge                     time.Duration
+	StaleRatePolicy                string
 }
 
 const (
@@ -23,44 +52,211 @@ func LoadConfig() (Config, error) {
 	var config Config
 	var errors []string
 
-	config.RedisPass = os.Getenv("REDIS_PASSWORD")
-	if config.RedisPass == "" {
-		errors = append(errors, "REDIS_PASSWORD is not set")
+	if standalone := os.Getenv("STANDALONE"); standalone != "" {
+		parsedStandalone, err := strconv.ParseBool(standalone)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("invalid STANDALONE: %s, must be true or false", standalone))
+		} else {
+			config.Standalone = parsedStandalone
+		}
+	}
+
+	config.StorageDriver = StorageDriverPostgres
+	config.CacheDriver = CacheDriverRedis
+	if config.Standalone {
+		config.StorageDriver = StorageDriverSQLite
+		config.CacheDriver = CacheDriverMemory
+	}
+	if driver := os.Getenv("STORAGE_DRIVER"); driver != "" {
+		driver = strings.ToLower(driver)
+		if driver != StorageDriverPostgres && driver != StorageDriverSQLite {
+			errors = append(errors, fmt.Sprintf("invalid STORAGE_DRIVER: %s, must be one of postgres or sqlite", driver))
+		} else {
+			config.StorageDriver = driver
+		}
+	}
+	if driver := os.Getenv("CACHE_DRIVER"); driver != "" {
+		driver = strings.ToLower(driver)
+		if driver != CacheDriverRedis && driver != CacheDriverMemory {
+			errors = append(errors, fmt.Sprintf("invalid CACHE_DRIVER: %s, must be one of redis or memory", driver))
+		} else {
+			config.CacheDriver = driver
+		}
+	}
+
+	config.SQLitePath = DefaultSQLitePath
+	if path := os.Getenv("SQLITE_PATH"); path != "" {
+		config.SQLitePath = path
+	}
+
+	if config.CacheDriver == CacheDriverRedis {
+		config.RedisPass = os.Getenv("REDIS_PASSWORD")
+		if config.RedisPass == "" {
+			errors = append(errors, "REDIS_PASSWORD is not set")
+		}
+
+		config.RedisAddr = os.Getenv("REDIS_ADDR")
+		config.RedisSentinelMaster = os.Getenv("REDIS_SENTINEL_MASTER")
+		config.RedisSentinelAddrs = parseAddrs(os.Getenv("REDIS_SENTINEL_ADDRS"))
+		config.RedisSentinelPass = os.Getenv("REDIS_SENTINEL_PASSWORD")
+		config.RedisClusterAddrs = parseAddrs(os.Getenv("REDIS_CLUSTER_ADDRS"))
+		switch {
+		case config.RedisSentinelMaster != "" && len(config.RedisClusterAddrs) > 0:
+			errors = append(errors, "REDIS_SENTINEL_MASTER and REDIS_CLUSTER_ADDRS cannot both be set")
+		case config.RedisSentinelMaster != "":
+			if len(config.RedisSentinelAddrs) == 0 {
+				errors = append(errors, "REDIS_SENTINEL_ADDRS is not set")
+			}
+		case len(config.RedisSentinelAddrs) > 0:
+			errors = append(errors, "REDIS_SENTINEL_MASTER is not set")
+		case len(config.RedisClusterAddrs) == 0 && config.RedisAddr == "":
+			errors = append(errors, "REDIS_ADDR is not set")
+		}
+
+		if db := os.Getenv("REDIS_DB"); db != "" {
+			parsedDB, err := strconv.Atoi(db)
+			if err != nil || parsedDB < 0 {
+				errors = append(errors, fmt.Sprintf("invalid REDIS_DB: %s, must be a non-negative integer", db))
+			} else if parsedDB != 0 && len(config.RedisClusterAddrs) > 0 {
+				errors = append(errors, fmt.Sprintf("invalid REDIS_DB: %s, Redis Cluster only supports database 0", db))
+			} else {
+				config.RedisDB = parsedDB
+			}
+		}
+
+		config.RedisTLSCACert = os.Getenv("REDIS_TLS_CA_CERT")
+		config.RedisTLSCert = os.Getenv("REDIS_TLS_CERT")
+		config.RedisTLSKey = os.Getenv("REDIS_TLS_KEY")
+		if (config.RedisTLSCert == "") != (config.RedisTLSKey == "") {
+			errors = append(errors, "REDIS_TLS_CERT and REDIS_TLS_KEY must be set together")
+		}
+		config.RedisTLS = config.RedisTLSCACert != "" || config.RedisTLSCert != ""
+		if useTLS := os.Getenv("REDIS_TLS"); useTLS != "" {
+			parsedTLS, err := strconv.ParseBool(useTLS)
+			if err != nil {
+				errors = append(errors, fmt.Sprintf("invalid REDIS_TLS: %s, must be true or false", useTLS))
+			} else {
+				config.RedisTLS = parsedTLS
+			}
+		}
+
+		if poolSize := os.Getenv("REDIS_POOL_SIZE"); poolSize != "" {
+			parsedPoolSize, err := strconv.Atoi(poolSize)
+			if err != nil || parsedPoolSize < 1 {
+				errors = append(errors, fmt.Sprintf("invalid REDIS_POOL_SIZE: %s, must be a positive integer", poolSize))
+			} else {
+				config.RedisPoolSize = parsedPoolSize
+			}
+		}
+
+		if minIdleConns := os.Getenv("REDIS_MIN_IDLE_CONNS"); minIdleConns != "" {
+			parsedMinIdleConns, err := strconv.Atoi(minIdleConns)
+			if err != nil || parsedMinIdleConns < 0 {
+				errors = append(errors, fmt.Sprintf("invalid REDIS_MIN_IDLE_CONNS: %s, must be a non-negative integer", minIdleConns))
+			} else {
+				config.RedisMinIdleConns = parsedMinIdleConns
+			}
+		}
+	}
+
+	if config.StorageDriver == StorageDriverPostgres {
+		pg_user := os.Getenv("POSTGRES_USER")
+		if pg_user == "" {
+			errors = append(errors, "POSTGRES_USER is not set")
+		}
+
+		pg_pass := os.Getenv("POSTGRES_PASSWORD")
+		if pg_pass == "" {
+			errors = append(errors, "POSTGRES_PASSWORD is not set")
+		}
+
+		pg_host := os.Getenv("POSTGRES_HOST")
+		if pg_host == "" {
+			errors = append(errors, "POSTGRES_HOST is not set")
+		}
+		pg_port := os.Getenv("POSTGRES_PORT")
+		if pg_port == "" {
+			errors = append(errors, "POSTGRES_PORT is not set")
+		}
+
+		pg_db := os.Getenv("POSTGRES_NAME")
+		if pg_db == "" {
+			errors = append(errors, "POSTGRES_NAME is not set")
+		}
+
+		config.PostgresConn = fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable", pg_user, pg_pass, pg_host, pg_port, pg_db)
 	}
 
-	config.RedisAddr = os.Getenv("REDIS_ADDR")
-	if config.RedisAddr == "" {
-		errors = append(errors, "REDIS_ADDR is not set")
+	config.RateProviders = parseRateProviders(os.Getenv("RATE_PROVIDER"))
+	config.RateProviderURLs = make(map[string]string, len(config.RateProviders))
+	for _, provider := range config.RateProviders {
+		if url := os.Getenv("RATE_PROVIDER_URL_" + strings.ToUpper(provider)); url != "" {
+			config.RateProviderURLs[provider] = url
+		}
 	}
 
-	pg_user := os.Getenv("POSTGRES_USER")
-	if pg_user == "" {
-		errors = append(errors, "POSTGRES_USER is not set")
+	config.RateTolerance = decimal.NewFromFloat(DefaultRateProviderTolerance)
+	if tolerance := os.Getenv("RATE_PROVIDER_TOLERANCE"); tolerance != "" {
+		parsedTolerance, err := decimal.NewFromString(tolerance)
+		if err != nil || !parsedTolerance.IsPositive() {
+			errors = append(errors, fmt.Sprintf("invalid RATE_PROVIDER_TOLERANCE: %s, must be a positive number", tolerance))
+		} else {
+			config.RateTolerance = parsedTolerance
+		}
 	}
 
-	pg_pass := os.Getenv("POSTGRES_PASSWORD")
-	if pg_pass == "" {
-		errors = append(errors, "POSTGRES_PASSWORD is not set")
+	config.RateJumpThreshold = decimal.NewFromFloat(DefaultRateJumpThreshold)
+	if threshold := os.Getenv("RATE_JUMP_THRESHOLD"); threshold != "" {
+		parsedThreshold, err := decimal.NewFromString(threshold)
+		if err != nil || !parsedThreshold.IsPositive() {
+			errors = append(errors, fmt.Sprintf("invalid RATE_JUMP_THRESHOLD: %s, must be a positive number", threshold))
+		} else {
+			config.RateJumpThreshold = parsedThreshold
+		}
 	}
 
-	pg_host := os.Getenv("POSTGRES_HOST")
-	if pg_host == "" {
-		errors = append(errors, "POSTGRES_HOST is not set")
+	config.CircuitBreakerFailureThreshold = DefaultCircuitBreakerFailureThreshold
+	if threshold := os.Getenv("CIRCUIT_BREAKER_FAILURE_THRESHOLD"); threshold != "" {
+		parsedThreshold, err := strconv.Atoi(threshold)
+		if err != nil || parsedThreshold < 1 {
+			errors = append(errors, fmt.Sprintf("invalid CIRCUIT_BREAKER_FAILURE_THRESHOLD: %s, must be a positive integer", threshold))
+		} else {
+			config.CircuitBreakerFailureThreshold = parsedThreshold
+		}
 	}
-	pg_port := os.Getenv("POSTGRES_PORT")
-	if pg_port == "" {
-		errors = append(errors, "POSTGRES_PORT is not set")
+
+	config.CircuitBreakerCoolDown = DefaultCircuitBreakerCoolDown
+	if coolDown := os.Getenv("CIRCUIT_BREAKER_COOLDOWN"); coolDown != "" {
+		parsedCoolDown, err := time.ParseDuration(coolDown)
+		if err != nil || parsedCoolDown <= 0 {
+			errors = append(errors, fmt.Sprintf("invalid CIRCUIT_BREAKER_COOLDOWN: %s, must be a positive duration", coolDown))
+		} else {
+			config.CircuitBreakerCoolDown = parsedCoolDown
+		}
 	}
 
-	pg_db := os.Getenv("POSTGRES_NAME")
-	if pg_db == "" {
-		errors = append(errors, "POSTGRES_NAME is not set")
+	config.RateMaxAge = DefaultRateMaxAge
+	if maxAge := os.Getenv("RATE_MAX_AGE"); maxAge != "" {
+		parsedMaxAge, err := time.ParseDuration(maxAge)
+		if err != nil || parsedMaxAge < 0 {
+			errors = append(errors, fmt.Sprintf("invalid RATE_MAX_AGE: %s, must be a non-negative duration", maxAge))
+		} else {
+			config.RateMaxAge = parsedMaxAge
+		}
 	}
 
-	config.PostgresConn = fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable", pg_user, pg_pass, pg_host, pg_port, pg_db)
+	config.StaleRatePolicy = StaleRatePolicyFlag
+	if policy := os.Getenv("RATE_STALE_POLICY"); policy != "" {
+		parsedPolicy := strings.ToLower(policy)
+		if parsedPolicy != StaleRatePolicyFlag && parsedPolicy != StaleRatePolicyReject {
+			errors = append(errors, fmt.Sprintf("invalid RATE_STALE_POLICY: %s, must be one of flag or reject", policy))
+		} else {
+			config.StaleRatePolicy = parsedPolicy
+		}
+	}
 
 	config.APIKey = os.Getenv("API_KEY")
-	if config.APIKey == "" {
+	if config.APIKey == "" && slices.Contains(config.RateProviders, RateProviderOpenExchangeRates) {
 		errors = append(errors, "API_KEY is not set")
 	}
 
@@ -75,6 +271,16 @@ func LoadConfig() (Config, error) {
 			config.ServerPort = uint16(parsedServerPort)
 		}
 	}
+
+	config.WorkerPort = DefaultWorkerPort
+	if workerPort := os.Getenv("WORKER_PORT"); workerPort != "" {
+		parsedWorkerPort, err := strconv.ParseUint(workerPort, decimalBase, bitSize)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("invalid WORKER_PORT: %s", err))
+		} else {
+			config.WorkerPort = uint16(parsedWorkerPort)
+		}
+	}
 	if len(errors) > 0 {
 		for _, err := range errors {
 			fmt.Println("Configuration Error:", err)
@@ -84,3 +290,28 @@ func LoadConfig() (Config, error) {
 
 	return config, nil
 }
+
+func parseAddrs(value string) []string {
+	var addrs []string
+	for _, addr := range strings.Split(value, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+func parseRateProviders(value string) []string {
+	var providers []string
+	for _, provider := range strings.Split(value, ",") {
+		provider = strings.ToLower(strings.TrimSpace(provider))
+		if provider == "" || slices.Contains(providers, provider) {
+			continue
+		}
+		providers = append(providers, provider)
+	}
+	if len(providers) == 0 {
+		return []string{RateProviderOpenExchangeRates}
+	}
+	return providers
+}
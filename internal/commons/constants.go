@@ -13,8 +13,60 @@ const (
 	RateUpdaterCacheExipiration = 1 * time.Hour
 	RateUpdaterInterval         = 1 * time.Hour
 	WorkerHeartbeatInterval     = 5 * time.Minute
+	DefaultWorkerPort           = 8081
+	WorkerMaxRateAge            = 3 * RateUpdaterInterval
+	WorkerLeaderLockKey         = 7264100
+	LeaderElectionInterval      = 15 * time.Second
+	SQLiteLeaderLockTTL         = 3 * LeaderElectionInterval
+	ScheduledRateCheckInterval  = time.Minute
+	MaxBackfillDays             = 366
 	ServerIdleTimeout           = time.Minute
 	ServerReadTimeout           = 10 * time.Second
 	ServerWriteTimeout          = 30 * time.Second
 	CacheExpiration             = 1 * time.Hour
+	LocalCacheExpiration        = time.Minute
+	LocalCacheSize              = 1000
+	LocalCacheFetchTimeout      = 5 * time.Second
+	RateChangeChannel           = "currency:rate-changes"
+	CacheKeyPrefix              = "challenge-bravo"
+	CacheSchemaVersion          = 3
+	RateHistoryDefaultDays      = 30
+	DefaultPageSize             = 20
+	MaxPageSize                 = 100
+	MaxCurrencyNameLength       = 100
+	MaxCurrencySymbolLength     = 10
+	DefaultMinorUnits           = 2
+	MaxMinorUnits               = 18
+	DivisionPrecision           = 20
+	MaxConversionTargets        = 50
+	MaxBatchConversions         = 100
+	MaxMatrixCodes              = 50
+)
+
+const (
+	RateProviderOpenExchangeRates = "openexchangerates"
+	RateProviderECB               = "ecb"
+	RateProviderFrankfurter       = "frankfurter"
+	DefaultRateProviderTolerance  = 0.05
+	DefaultRateJumpThreshold      = 0.25
+	ECBHistoryCacheExpiration     = time.Hour
+)
+
+const (
+	DefaultCircuitBreakerFailureThreshold = 3
+	DefaultCircuitBreakerCoolDown         = 4 * time.Hour
+)
+
+const (
+	DefaultRateMaxAge     = 24 * time.Hour
+	StaleRatePolicyFlag   = "flag"
+	StaleRatePolicyReject = "reject"
+)
+
+const (
+	StorageDriverPostgres = "postgres"
+	StorageDriverSQLite   = "sqlite"
+	CacheDriverRedis      = "redis"
+	CacheDriverMemory     = "memory"
+	DefaultSQLitePath     = "challenge-bravo.db"
 )
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Lutefd/challenge-bravo/internal/commons"
+	"github.com/Lutefd/challenge-bravo/internal/model"
+)
+
+type CacheStatsSource interface {
+	Stats() model.CacheStats
+}
+
+type CacheStatsHandler struct {
+	source CacheStatsSource
+}
+
+func NewCacheStatsHandler(source CacheStatsSource) *CacheStatsHandler {
+	return &CacheStatsHandler{source: source}
+}
+
+func (h *CacheStatsHandler) GetCacheStats(w http.ResponseWriter, r *http.Request) {
+	commons.RespondWithJSON(w, http.StatusOK, h.source.Stats())
+}
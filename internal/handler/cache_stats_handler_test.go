@@ -0,0 +1,31 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Lutefd/challenge-bravo/internal/handler"
+	"github.com/Lutefd/challenge-bravo/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubCacheStatsSource struct {
+	stats model.CacheStats
+}
+
+func (s stubCacheStatsSource) Stats() model.CacheStats {
+	return s.stats
+}
+
+func TestCacheStatsHandler_GetCacheStats(t *testing.T) {
+	h := handler.NewCacheStatsHandler(stubCacheStatsSource{stats: model.CacheStats{
+		Hits: 120, Misses: 8, Coalesced: 5, Evictions: 1, Size: 42, Capacity: 1000,
+	}})
+
+	rr := httptest.NewRecorder()
+	h.GetCacheStats(rr, httptest.NewRequest(http.MethodGet, "/cache/stats", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"hits":120,"misses":8,"coalesced":5,"evictions":1,"size":42,"capacity":1000}`, rr.Body.String())
+}
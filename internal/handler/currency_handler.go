@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -13,16 +14,32 @@ import (
 	"github.com/Lutefd/challenge-bravo/internal/model"
 	"github.com/Lutefd/challenge-bravo/internal/service"
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 type CurrencyHandler struct {
 	currencyService service.CurrencyServiceInterface
+	stalePolicy     model.StaleRatePolicy
 }
 
-func NewCurrencyHandler(currencyService service.CurrencyServiceInterface) *CurrencyHandler {
-	return &CurrencyHandler{
+type CurrencyHandlerOption func(*CurrencyHandler)
+
+func WithStaleRatePolicy(policy model.StaleRatePolicy) CurrencyHandlerOption {
+	return func(h *CurrencyHandler) {
+		h.stalePolicy = policy
+	}
+}
+
+func NewCurrencyHandler(currencyService service.CurrencyServiceInterface, options ...CurrencyHandlerOption) *CurrencyHandler {
+	h := &CurrencyHandler{
 		currencyService: currencyService,
+		stalePolicy:     model.DefaultStaleRatePolicy,
+	}
+	for _, option := range options {
+		option(h)
 	}
+	return h
 }
 
 func (h *CurrencyHandler) ConvertCurrency(w http.ResponseWriter, r *http.Request) {
@@ -34,13 +51,19 @@ func (h *CurrencyHandler) ConvertCurrency(w http.ResponseWriter, r *http.Request
 		commons.RespondWithError(w, http.StatusBadRequest, "missing required parameters")
 		return
 	}
-	if len(from) > commons.AllowedCurrencyLength || len(to) > commons.AllowedCurrencyLength {
-		commons.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid currency code, must be up to %d characters", commons.AllowedCurrencyLength))
+	targets := strings.Split(to, ",")
+	if len(targets) > commons.MaxConversionTargets {
+		commons.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("too many target currencies, must be up to %d", commons.MaxConversionTargets))
 		return
 	}
-	if len(from) < commons.MinimumCurrencyLength || len(to) < commons.MinimumCurrencyLength {
-		commons.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid currency code, must be at least %d characters", commons.MinimumCurrencyLength))
-		return
+	for i := range targets {
+		targets[i] = strings.TrimSpace(targets[i])
+	}
+	for _, code := range append([]string{from}, targets...) {
+		if err := validateCurrencyCode(code); err != nil {
+			commons.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 	}
 
 	amount, err := parseAmount(amountStr)
@@ -49,36 +72,420 @@ func (h *CurrencyHandler) ConvertCurrency(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if amount < 0 {
+	if amount.IsNegative() {
 		commons.RespondWithError(w, http.StatusBadRequest, "amount must be non-negative")
 		return
 	}
 
-	result, err := h.currencyService.Convert(r.Context(), from, to, amount)
+	rounding, err := parseRounding(r.URL.Query().Get("round"))
+	if err != nil {
+		commons.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stalePolicy, err := h.parseStalePolicy(r.URL.Query().Get("on_stale"))
+	if err != nil {
+		commons.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dateStr := r.URL.Query().Get("date")
+	var date time.Time
+	if dateStr != "" {
+		date, err = time.Parse(time.DateOnly, dateStr)
+		if err != nil {
+			commons.RespondWithError(w, http.StatusBadRequest, "invalid date, must be in YYYY-MM-DD format")
+			return
+		}
+		if date.After(time.Now()) {
+			commons.RespondWithError(w, http.StatusBadRequest, "date must not be in the future")
+			return
+		}
+	}
+
+	var conversions []model.Conversion
+	if len(targets) == 1 {
+		var conversion *model.Conversion
+		if dateStr != "" {
+			conversion, err = h.currencyService.ConvertAt(r.Context(), from, targets[0], amount, date.AddDate(0, 0, 1))
+		} else {
+			conversion, err = h.currencyService.Convert(r.Context(), from, targets[0], amount)
+		}
+		if err == nil {
+			conversions = append(conversions, *conversion)
+		}
+	} else {
+		requests := make([]model.ConversionRequest, 0, len(targets))
+		for _, target := range targets {
+			requests = append(requests, model.ConversionRequest{From: from, To: target, Amount: amount})
+		}
+		if dateStr != "" {
+			conversions, err = h.currencyService.ConvertBatchAt(r.Context(), requests, date.AddDate(0, 0, 1))
+		} else {
+			conversions, err = h.currencyService.ConvertBatch(r.Context(), requests)
+		}
+	}
+	if err != nil {
+		respondWithConversionError(w, err)
+		return
+	}
+	if rejectStaleConversions(w, conversions, stalePolicy) {
+		return
+	}
+
+	var response map[string]interface{}
+	if len(targets) == 1 {
+		response = conversionResponse(&conversions[0], rounding)
+	} else {
+		results := make([]map[string]interface{}, 0, len(conversions))
+		for i := range conversions {
+			results = append(results, conversionResponse(&conversions[i], rounding))
+		}
+		response = map[string]interface{}{
+			"from":          from,
+			"amount":        amount,
+			"from_currency": conversions[0].From,
+			"from_rate":     conversions[0].FromRate,
+			"stale":         len(staleCodes(conversions)) > 0,
+			"conversions":   results,
+		}
+		if conversions[0].Version != 0 {
+			response["rate_version"] = conversions[0].Version
+		}
+	}
+	if dateStr != "" {
+		response["date"] = dateStr
+	}
+	commons.RespondWithJSON(w, http.StatusOK, response)
+}
+
+func (h *CurrencyHandler) ConvertBatch(w http.ResponseWriter, r *http.Request) {
+	var items []struct {
+		From   string      `json:"from"`
+		To     string      `json:"to"`
+		Amount json.Number `json:"amount"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+		commons.RespondWithError(w, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+	if len(items) == 0 {
+		commons.RespondWithError(w, http.StatusBadRequest, "batch must contain at least one item")
+		return
+	}
+	if len(items) > commons.MaxBatchConversions {
+		commons.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("batch must contain up to %d items", commons.MaxBatchConversions))
+		return
+	}
+
+	rounding, err := parseRounding(r.URL.Query().Get("round"))
+	if err != nil {
+		commons.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stalePolicy, err := h.parseStalePolicy(r.URL.Query().Get("on_stale"))
+	if err != nil {
+		commons.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	requests := make([]model.ConversionRequest, 0, len(items))
+	for i, item := range items {
+		from := strings.ToUpper(item.From)
+		to := strings.ToUpper(item.To)
+		for _, code := range []string{from, to} {
+			if err := validateCurrencyCode(code); err != nil {
+				commons.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("item %d: %s", i, err))
+				return
+			}
+		}
+		amount, err := parseAmount(item.Amount.String())
+		if err != nil {
+			commons.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("item %d: invalid amount", i))
+			return
+		}
+		if amount.IsNegative() {
+			commons.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("item %d: amount must be non-negative", i))
+			return
+		}
+		requests = append(requests, model.ConversionRequest{From: from, To: to, Amount: amount})
+	}
+
+	conversions, err := h.currencyService.ConvertBatch(r.Context(), requests)
+	if err != nil {
+		respondWithConversionError(w, err)
+		return
+	}
+	if rejectStaleConversions(w, conversions, stalePolicy) {
+		return
+	}
+
+	results := make([]map[string]interface{}, 0, len(conversions))
+	for i := range conversions {
+		results = append(results, conversionResponse(&conversions[i], rounding))
+	}
+	commons.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"conversions": results})
+}
+
+func (h *CurrencyHandler) GetRateMatrix(w http.ResponseWriter, r *http.Request) {
+	codesStr := r.URL.Query().Get("codes")
+	if codesStr == "" {
+		commons.RespondWithError(w, http.StatusBadRequest, "missing required parameters")
+		return
+	}
+
+	seen := make(map[string]bool)
+	var codes []string
+	for _, code := range strings.Split(codesStr, ",") {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if err := validateCurrencyCode(code); err != nil {
+			commons.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+	if len(codes) > commons.MaxMatrixCodes {
+		commons.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("too many currencies, must be up to %d", commons.MaxMatrixCodes))
+		return
+	}
+
+	matrix, err := h.currencyService.GetRateMatrix(r.Context(), codes)
+	if err != nil {
+		if errors.Is(err, model.ErrCurrencyNotFound) {
+			commons.RespondWithError(w, http.StatusNotFound, err.Error())
+		} else {
+			commons.RespondWithError(w, http.StatusInternalServerError, "failed to get rate matrix")
+		}
+		return
+	}
+
+	commons.RespondWithJSON(w, http.StatusOK, matrix)
+}
+
+func conversionResponse(conversion *model.Conversion, rounding model.RoundingMode) map[string]interface{} {
+	conversion.Round(rounding)
+	response := map[string]interface{}{
+		"from":          conversion.From.Code,
+		"to":            conversion.To.Code,
+		"amount":        conversion.Amount,
+		"result":        conversion.FormattedResult(),
+		"round":         conversion.Rounding,
+		"precision":     conversion.Precision,
+		"from_currency": conversion.From,
+		"to_currency":   conversion.To,
+		"from_rate":     conversion.FromRate,
+		"to_rate":       conversion.ToRate,
+		"stale":         conversion.Stale,
+	}
+	if conversion.Version != 0 {
+		response["rate_version"] = conversion.Version
+	}
+	return response
+}
+
+func rejectStaleConversions(w http.ResponseWriter, conversions []model.Conversion, policy model.StaleRatePolicy) bool {
+	if policy != model.StaleRatePolicyReject {
+		return false
+	}
+	codes := staleCodes(conversions)
+	if len(codes) == 0 {
+		return false
+	}
+	commons.RespondWithError(w, http.StatusServiceUnavailable, fmt.Sprintf("rates are stale for %s", strings.Join(codes, ", ")))
+	return true
+}
+
+func staleCodes(conversions []model.Conversion) []string {
+	var codes []string
+	for i := range conversions {
+		for _, code := range conversions[i].StaleCodes() {
+			if !slices.Contains(codes, code) {
+				codes = append(codes, code)
+			}
+		}
+	}
+	return codes
+}
+
+func respondWithConversionError(w http.ResponseWriter, err error) {
+	if errors.Is(err, model.ErrCurrencyNotFound) {
+		commons.RespondWithError(w, http.StatusNotFound, err.Error())
+	} else {
+		commons.RespondWithError(w, http.StatusInternalServerError, "conversion failed")
+	}
+}
+
+func (h *CurrencyHandler) GetCurrencyHistory(w http.ResponseWriter, r *http.Request) {
+	code := strings.ToUpper(chi.URLParam(r, "code"))
+	if len(code) > commons.AllowedCurrencyLength {
+		commons.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid currency code, must be up to %d characters", commons.AllowedCurrencyLength))
+		return
+	}
+	if len(code) < commons.MinimumCurrencyLength {
+		commons.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid currency code, must be at least %d characters", commons.MinimumCurrencyLength))
+		return
+	}
+
+	now := time.Now().UTC()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		parsed, err := time.Parse(time.DateOnly, toStr)
+		if err != nil {
+			commons.RespondWithError(w, http.StatusBadRequest, "invalid to date, must be in YYYY-MM-DD format")
+			return
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -commons.RateHistoryDefaultDays)
+	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+		parsed, err := time.Parse(time.DateOnly, fromStr)
+		if err != nil {
+			commons.RespondWithError(w, http.StatusBadRequest, "invalid from date, must be in YYYY-MM-DD format")
+			return
+		}
+		from = parsed
+	}
+
+	if from.After(to) {
+		commons.RespondWithError(w, http.StatusBadRequest, "from date must not be after to date")
+		return
+	}
+
+	history, err := h.currencyService.GetRateHistory(r.Context(), code, from, to.AddDate(0, 0, 1))
 	if err != nil {
 		if errors.Is(err, model.ErrCurrencyNotFound) {
 			commons.RespondWithError(w, http.StatusNotFound, err.Error())
 		} else {
-			commons.RespondWithError(w, http.StatusInternalServerError, "conversion failed")
+			commons.RespondWithError(w, http.StatusInternalServerError, "failed to get rate history")
 		}
 		return
 	}
 
 	commons.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
-		"from":   from,
-		"to":     to,
-		"amount": amount,
-		"result": result,
+		"code":    code,
+		"from":    from.Format(time.DateOnly),
+		"to":      to.Format(time.DateOnly),
+		"history": history,
 	})
 }
 
+func (h *CurrencyHandler) ListCurrencies(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	page := 1
+	if pageStr := query.Get("page"); pageStr != "" {
+		parsed, err := strconv.Atoi(pageStr)
+		if err != nil || parsed < 1 {
+			commons.RespondWithError(w, http.StatusBadRequest, "invalid page, must be a positive integer")
+			return
+		}
+		page = parsed
+	}
+
+	pageSize := commons.DefaultPageSize
+	if pageSizeStr := query.Get("page_size"); pageSizeStr != "" {
+		parsed, err := strconv.Atoi(pageSizeStr)
+		if err != nil || parsed < 1 || parsed > commons.MaxPageSize {
+			commons.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid page_size, must be between 1 and %d", commons.MaxPageSize))
+			return
+		}
+		pageSize = parsed
+	}
+
+	opts := model.CurrencyListOptions{
+		SortBy: "code",
+		Limit:  pageSize,
+		Offset: (page - 1) * pageSize,
+	}
+
+	if sortBy := query.Get("sort"); sortBy != "" {
+		if !model.CurrencySortFields[sortBy] {
+			commons.RespondWithError(w, http.StatusBadRequest, "invalid sort field")
+			return
+		}
+		opts.SortBy = sortBy
+	}
+
+	switch strings.ToLower(query.Get("order")) {
+	case "", "asc":
+	case "desc":
+		opts.Descending = true
+	default:
+		commons.RespondWithError(w, http.StatusBadRequest, "invalid order, must be asc or desc")
+		return
+	}
+
+	switch source := model.CurrencySource(strings.ToLower(query.Get("source"))); source {
+	case "", model.CurrencySourceProvider, model.CurrencySourceManual:
+		opts.Source = source
+	default:
+		commons.RespondWithError(w, http.StatusBadRequest, "invalid source, must be provider or manual")
+		return
+	}
+
+	if updatedSinceStr := query.Get("updated_since"); updatedSinceStr != "" {
+		updatedSince, err := parseTimestamp(updatedSinceStr)
+		if err != nil {
+			commons.RespondWithError(w, http.StatusBadRequest, "invalid updated_since, must be an RFC 3339 timestamp or YYYY-MM-DD date")
+			return
+		}
+		opts.UpdatedSince = updatedSince
+	}
+
+	currencies, total, err := h.currencyService.ListCurrencies(r.Context(), opts)
+	if err != nil {
+		commons.RespondWithError(w, http.StatusInternalServerError, "failed to list currencies")
+		return
+	}
+
+	commons.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"currencies": currencies,
+		"page":       page,
+		"page_size":  pageSize,
+		"total":      total,
+	})
+}
+
+func (h *CurrencyHandler) GetCurrency(w http.ResponseWriter, r *http.Request) {
+	code := strings.ToUpper(chi.URLParam(r, "code"))
+	if len(code) > commons.AllowedCurrencyLength {
+		commons.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid currency code, must be up to %d characters", commons.AllowedCurrencyLength))
+		return
+	}
+	if len(code) < commons.MinimumCurrencyLength {
+		commons.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid currency code, must be at least %d characters", commons.MinimumCurrencyLength))
+		return
+	}
+
+	currency, err := h.currencyService.GetCurrency(r.Context(), code)
+	if err != nil {
+		if errors.Is(err, model.ErrCurrencyNotFound) {
+			commons.RespondWithError(w, http.StatusNotFound, err.Error())
+		} else {
+			commons.RespondWithError(w, http.StatusInternalServerError, "failed to get currency")
+		}
+		return
+	}
+
+	commons.RespondWithJSON(w, http.StatusOK, currency)
+}
+
 func (h *CurrencyHandler) AddCurrency(w http.ResponseWriter, r *http.Request) {
 	var currency struct {
 		Code string      `json:"code"`
 		Rate interface{} `json:"rate_to_usd"`
+		Peg  *pegInput   `json:"peg"`
+		currencyMetadataInput
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&currency); err != nil {
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&currency); err != nil {
 		commons.RespondWithError(w, http.StatusBadRequest, "invalid request payload")
 		return
 	}
@@ -101,27 +508,32 @@ func (h *CurrencyHandler) AddCurrency(w http.ResponseWriter, r *http.Request) {
 		commons.RespondWithError(w, http.StatusInternalServerError, "user information not available")
 		return
 	}
-	rate, err := parseRate(currency.Rate)
+	rate, peg, err := parseRateOrPeg(currency.Rate, currency.Peg)
 	if err != nil {
-		commons.RespondWithError(w, http.StatusBadRequest, "invalid rate: "+err.Error())
+		commons.RespondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-
-	if rate <= 0 {
-		commons.RespondWithError(w, http.StatusBadRequest, "rate must be positive")
+	if err := currency.validate(); err != nil {
+		commons.RespondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 	newCurrency := &model.Currency{
-		Code:      strings.ToUpper(currency.Code),
-		Rate:      rate,
-		CreatedBy: user.ID,
-		UpdatedBy: user.ID,
-		UpdatedAt: time.Now(),
-		CreatedAt: time.Now(),
+		Code:             strings.ToUpper(currency.Code),
+		Rate:             rate,
+		Peg:              peg,
+		CreatedBy:        user.ID,
+		UpdatedBy:        user.ID,
+		UpdatedAt:        time.Now(),
+		CreatedAt:        time.Now(),
+		CurrencyMetadata: currency.toMetadata(),
 	}
 
 	if err := h.currencyService.AddCurrency(r.Context(), newCurrency); err != nil {
-		commons.RespondWithError(w, http.StatusInternalServerError, "failed to add currency")
+		if errors.Is(err, model.ErrInvalidPeg) {
+			commons.RespondWithError(w, http.StatusBadRequest, err.Error())
+		} else {
+			commons.RespondWithError(w, http.StatusInternalServerError, "failed to add currency")
+		}
 		return
 	}
 
@@ -136,34 +548,91 @@ func (h *CurrencyHandler) UpdateCurrency(w http.ResponseWriter, r *http.Request)
 	}
 
 	var input struct {
-		Rate interface{} `json:"rate_to_usd"`
+		Rate        interface{} `json:"rate_to_usd"`
+		Peg         *pegInput   `json:"peg"`
+		EffectiveAt *string     `json:"effective_at"`
+		currencyMetadataInput
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&input); err != nil {
 		commons.RespondWithError(w, http.StatusBadRequest, "invalid request payload")
 		return
 	}
 
-	rate, err := parseRate(input.Rate)
-	if err != nil {
-		commons.RespondWithError(w, http.StatusBadRequest, "invalid rate: "+err.Error())
+	var rate *decimal.Decimal
+	var peg *model.CurrencyPeg
+	if input.Rate != nil || input.Peg != nil {
+		parsedRate, parsedPeg, err := parseRateOrPeg(input.Rate, input.Peg)
+		if err != nil {
+			commons.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if parsedPeg == nil {
+			rate = &parsedRate
+		}
+		peg = parsedPeg
+	} else if input.isEmpty() {
+		commons.RespondWithError(w, http.StatusBadRequest, "rate_to_usd, peg or a metadata field is required")
 		return
 	}
-
-	if rate <= 0 {
-		commons.RespondWithError(w, http.StatusBadRequest, "rate must be positive")
+	if err := input.validate(); err != nil {
+		commons.RespondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	var effectiveAt time.Time
+	if input.EffectiveAt != nil {
+		var err error
+		effectiveAt, err = parseTimestamp(*input.EffectiveAt)
+		if err != nil {
+			commons.RespondWithError(w, http.StatusBadRequest, "invalid effective_at, must be an RFC 3339 timestamp or YYYY-MM-DD date")
+			return
+		}
+		if !effectiveAt.After(time.Now()) {
+			commons.RespondWithError(w, http.StatusBadRequest, "effective_at must be in the future")
+			return
+		}
+		if rate == nil || !input.isEmpty() {
+			commons.RespondWithError(w, http.StatusBadRequest, "effective_at can only be used with rate_to_usd")
+			return
+		}
+	}
+
 	user, ok := r.Context().Value("user").(model.User)
 	if !ok {
 		commons.RespondWithError(w, http.StatusInternalServerError, "user information not available")
 		return
 	}
 
-	if err := h.currencyService.UpdateCurrency(r.Context(), code, rate, user.ID); err != nil {
+	if input.EffectiveAt != nil {
+		change := &model.ScheduledRateChange{
+			Code:        code,
+			Rate:        *rate,
+			EffectiveAt: effectiveAt.UTC(),
+			CreatedBy:   user.ID,
+		}
+		if err := h.currencyService.ScheduleRateChange(r.Context(), change); err != nil {
+			if errors.Is(err, model.ErrCurrencyNotFound) {
+				commons.RespondWithError(w, http.StatusNotFound, "currency not found")
+			} else {
+				commons.RespondWithError(w, http.StatusInternalServerError, "failed to schedule rate change")
+			}
+			return
+		}
+		commons.RespondWithJSON(w, http.StatusAccepted, change)
+		return
+	}
+
+	update := input.toUpdate()
+	update.Rate = rate
+	update.Peg = peg
+	if err := h.currencyService.UpdateCurrency(r.Context(), code, update, user.ID); err != nil {
 		if err == model.ErrCurrencyNotFound {
 			commons.RespondWithError(w, http.StatusNotFound, "currency not found")
+		} else if errors.Is(err, model.ErrInvalidPeg) {
+			commons.RespondWithError(w, http.StatusBadRequest, err.Error())
 		} else {
 			commons.RespondWithError(w, http.StatusInternalServerError, "failed to update currency")
 		}
@@ -189,24 +658,368 @@ func (h *CurrencyHandler) RemoveCurrency(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	if err := h.currencyService.RemoveCurrency(r.Context(), code); err != nil {
-		commons.RespondWithError(w, http.StatusInternalServerError, "failed to remove currency")
+		if errors.Is(err, model.ErrCurrencyInUse) {
+			commons.RespondWithError(w, http.StatusConflict, err.Error())
+		} else {
+			commons.RespondWithError(w, http.StatusInternalServerError, "failed to remove currency")
+		}
 		return
 	}
 
 	commons.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "currency removed successfully"})
 }
-func parseAmount(amountStr string) (float64, error) {
+
+func (h *CurrencyHandler) LockCurrency(w http.ResponseWriter, r *http.Request) {
+	h.setCurrencyLock(w, r, true)
+}
+
+func (h *CurrencyHandler) UnlockCurrency(w http.ResponseWriter, r *http.Request) {
+	h.setCurrencyLock(w, r, false)
+}
+
+func (h *CurrencyHandler) setCurrencyLock(w http.ResponseWriter, r *http.Request, locked bool) {
+	code := strings.ToUpper(chi.URLParam(r, "code"))
+	if code == "" || len(code) > commons.AllowedCurrencyLength || len(code) < commons.MinimumCurrencyLength {
+		commons.RespondWithError(w, http.StatusBadRequest, "invalid currency code")
+		return
+	}
+
+	if err := h.currencyService.SetCurrencyLock(r.Context(), code, locked); err != nil {
+		if errors.Is(err, model.ErrCurrencyNotFound) {
+			commons.RespondWithError(w, http.StatusNotFound, err.Error())
+		} else {
+			commons.RespondWithError(w, http.StatusInternalServerError, "failed to update currency lock")
+		}
+		return
+	}
+
+	message := "currency unlocked successfully"
+	if locked {
+		message = "currency locked successfully"
+	}
+	commons.RespondWithJSON(w, http.StatusOK, map[string]string{"message": message})
+}
+
+func (h *CurrencyHandler) ListScheduledRateChanges(w http.ResponseWriter, r *http.Request) {
+	code := strings.ToUpper(r.URL.Query().Get("code"))
+	if code != "" {
+		if err := validateCurrencyCode(code); err != nil {
+			commons.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	changes, err := h.currencyService.ListScheduledRateChanges(r.Context(), code)
+	if err != nil {
+		commons.RespondWithError(w, http.StatusInternalServerError, "failed to list scheduled rate changes")
+		return
+	}
+
+	commons.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"scheduled_changes": changes})
+}
+
+func (h *CurrencyHandler) CancelScheduledRateChange(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		commons.RespondWithError(w, http.StatusBadRequest, "invalid scheduled change id")
+		return
+	}
+
+	if err := h.currencyService.CancelScheduledRateChange(r.Context(), id); err != nil {
+		if errors.Is(err, model.ErrScheduledChangeNotFound) {
+			commons.RespondWithError(w, http.StatusNotFound, err.Error())
+		} else {
+			commons.RespondWithError(w, http.StatusInternalServerError, "failed to cancel scheduled rate change")
+		}
+		return
+	}
+
+	commons.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "scheduled rate change canceled successfully"})
+}
+
+func (h *CurrencyHandler) SetJumpThreshold(w http.ResponseWriter, r *http.Request) {
+	code := strings.ToUpper(chi.URLParam(r, "code"))
+	if code == "" || len(code) > commons.AllowedCurrencyLength || len(code) < commons.MinimumCurrencyLength {
+		commons.RespondWithError(w, http.StatusBadRequest, "invalid currency code")
+		return
+	}
+
+	var input struct {
+		Threshold interface{} `json:"threshold"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&input); err != nil {
+		commons.RespondWithError(w, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	threshold, err := parseRate(input.Threshold)
+	if err != nil {
+		commons.RespondWithError(w, http.StatusBadRequest, "invalid threshold: "+err.Error())
+		return
+	}
+	if !threshold.IsPositive() {
+		commons.RespondWithError(w, http.StatusBadRequest, "threshold must be positive")
+		return
+	}
+
+	h.setJumpThreshold(w, r, code, &threshold)
+}
+
+func (h *CurrencyHandler) ResetJumpThreshold(w http.ResponseWriter, r *http.Request) {
+	code := strings.ToUpper(chi.URLParam(r, "code"))
+	if code == "" || len(code) > commons.AllowedCurrencyLength || len(code) < commons.MinimumCurrencyLength {
+		commons.RespondWithError(w, http.StatusBadRequest, "invalid currency code")
+		return
+	}
+
+	h.setJumpThreshold(w, r, code, nil)
+}
+
+func (h *CurrencyHandler) setJumpThreshold(w http.ResponseWriter, r *http.Request, code string, threshold *decimal.Decimal) {
+	if err := h.currencyService.SetJumpThreshold(r.Context(), code, threshold); err != nil {
+		if errors.Is(err, model.ErrCurrencyNotFound) {
+			commons.RespondWithError(w, http.StatusNotFound, err.Error())
+		} else {
+			commons.RespondWithError(w, http.StatusInternalServerError, "failed to update jump threshold")
+		}
+		return
+	}
+
+	message := "jump threshold reset successfully"
+	if threshold != nil {
+		message = "jump threshold updated successfully"
+	}
+	commons.RespondWithJSON(w, http.StatusOK, map[string]string{"message": message})
+}
+
+func (h *CurrencyHandler) ListQuarantinedRates(w http.ResponseWriter, r *http.Request) {
+	code := strings.ToUpper(r.URL.Query().Get("code"))
+	if code != "" {
+		if err := validateCurrencyCode(code); err != nil {
+			commons.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	rates, err := h.currencyService.ListQuarantinedRates(r.Context(), code)
+	if err != nil {
+		commons.RespondWithError(w, http.StatusInternalServerError, "failed to list quarantined rates")
+		return
+	}
+
+	commons.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"quarantined_rates": rates})
+}
+
+func (h *CurrencyHandler) ApproveQuarantinedRate(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		commons.RespondWithError(w, http.StatusBadRequest, "invalid quarantined rate id")
+		return
+	}
+
+	user, ok := r.Context().Value("user").(model.User)
+	if !ok {
+		commons.RespondWithError(w, http.StatusInternalServerError, "user information not available")
+		return
+	}
+
+	rate, err := h.currencyService.ApproveQuarantinedRate(r.Context(), id, user.ID)
+	if err != nil {
+		if errors.Is(err, model.ErrQuarantinedRateNotFound) || errors.Is(err, model.ErrCurrencyNotFound) {
+			commons.RespondWithError(w, http.StatusNotFound, err.Error())
+		} else if errors.Is(err, model.ErrCurrencyLocked) {
+			commons.RespondWithError(w, http.StatusConflict, err.Error())
+		} else {
+			commons.RespondWithError(w, http.StatusInternalServerError, "failed to approve quarantined rate")
+		}
+		return
+	}
+
+	commons.RespondWithJSON(w, http.StatusOK, rate)
+}
+
+func (h *CurrencyHandler) RejectQuarantinedRate(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		commons.RespondWithError(w, http.StatusBadRequest, "invalid quarantined rate id")
+		return
+	}
+
+	if err := h.currencyService.RejectQuarantinedRate(r.Context(), id); err != nil {
+		if errors.Is(err, model.ErrQuarantinedRateNotFound) {
+			commons.RespondWithError(w, http.StatusNotFound, err.Error())
+		} else {
+			commons.RespondWithError(w, http.StatusInternalServerError, "failed to reject quarantined rate")
+		}
+		return
+	}
+
+	commons.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "quarantined rate rejected successfully"})
+}
+
+type pegInput struct {
+	Anchor string      `json:"anchor"`
+	Ratio  interface{} `json:"ratio"`
+}
+
+func parseRateOrPeg(rateInput interface{}, peg *pegInput) (decimal.Decimal, *model.CurrencyPeg, error) {
+	if peg == nil {
+		rate, err := parseRate(rateInput)
+		if err != nil {
+			return decimal.Zero, nil, errors.New("invalid rate: " + err.Error())
+		}
+		if !rate.IsPositive() {
+			return decimal.Zero, nil, errors.New("rate must be positive")
+		}
+		return rate, nil, nil
+	}
+
+	if rateInput != nil {
+		return decimal.Zero, nil, errors.New("rate_to_usd and peg cannot be used together")
+	}
+	anchor := strings.ToUpper(peg.Anchor)
+	if err := validateCurrencyCode(anchor); err != nil {
+		return decimal.Zero, nil, errors.New("invalid peg anchor: " + err.Error())
+	}
+	ratio, err := parseRate(peg.Ratio)
+	if err != nil {
+		return decimal.Zero, nil, errors.New("invalid peg ratio: " + err.Error())
+	}
+	if !ratio.IsPositive() {
+		return decimal.Zero, nil, errors.New("peg ratio must be positive")
+	}
+	return decimal.Zero, &model.CurrencyPeg{Anchor: anchor, Ratio: ratio}, nil
+}
+
+type currencyMetadataInput struct {
+	Name       *string  `json:"name"`
+	Symbol     *string  `json:"symbol"`
+	MinorUnits *int     `json:"minor_units"`
+	Countries  []string `json:"countries"`
+	Kind       *string  `json:"kind"`
+}
+
+func (in *currencyMetadataInput) validate() error {
+	if in.Name != nil && len(*in.Name) > commons.MaxCurrencyNameLength {
+		return fmt.Errorf("invalid name, must be up to %d characters", commons.MaxCurrencyNameLength)
+	}
+	if in.Symbol != nil && len(*in.Symbol) > commons.MaxCurrencySymbolLength {
+		return fmt.Errorf("invalid symbol, must be up to %d characters", commons.MaxCurrencySymbolLength)
+	}
+	if in.MinorUnits != nil && (*in.MinorUnits < 0 || *in.MinorUnits > commons.MaxMinorUnits) {
+		return fmt.Errorf("invalid minor_units, must be between 0 and %d", commons.MaxMinorUnits)
+	}
+	for i, country := range in.Countries {
+		country = strings.ToUpper(strings.TrimSpace(country))
+		if len(country) != 2 || strings.Trim(country, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+			return fmt.Errorf("invalid country %q, must be an ISO 3166-1 alpha-2 code", in.Countries[i])
+		}
+		in.Countries[i] = country
+	}
+	if in.Kind != nil {
+		kind := model.CurrencyKind(strings.ToLower(*in.Kind))
+		if !kind.IsValid() {
+			return errors.New("invalid kind, must be one of fiat, crypto, fictional or commodity")
+		}
+		*in.Kind = string(kind)
+	}
+	return nil
+}
+
+func (in *currencyMetadataInput) isEmpty() bool {
+	return in.Name == nil && in.Symbol == nil && in.MinorUnits == nil && in.Countries == nil && in.Kind == nil
+}
+
+func (in *currencyMetadataInput) toMetadata() model.CurrencyMetadata {
+	metadata := model.CurrencyMetadata{
+		MinorUnits: model.MinorUnits(commons.DefaultMinorUnits),
+		Countries:  []string{},
+		Kind:       model.CurrencyKindFictional,
+	}
+	if in.Name != nil {
+		metadata.Name = *in.Name
+	}
+	if in.Symbol != nil {
+		metadata.Symbol = *in.Symbol
+	}
+	if in.MinorUnits != nil {
+		metadata.MinorUnits = in.MinorUnits
+	}
+	if in.Countries != nil {
+		metadata.Countries = in.Countries
+	}
+	if in.Kind != nil {
+		metadata.Kind = model.CurrencyKind(*in.Kind)
+	}
+	return metadata
+}
+
+func (in *currencyMetadataInput) toUpdate() model.CurrencyUpdate {
+	update := model.CurrencyUpdate{
+		Name:       in.Name,
+		Symbol:     in.Symbol,
+		MinorUnits: in.MinorUnits,
+		Countries:  in.Countries,
+	}
+	if in.Kind != nil {
+		kind := model.CurrencyKind(*in.Kind)
+		update.Kind = &kind
+	}
+	return update
+}
+
+func parseAmount(amountStr string) (decimal.Decimal, error) {
 	amountStr = strings.Replace(amountStr, ",", ".", -1)
-	return strconv.ParseFloat(amountStr, 64)
+	return decimal.NewFromString(amountStr)
+}
+func parseRounding(value string) (model.RoundingMode, error) {
+	if value == "" {
+		return model.DefaultRoundingMode, nil
+	}
+	rounding := model.RoundingMode(strings.ToLower(value))
+	if !rounding.IsValid() {
+		return "", errors.New("invalid round, must be one of half-up, half-even, down, up or none")
+	}
+	return rounding, nil
+}
+
+func (h *CurrencyHandler) parseStalePolicy(value string) (model.StaleRatePolicy, error) {
+	if value == "" {
+		return h.stalePolicy, nil
+	}
+	policy := model.StaleRatePolicy(strings.ToLower(value))
+	if !policy.IsValid() {
+		return "", errors.New("invalid on_stale, must be one of flag or reject")
+	}
+	return policy, nil
+}
+
+func validateCurrencyCode(code string) error {
+	if len(code) > commons.AllowedCurrencyLength {
+		return fmt.Errorf("invalid currency code, must be up to %d characters", commons.AllowedCurrencyLength)
+	}
+	if len(code) < commons.MinimumCurrencyLength {
+		return fmt.Errorf("invalid currency code, must be at least %d characters", commons.MinimumCurrencyLength)
+	}
+	return nil
+}
+
+func parseTimestamp(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
 }
-func parseRate(rate interface{}) (float64, error) {
+func parseRate(rate interface{}) (decimal.Decimal, error) {
 	switch v := rate.(type) {
-	case float64:
-		return v, nil
+	case json.Number:
+		return decimal.NewFromString(v.String())
 	case string:
 		v = strings.Replace(v, ",", ".", -1)
-		return strconv.ParseFloat(v, 64)
+		return decimal.NewFromString(v)
 	default:
-		return 0, fmt.Errorf("unsupported rate type")
+		return decimal.Zero, fmt.Errorf("unsupported rate type")
 	}
 }
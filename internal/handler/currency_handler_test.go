@@ -7,13 +7,17 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Lutefd/challenge-bravo/internal/commons"
 	"github.com/Lutefd/challenge-bravo/internal/handler"
 	"github.com/Lutefd/challenge-bravo/internal/model"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -22,17 +26,151 @@ type MockCurrencyService struct {
 	mock.Mock
 }
 
-func (m *MockCurrencyService) Convert(ctx context.Context, from, to string, amount float64) (float64, error) {
+func (m *MockCurrencyService) Convert(ctx context.Context, from, to string, amount decimal.Decimal) (*model.Conversion, error) {
 	args := m.Called(ctx, from, to, amount)
-	return args.Get(0).(float64), args.Error(1)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.Conversion), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCurrencyService) ConvertBatch(ctx context.Context, requests []model.ConversionRequest) ([]model.Conversion, error) {
+	args := m.Called(ctx, requests)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.Conversion), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCurrencyService) ConvertAt(ctx context.Context, from, to string, amount decimal.Decimal, at time.Time) (*model.Conversion, error) {
+	args := m.Called(ctx, from, to, amount, at)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.Conversion), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCurrencyService) ConvertBatchAt(ctx context.Context, requests []model.ConversionRequest, at time.Time) ([]model.Conversion, error) {
+	args := m.Called(ctx, requests, at)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.Conversion), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCurrencyService) GetRateMatrix(ctx context.Context, codes []string) (*model.RateMatrix, error) {
+	args := m.Called(ctx, codes)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.RateMatrix), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCurrencyService) GetRateHistory(ctx context.Context, code string, from, to time.Time) ([]model.RateHistory, error) {
+	args := m.Called(ctx, code, from, to)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.RateHistory), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCurrencyService) GetCurrency(ctx context.Context, code string) (*model.Currency, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.Currency), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCurrencyService) ListCurrencies(ctx context.Context, opts model.CurrencyListOptions) ([]model.Currency, int, error) {
+	args := m.Called(ctx, opts)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.Currency), args.Int(1), args.Error(2)
+	}
+	return nil, args.Int(1), args.Error(2)
 }
 
 func (m *MockCurrencyService) AddCurrency(ctx context.Context, curr *model.Currency) error {
 	args := m.Called(ctx, curr)
 	return args.Error(0)
 }
-func (m *MockCurrencyService) UpdateCurrency(ctx context.Context, code string, rate float64, updatedBy uuid.UUID) error {
-	args := m.Called(ctx, code, rate, updatedBy)
+func (m *MockCurrencyService) UpdateCurrency(ctx context.Context, code string, update model.CurrencyUpdate, updatedBy uuid.UUID) error {
+	args := m.Called(ctx, code, update, updatedBy)
+	return args.Error(0)
+}
+
+var (
+	usdInfo = model.CurrencyInfo{Code: "USD", CurrencyMetadata: model.CurrencyMetadata{
+		Name: "US Dollar", Symbol: "$", MinorUnits: model.MinorUnits(2), Countries: []string{"US"}, Kind: model.CurrencyKindFiat,
+	}}
+	eurInfo = model.CurrencyInfo{Code: "EUR", CurrencyMetadata: model.CurrencyMetadata{
+		Name: "Euro", Symbol: "€", MinorUnits: model.MinorUnits(2), Countries: []string{"DE", "FR"}, Kind: model.CurrencyKindFiat,
+	}}
+	rateUpdatedAt = time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+	usdLeg        = model.RateLeg{Code: "USD", Rate: decimal.NewFromInt(1), UpdatedAt: &rateUpdatedAt, Source: model.CurrencySourceProvider}
+	eurLeg        = model.RateLeg{Code: "EUR", Rate: decimal.RequireFromString("0.85"), UpdatedAt: &rateUpdatedAt, Source: model.CurrencySourceProvider}
+)
+
+const (
+	usdInfoJSON = `{"code":"USD","name":"US Dollar","symbol":"$","minor_units":2,"countries":["US"],"kind":"fiat"}`
+	eurInfoJSON = `{"code":"EUR","name":"Euro","symbol":"€","minor_units":2,"countries":["DE","FR"],"kind":"fiat"}`
+	usdLegJSON  = `{"code":"USD","rate":"1","updated_at":"2024-08-10T12:00:00Z","source":"provider","stale":false}`
+	eurLegJSON  = `{"code":"EUR","rate":"0.85","updated_at":"2024-08-10T12:00:00Z","source":"provider","stale":false}`
+)
+
+func decimalEq(value string) interface{} {
+	expected := decimal.RequireFromString(value)
+	return mock.MatchedBy(func(d decimal.Decimal) bool {
+		return d.Equal(expected)
+	})
+}
+
+func (m *MockCurrencyService) SetCurrencyLock(ctx context.Context, code string, locked bool) error {
+	args := m.Called(ctx, code, locked)
+	return args.Error(0)
+}
+
+func (m *MockCurrencyService) ScheduleRateChange(ctx context.Context, change *model.ScheduledRateChange) error {
+	args := m.Called(ctx, change)
+	return args.Error(0)
+}
+
+func (m *MockCurrencyService) ListScheduledRateChanges(ctx context.Context, code string) ([]model.ScheduledRateChange, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.ScheduledRateChange), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCurrencyService) CancelScheduledRateChange(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockCurrencyService) SetJumpThreshold(ctx context.Context, code string, threshold *decimal.Decimal) error {
+	args := m.Called(ctx, code, threshold)
+	return args.Error(0)
+}
+
+func (m *MockCurrencyService) ListQuarantinedRates(ctx context.Context, code string) ([]model.QuarantinedRate, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.QuarantinedRate), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCurrencyService) ApproveQuarantinedRate(ctx context.Context, id, approvedBy uuid.UUID) (*model.QuarantinedRate, error) {
+	args := m.Called(ctx, id, approvedBy)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.QuarantinedRate), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCurrencyService) RejectQuarantinedRate(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
@@ -60,9 +198,9 @@ func TestConvertCurrency(t *testing.T) {
 			to:             "EUR",
 			amount:         "100.00",
 			expectedStatus: http.StatusOK,
-			expectedBody:   `{"amount":100,"from":"USD","result":85,"to":"EUR"}`,
+			expectedBody:   `{"amount":"100","from":"USD","result":"85.00","round":"half-up","precision":2,"to":"EUR","from_currency":` + usdInfoJSON + `,"to_currency":` + eurInfoJSON + `,"from_rate":` + usdLegJSON + `,"to_rate":` + eurLegJSON + `,"stale":false}`,
 			mockBehavior: func() {
-				mockService.On("Convert", mock.Anything, "USD", "EUR", 100.0).Return(85.0, nil).Once()
+				mockService.On("Convert", mock.Anything, "USD", "EUR", decimalEq("100")).Return(&model.Conversion{From: usdInfo, To: eurInfo, Amount: decimal.NewFromInt(100), Result: decimal.NewFromInt(85), FromRate: usdLeg, ToRate: eurLeg}, nil).Once()
 			},
 		},
 		{
@@ -71,9 +209,20 @@ func TestConvertCurrency(t *testing.T) {
 			to:             "EUR",
 			amount:         "100,00",
 			expectedStatus: http.StatusOK,
-			expectedBody:   `{"amount":100,"from":"USD","result":85,"to":"EUR"}`,
+			expectedBody:   `{"amount":"100","from":"USD","result":"85.00","round":"half-up","precision":2,"to":"EUR","from_currency":` + usdInfoJSON + `,"to_currency":` + eurInfoJSON + `,"from_rate":` + usdLegJSON + `,"to_rate":` + eurLegJSON + `,"stale":false}`,
 			mockBehavior: func() {
-				mockService.On("Convert", mock.Anything, "USD", "EUR", 100.0).Return(85.0, nil).Once()
+				mockService.On("Convert", mock.Anything, "USD", "EUR", decimalEq("100")).Return(&model.Conversion{From: usdInfo, To: eurInfo, Amount: decimal.NewFromInt(100), Result: decimal.NewFromInt(85), FromRate: usdLeg, ToRate: eurLeg}, nil).Once()
+			},
+		},
+		{
+			name:           "Conversion from a rate snapshot",
+			from:           "USD",
+			to:             "EUR",
+			amount:         "100",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"amount":"100","from":"USD","result":"85.00","round":"half-up","precision":2,"to":"EUR","from_currency":` + usdInfoJSON + `,"to_currency":` + eurInfoJSON + `,"from_rate":` + usdLegJSON + `,"to_rate":` + eurLegJSON + `,"stale":false,"rate_version":12}`,
+			mockBehavior: func() {
+				mockService.On("Convert", mock.Anything, "USD", "EUR", decimalEq("100")).Return(&model.Conversion{From: usdInfo, To: eurInfo, Amount: decimal.NewFromInt(100), Result: decimal.NewFromInt(85), FromRate: usdLeg, ToRate: eurLeg, Version: 12}, nil).Once()
 			},
 		},
 		{
@@ -102,7 +251,7 @@ func TestConvertCurrency(t *testing.T) {
 			expectedStatus: http.StatusNotFound,
 			expectedBody:   `{"error":"currency not found: XYZ"}`,
 			mockBehavior: func() {
-				mockService.On("Convert", mock.Anything, "XYZ", "EUR", 100.0).Return(0.0, fmt.Errorf("%w: XYZ", model.ErrCurrencyNotFound)).Once()
+				mockService.On("Convert", mock.Anything, "XYZ", "EUR", decimalEq("100")).Return(nil, fmt.Errorf("%w: XYZ", model.ErrCurrencyNotFound)).Once()
 			},
 		},
 		{
@@ -113,7 +262,7 @@ func TestConvertCurrency(t *testing.T) {
 			expectedStatus: http.StatusNotFound,
 			expectedBody:   `{"error":"currency not found: XYZ"}`,
 			mockBehavior: func() {
-				mockService.On("Convert", mock.Anything, "USD", "XYZ", 100.0).Return(0.0, fmt.Errorf("%w: XYZ", model.ErrCurrencyNotFound)).Once()
+				mockService.On("Convert", mock.Anything, "USD", "XYZ", decimalEq("100")).Return(nil, fmt.Errorf("%w: XYZ", model.ErrCurrencyNotFound)).Once()
 			},
 		},
 	}
@@ -135,93 +284,910 @@ func TestConvertCurrency(t *testing.T) {
 	}
 }
 
-func TestAddCurrency(t *testing.T) {
+func TestConvertCurrency_Rounding(t *testing.T) {
+	mockService := new(MockCurrencyService)
+	h := handler.NewCurrencyHandler(mockService)
+
+	infos := map[string]model.CurrencyInfo{
+		"EUR": eurInfo,
+		"BTC": {Code: "BTC", CurrencyMetadata: model.KnownCurrencyMetadata("BTC")},
+		"JPY": {Code: "JPY", CurrencyMetadata: model.KnownCurrencyMetadata("JPY")},
+		"XDR": {Code: "XDR", CurrencyMetadata: model.KnownCurrencyMetadata("XDR")},
+	}
+
+	tests := []struct {
+		name              string
+		to                string
+		round             string
+		result            string
+		expectedStatus    int
+		expectedResult    string
+		expectedPrecision int32
+		expectedRound     string
+	}{
+		{"Default rounds half up to minor units", "EUR", "", "12.345", http.StatusOK, "12.35", 2, "half-up"},
+		{"Half even", "EUR", "half-even", "12.345", http.StatusOK, "12.34", 2, "half-even"},
+		{"Down", "EUR", "down", "12.349", http.StatusOK, "12.34", 2, "down"},
+		{"Up", "EUR", "UP", "12.341", http.StatusOK, "12.35", 2, "up"},
+		{"None keeps every digit", "EUR", "none", "12.3456789", http.StatusOK, "12.3456789", 7, "none"},
+		{"Provider-created crypto keeps its minor units", "BTC", "", "0.0001600049", http.StatusOK, "0.00016000", 8, "half-up"},
+		{"Provider-created zero decimal currency", "JPY", "", "1499.5", http.StatusOK, "1500", 0, "half-up"},
+		{"Unknown minor units are not rounded", "XDR", "", "7.4801234567", http.StatusOK, "7.4801234567", 10, "none"},
+		{"Invalid mode", "EUR", "ceiling", "", http.StatusBadRequest, "", 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.expectedStatus == http.StatusOK {
+				mockService.On("Convert", mock.Anything, "USD", tt.to, decimalEq("10")).Return(&model.Conversion{
+					From: usdInfo, To: infos[tt.to], Amount: decimal.NewFromInt(10), Result: decimal.RequireFromString(tt.result),
+				}, nil).Once()
+			}
+
+			req, _ := http.NewRequest("GET", "/convert?from=USD&to="+tt.to+"&amount=10&round="+tt.round, nil)
+			rr := httptest.NewRecorder()
+
+			h.ConvertCurrency(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus != http.StatusOK {
+				assert.JSONEq(t, `{"error":"invalid round, must be one of half-up, half-even, down, up or none"}`, rr.Body.String())
+				return
+			}
+
+			var response struct {
+				Result    string `json:"result"`
+				Round     string `json:"round"`
+				Precision int32  `json:"precision"`
+			}
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, tt.expectedResult, response.Result)
+			assert.Equal(t, tt.expectedPrecision, response.Precision)
+			assert.Equal(t, tt.expectedRound, response.Round)
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestConvertCurrency_MultipleTargets(t *testing.T) {
+	mockService := new(MockCurrencyService)
+	h := handler.NewCurrencyHandler(mockService)
+
+	brlInfo := model.CurrencyInfo{Code: "BRL", CurrencyMetadata: model.CurrencyMetadata{MinorUnits: model.MinorUnits(2), Countries: []string{}, Kind: model.CurrencyKindFiat}}
+
+	t.Run("Converts into every target", func(t *testing.T) {
+		mockService.On("ConvertBatch", mock.Anything, mock.MatchedBy(func(requests []model.ConversionRequest) bool {
+			return len(requests) == 2 && requests[0].From == "USD" && requests[0].To == "EUR" && requests[1].To == "BRL" &&
+				requests[0].Amount.Equal(decimal.NewFromInt(100)) && requests[1].Amount.Equal(decimal.NewFromInt(100))
+		})).Return([]model.Conversion{
+			{From: usdInfo, To: eurInfo, Amount: decimal.NewFromInt(100), Result: decimal.NewFromInt(85), Version: 3},
+			{From: usdInfo, To: brlInfo, Amount: decimal.NewFromInt(100), Result: decimal.RequireFromString("523.456"), Version: 3},
+		}, nil).Once()
+
+		req, _ := http.NewRequest("GET", "/convert?from=USD&to=EUR,brl&amount=100", nil)
+		rr := httptest.NewRecorder()
+
+		h.ConvertCurrency(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var response struct {
+			From        string `json:"from"`
+			Amount      string `json:"amount"`
+			RateVersion uint64 `json:"rate_version"`
+			Conversions []struct {
+				To          string `json:"to"`
+				Result      string `json:"result"`
+				RateVersion uint64 `json:"rate_version"`
+			} `json:"conversions"`
+		}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "USD", response.From)
+		assert.Equal(t, "100", response.Amount)
+		assert.Equal(t, uint64(3), response.RateVersion)
+		assert.Len(t, response.Conversions, 2)
+		assert.Equal(t, "EUR", response.Conversions[0].To)
+		assert.Equal(t, "85.00", response.Conversions[0].Result)
+		assert.Equal(t, "BRL", response.Conversions[1].To)
+		assert.Equal(t, "523.46", response.Conversions[1].Result)
+		assert.Equal(t, uint64(3), response.Conversions[1].RateVersion)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Converts into every target on a date", func(t *testing.T) {
+		at := time.Date(2024, 8, 11, 0, 0, 0, 0, time.UTC)
+		mockService.On("ConvertBatchAt", mock.Anything, mock.MatchedBy(func(requests []model.ConversionRequest) bool {
+			return len(requests) == 2 && requests[0].From == "USD" && requests[0].To == "EUR" && requests[1].To == "BRL"
+		}), at).Return([]model.Conversion{
+			{From: usdInfo, To: eurInfo, Amount: decimal.NewFromInt(100), Result: decimal.NewFromInt(80), FromRate: usdLeg, ToRate: eurLeg},
+			{From: usdInfo, To: brlInfo, Amount: decimal.NewFromInt(100), Result: decimal.NewFromInt(520), FromRate: usdLeg},
+		}, nil).Once()
+
+		req, _ := http.NewRequest("GET", "/convert?from=USD&to=EUR,BRL&amount=100&date=2024-08-10", nil)
+		rr := httptest.NewRecorder()
+
+		h.ConvertCurrency(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var response struct {
+			Date        string `json:"date"`
+			Conversions []struct {
+				To     string `json:"to"`
+				Result string `json:"result"`
+			} `json:"conversions"`
+		}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "2024-08-10", response.Date)
+		assert.Len(t, response.Conversions, 2)
+		assert.Equal(t, "80.00", response.Conversions[0].Result)
+		assert.Equal(t, "520.00", response.Conversions[1].Result)
+		mockService.AssertExpectations(t)
+		mockService.AssertNotCalled(t, "ConvertAt", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unknown target", func(t *testing.T) {
+		mockService.On("ConvertBatch", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w: XYZ", model.ErrCurrencyNotFound)).Once()
+
+		req, _ := http.NewRequest("GET", "/convert?from=USD&to=EUR,XYZ&amount=100", nil)
+		rr := httptest.NewRecorder()
+
+		h.ConvertCurrency(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.JSONEq(t, `{"error":"currency not found: XYZ"}`, rr.Body.String())
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid target", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/convert?from=USD&to=EUR,X&amount=100", nil)
+		rr := httptest.NewRecorder()
+
+		h.ConvertCurrency(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.JSONEq(t, `{"error":"invalid currency code, must be at least 3 characters"}`, rr.Body.String())
+	})
+
+	t.Run("Too many targets", func(t *testing.T) {
+		targets := strings.Repeat("EUR,", commons.MaxConversionTargets) + "BRL"
+		req, _ := http.NewRequest("GET", "/convert?from=USD&to="+targets+"&amount=100", nil)
+		rr := httptest.NewRecorder()
+
+		h.ConvertCurrency(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.JSONEq(t, fmt.Sprintf(`{"error":"too many target currencies, must be up to %d"}`, commons.MaxConversionTargets), rr.Body.String())
+	})
+}
+
+func TestConvertBatch(t *testing.T) {
 	mockService := new(MockCurrencyService)
 	h := handler.NewCurrencyHandler(mockService)
 
 	tests := []struct {
 		name           string
-		payload        map[string]interface{}
+		payload        string
 		expectedStatus int
 		expectedBody   string
 		mockBehavior   func()
 	}{
 		{
-			name: "Valid currency with period",
-			payload: map[string]interface{}{
-				"code":        "USD",
-				"rate_to_usd": 1.0,
-			},
-			expectedStatus: http.StatusCreated,
-			expectedBody:   `{"message":"currency added successfully"}`,
+			name:           "Valid batch",
+			payload:        `[{"from":"usd","to":"EUR","amount":100},{"from":"EUR","to":"USD","amount":"85"}]`,
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"conversions":[` +
+				`{"from":"USD","to":"EUR","amount":"100","result":"85.00","round":"half-up","precision":2,"from_currency":` + usdInfoJSON + `,"to_currency":` + eurInfoJSON + `,"from_rate":` + usdLegJSON + `,"to_rate":` + eurLegJSON + `,"stale":false},` +
+				`{"from":"EUR","to":"USD","amount":"85","result":"100.00","round":"half-up","precision":2,"from_currency":` + eurInfoJSON + `,"to_currency":` + usdInfoJSON + `,"from_rate":` + eurLegJSON + `,"to_rate":` + usdLegJSON + `,"stale":false}]}`,
 			mockBehavior: func() {
-				mockService.On("AddCurrency", mock.Anything, mock.MatchedBy(func(c *model.Currency) bool {
-					return c.Code == "USD" && c.Rate == 1.0
-				})).Return(nil).Once()
+				mockService.On("ConvertBatch", mock.Anything, mock.MatchedBy(func(requests []model.ConversionRequest) bool {
+					return len(requests) == 2 && requests[0].From == "USD" && requests[0].To == "EUR" && requests[1].From == "EUR" &&
+						requests[0].Amount.Equal(decimal.NewFromInt(100)) && requests[1].Amount.Equal(decimal.NewFromInt(85))
+				})).Return([]model.Conversion{
+					{From: usdInfo, To: eurInfo, Amount: decimal.NewFromInt(100), Result: decimal.NewFromInt(85), FromRate: usdLeg, ToRate: eurLeg},
+					{From: eurInfo, To: usdInfo, Amount: decimal.NewFromInt(85), Result: decimal.NewFromInt(100), FromRate: eurLeg, ToRate: usdLeg},
+				}, nil).Once()
 			},
 		},
 		{
-			name: "Valid currency with comma",
-			payload: map[string]interface{}{
-				"code":        "EUR",
-				"rate_to_usd": "0,85",
-			},
-			expectedStatus: http.StatusCreated,
-			expectedBody:   `{"message":"currency added successfully"}`,
+			name:           "Empty batch",
+			payload:        `[]`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"batch must contain at least one item"}`,
+			mockBehavior:   func() {},
+		},
+		{
+			name:           "Invalid payload",
+			payload:        `{"from":"USD"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid request payload"}`,
+			mockBehavior:   func() {},
+		},
+		{
+			name:           "Invalid currency code",
+			payload:        `[{"from":"USD","to":"EUR","amount":1},{"from":"USD","to":"TOOLONG","amount":1}]`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"item 1: invalid currency code, must be up to 5 characters"}`,
+			mockBehavior:   func() {},
+		},
+		{
+			name:           "Missing amount",
+			payload:        `[{"from":"USD","to":"EUR"}]`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"item 0: invalid amount"}`,
+			mockBehavior:   func() {},
+		},
+		{
+			name:           "Negative amount",
+			payload:        `[{"from":"USD","to":"EUR","amount":-1}]`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"item 0: amount must be non-negative"}`,
+			mockBehavior:   func() {},
+		},
+		{
+			name:           "Currency not found",
+			payload:        `[{"from":"USD","to":"XYZ","amount":1}]`,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"currency not found: XYZ"}`,
 			mockBehavior: func() {
-				mockService.On("AddCurrency", mock.Anything, mock.MatchedBy(func(c *model.Currency) bool {
-					return c.Code == "EUR" && c.Rate == 0.85
-				})).Return(nil).Once()
+				mockService.On("ConvertBatch", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w: XYZ", model.ErrCurrencyNotFound)).Once()
 			},
 		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior()
+
+			req, _ := http.NewRequest("POST", "/convert/batch", strings.NewReader(tt.payload))
+			rr := httptest.NewRecorder()
+
+			h.ConvertBatch(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetRateMatrix(t *testing.T) {
+	mockService := new(MockCurrencyService)
+	h := handler.NewCurrencyHandler(mockService)
+
+	generatedAt := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		codes          string
+		expectedStatus int
+		expectedBody   string
+		mockBehavior   func()
+	}{
 		{
-			name: "Negative rate",
-			payload: map[string]interface{}{
-				"code":        "USD",
-				"rate_to_usd": -1.0,
+			name:           "Valid matrix",
+			codes:          "usd, EUR,USD",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"codes":["USD","EUR"],"rates":{"USD":{"USD":"1","EUR":"0.8"},"EUR":{"USD":"1.25","EUR":"1"}},"generated_at":"2024-08-10T12:00:00Z"}`,
+			mockBehavior: func() {
+				mockService.On("GetRateMatrix", mock.Anything, []string{"USD", "EUR"}).Return(&model.RateMatrix{
+					Codes: []string{"USD", "EUR"},
+					Rates: map[string]map[string]decimal.Decimal{
+						"USD": {"USD": decimal.NewFromInt(1), "EUR": decimal.RequireFromString("0.8")},
+						"EUR": {"USD": decimal.RequireFromString("1.25"), "EUR": decimal.NewFromInt(1)},
+					},
+					GeneratedAt: generatedAt,
+				}, nil).Once()
 			},
+		},
+		{
+			name:           "Missing codes",
+			codes:          "",
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"error":"rate must be positive"}`,
+			expectedBody:   `{"error":"missing required parameters"}`,
 			mockBehavior:   func() {},
 		},
 		{
-			name: "Invalid rate type",
-			payload: map[string]interface{}{
-				"code":        "USD",
-				"rate_to_usd": "invalid",
-			},
+			name:           "Invalid code",
+			codes:          "USD,TOOLONG",
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"error":"invalid rate: strconv.ParseFloat: parsing \"invalid\": invalid syntax"}`,
+			expectedBody:   `{"error":"invalid currency code, must be up to 5 characters"}`,
 			mockBehavior:   func() {},
 		},
 		{
-			name: "Missing code",
-			payload: map[string]interface{}{
-				"rate_to_usd": 1.0,
+			name:           "Currency not found",
+			codes:          "USD,XYZ",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"currency not found: XYZ"}`,
+			mockBehavior: func() {
+				mockService.On("GetRateMatrix", mock.Anything, []string{"USD", "XYZ"}).Return(nil, fmt.Errorf("%w: XYZ", model.ErrCurrencyNotFound)).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior()
+
+			req, _ := http.NewRequest("GET", "/matrix?codes="+url.QueryEscape(tt.codes), nil)
+			rr := httptest.NewRecorder()
+
+			h.GetRateMatrix(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestConvertCurrency_WithDate(t *testing.T) {
+	mockService := new(MockCurrencyService)
+	h := handler.NewCurrencyHandler(mockService)
+
+	tests := []struct {
+		name           string
+		date           string
+		expectedStatus int
+		expectedBody   string
+		mockBehavior   func()
+	}{
+		{
+			name:           "Valid historical conversion",
+			date:           "2024-08-10",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"amount":"100","date":"2024-08-10","from":"USD","result":"80.00","round":"half-up","precision":2,"to":"EUR","from_currency":` + usdInfoJSON + `,"to_currency":` + eurInfoJSON + `,"from_rate":` + usdLegJSON + `,"to_rate":` + eurLegJSON + `,"stale":false}`,
+			mockBehavior: func() {
+				at := time.Date(2024, 8, 11, 0, 0, 0, 0, time.UTC)
+				mockService.On("ConvertAt", mock.Anything, "USD", "EUR", decimalEq("100"), at).Return(&model.Conversion{From: usdInfo, To: eurInfo, Amount: decimal.NewFromInt(100), Result: decimal.NewFromInt(80), FromRate: usdLeg, ToRate: eurLeg}, nil).Once()
 			},
-			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"error":"invalid currency code"}`,
-			mockBehavior:   func() {},
 		},
 		{
-			name: "Invalid code length - Above Maximum Allowed",
+			name:           "No rate recorded for date",
+			date:           "2020-01-01",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"currency not found: USD"}`,
+			mockBehavior: func() {
+				at := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+				mockService.On("ConvertAt", mock.Anything, "USD", "EUR", decimalEq("100"), at).Return(nil, fmt.Errorf("%w: USD", model.ErrCurrencyNotFound)).Once()
+			},
+		},
+		{
+			name:           "Invalid date format",
+			date:           "10/08/2024",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid date, must be in YYYY-MM-DD format"}`,
+			mockBehavior:   func() {},
+		},
+		{
+			name:           "Future date",
+			date:           time.Now().AddDate(0, 0, 2).Format(time.DateOnly),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"date must not be in the future"}`,
+			mockBehavior:   func() {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior()
+
+			req, _ := http.NewRequest("GET", "/convert?from=USD&to=EUR&amount=100&date="+tt.date, nil)
+			rr := httptest.NewRecorder()
+
+			h.ConvertCurrency(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestConvertCurrency_StaleRates(t *testing.T) {
+	staleLeg := eurLeg
+	staleLeg.Stale = true
+	staleConversion := func() *model.Conversion {
+		return &model.Conversion{From: usdInfo, To: eurInfo, Amount: decimal.NewFromInt(100), Result: decimal.NewFromInt(85), FromRate: usdLeg, ToRate: staleLeg, Stale: true}
+	}
+
+	tests := []struct {
+		name           string
+		policy         model.StaleRatePolicy
+		onStale        string
+		expectedStatus int
+		expectedError  string
+	}{
+		{"Flagged by default", model.StaleRatePolicyFlag, "", http.StatusOK, ""},
+		{"Client rejects stale rates", model.StaleRatePolicyFlag, "reject", http.StatusServiceUnavailable, "rates are stale for EUR"},
+		{"Rejected by configured policy", model.StaleRatePolicyReject, "", http.StatusServiceUnavailable, "rates are stale for EUR"},
+		{"Client accepts flagged rates", model.StaleRatePolicyReject, "FLAG", http.StatusOK, ""},
+		{"Invalid policy", model.StaleRatePolicyFlag, "ignore", http.StatusBadRequest, "invalid on_stale, must be one of flag or reject"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockCurrencyService)
+			h := handler.NewCurrencyHandler(mockService, handler.WithStaleRatePolicy(tt.policy))
+			if tt.expectedStatus != http.StatusBadRequest {
+				mockService.On("Convert", mock.Anything, "USD", "EUR", decimalEq("100")).Return(staleConversion(), nil).Once()
+			}
+
+			req, _ := http.NewRequest("GET", "/convert?from=USD&to=EUR&amount=100&on_stale="+tt.onStale, nil)
+			rr := httptest.NewRecorder()
+
+			h.ConvertCurrency(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedError != "" {
+				assert.JSONEq(t, `{"error":"`+tt.expectedError+`"}`, rr.Body.String())
+			} else {
+				var response struct {
+					Stale  bool          `json:"stale"`
+					ToRate model.RateLeg `json:"to_rate"`
+				}
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.True(t, response.Stale)
+				assert.True(t, response.ToRate.Stale)
+				assert.True(t, rateUpdatedAt.Equal(*response.ToRate.UpdatedAt))
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+
+	t.Run("Batch rejects stale rates", func(t *testing.T) {
+		mockService := new(MockCurrencyService)
+		h := handler.NewCurrencyHandler(mockService, handler.WithStaleRatePolicy(model.StaleRatePolicyReject))
+		mockService.On("ConvertBatch", mock.Anything, mock.Anything).Return([]model.Conversion{*staleConversion()}, nil).Once()
+
+		req, _ := http.NewRequest("POST", "/convert/batch", strings.NewReader(`[{"from":"USD","to":"EUR","amount":100}]`))
+		rr := httptest.NewRecorder()
+
+		h.ConvertBatch(rr, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.JSONEq(t, `{"error":"rates are stale for EUR"}`, rr.Body.String())
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestGetCurrencyHistory(t *testing.T) {
+	mockService := new(MockCurrencyService)
+	h := handler.NewCurrencyHandler(mockService)
+
+	recordedAt := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+	updatedBy := uuid.New()
+
+	tests := []struct {
+		name           string
+		url            string
+		expectedStatus int
+		expectedBody   string
+		mockBehavior   func()
+	}{
+		{
+			name:           "Valid range",
+			url:            "/currency/eur/history?from=2024-08-01&to=2024-08-31",
+			expectedStatus: http.StatusOK,
+			expectedBody: fmt.Sprintf(`{"code":"EUR","from":"2024-08-01","to":"2024-08-31","history":[{"code":"EUR","rate":"0.85","recorded_at":"2024-08-10T12:00:00Z","updated_by":"%s"}]}`,
+				updatedBy),
+			mockBehavior: func() {
+				from := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+				to := time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)
+				mockService.On("GetRateHistory", mock.Anything, "EUR", from, to).Return([]model.RateHistory{
+					{Code: "EUR", Rate: decimal.RequireFromString("0.85"), RecordedAt: recordedAt, UpdatedBy: updatedBy},
+				}, nil).Once()
+			},
+		},
+		{
+			name:           "Invalid from date",
+			url:            "/currency/EUR/history?from=yesterday",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid from date, must be in YYYY-MM-DD format"}`,
+			mockBehavior:   func() {},
+		},
+		{
+			name:           "From after to",
+			url:            "/currency/EUR/history?from=2024-09-01&to=2024-08-01",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"from date must not be after to date"}`,
+			mockBehavior:   func() {},
+		},
+		{
+			name:           "Invalid code length",
+			url:            "/currency/EU/history",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   fmt.Sprintf(`{"error":"invalid currency code, must be at least %d characters"}`, commons.MinimumCurrencyLength),
+			mockBehavior:   func() {},
+		},
+		{
+			name:           "Unknown currency",
+			url:            "/currency/XYZ/history",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"currency not found: XYZ"}`,
+			mockBehavior: func() {
+				mockService.On("GetRateHistory", mock.Anything, "XYZ", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w: XYZ", model.ErrCurrencyNotFound)).Once()
+			},
+		},
+		{
+			name:           "Service error",
+			url:            "/currency/EUR/history?from=2024-08-01&to=2024-08-31",
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"failed to get rate history"}`,
+			mockBehavior: func() {
+				mockService.On("GetRateHistory", mock.Anything, "EUR", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("db error")).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior()
+
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			rr := httptest.NewRecorder()
+
+			router := chi.NewRouter()
+			router.Get("/currency/{code}/history", h.GetCurrencyHistory)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListCurrencies(t *testing.T) {
+	mockService := new(MockCurrencyService)
+	h := handler.NewCurrencyHandler(mockService)
+
+	updatedAt := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedBody   string
+		mockBehavior   func()
+	}{
+		{
+			name:           "Default pagination",
+			query:          "",
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"currencies":[{"code":"EUR","rate":"0.85","updated_at":"2024-08-10T12:00:00Z","created_by":"00000000-0000-0000-0000-000000000000","updated_by":"00000000-0000-0000-0000-000000000000","created_at":"2024-08-10T12:00:00Z",
+				"name":"Euro","symbol":"€","minor_units":2,"countries":["DE","FR"],"kind":"fiat","source":"provider","locked":false}],
+				"page":1,"page_size":20,"total":1}`,
+			mockBehavior: func() {
+				opts := model.CurrencyListOptions{SortBy: "code", Limit: commons.DefaultPageSize, Offset: 0}
+				mockService.On("ListCurrencies", mock.Anything, opts).Return([]model.Currency{
+					{Code: "EUR", Rate: decimal.RequireFromString("0.85"), UpdatedAt: updatedAt, CreatedAt: updatedAt, Source: model.CurrencySourceProvider, CurrencyMetadata: eurInfo.CurrencyMetadata},
+				}, 1, nil).Once()
+			},
+		},
+		{
+			name:           "Filters, sorting and pagination",
+			query:          "?page=3&page_size=10&sort=updated_at&order=desc&source=manual&updated_since=2024-08-01",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"currencies":[],"page":3,"page_size":10,"total":5}`,
+			mockBehavior: func() {
+				opts := model.CurrencyListOptions{
+					Source:       model.CurrencySourceManual,
+					UpdatedSince: time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC),
+					SortBy:       "updated_at",
+					Descending:   true,
+					Limit:        10,
+					Offset:       20,
+				}
+				mockService.On("ListCurrencies", mock.Anything, opts).Return([]model.Currency{}, 5, nil).Once()
+			},
+		},
+		{
+			name:           "Invalid page",
+			query:          "?page=0",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid page, must be a positive integer"}`,
+			mockBehavior:   func() {},
+		},
+		{
+			name:           "Page size too large",
+			query:          "?page_size=1000",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   fmt.Sprintf(`{"error":"invalid page_size, must be between 1 and %d"}`, commons.MaxPageSize),
+			mockBehavior:   func() {},
+		},
+		{
+			name:           "Invalid sort field",
+			query:          "?sort=created_by",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid sort field"}`,
+			mockBehavior:   func() {},
+		},
+		{
+			name:           "Invalid source",
+			query:          "?source=unknown",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid source, must be provider or manual"}`,
+			mockBehavior:   func() {},
+		},
+		{
+			name:           "Invalid updated_since",
+			query:          "?updated_since=yesterday",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid updated_since, must be an RFC 3339 timestamp or YYYY-MM-DD date"}`,
+			mockBehavior:   func() {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior()
+
+			req, _ := http.NewRequest("GET", "/currency"+tt.query, nil)
+			rr := httptest.NewRecorder()
+
+			h.ListCurrencies(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetCurrency(t *testing.T) {
+	mockService := new(MockCurrencyService)
+	h := handler.NewCurrencyHandler(mockService)
+
+	router := chi.NewRouter()
+	router.Get("/currency/{code}", h.GetCurrency)
+
+	t.Run("Success", func(t *testing.T) {
+		updatedAt := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+		userID := uuid.New()
+		mockService.On("GetCurrency", mock.Anything, "EUR").Return(&model.Currency{
+			Code: "EUR", Rate: decimal.RequireFromString("0.85"), UpdatedAt: updatedAt, CreatedAt: updatedAt, CreatedBy: userID, UpdatedBy: userID,
+			Source: model.CurrencySourceManual, Locked: true,
+			CurrencyMetadata: eurInfo.CurrencyMetadata,
+		}, nil).Once()
+
+		req, _ := http.NewRequest("GET", "/currency/eur", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, fmt.Sprintf(`{"code":"EUR","rate":"0.85","updated_at":"2024-08-10T12:00:00Z","created_by":"%[1]s","updated_by":"%[1]s","created_at":"2024-08-10T12:00:00Z",
+			"name":"Euro","symbol":"€","minor_units":2,"countries":["DE","FR"],"kind":"fiat","source":"manual","locked":true}`, userID), rr.Body.String())
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		mockService.On("GetCurrency", mock.Anything, "XYZ").Return(nil, fmt.Errorf("%w: XYZ", model.ErrCurrencyNotFound)).Once()
+
+		req, _ := http.NewRequest("GET", "/currency/XYZ", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.JSONEq(t, `{"error":"currency not found: XYZ"}`, rr.Body.String())
+	})
+
+	t.Run("Invalid code length", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/currency/RRRRRR", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	mockService.AssertExpectations(t)
+}
+
+func TestAddCurrency(t *testing.T) {
+	mockService := new(MockCurrencyService)
+	h := handler.NewCurrencyHandler(mockService)
+
+	tests := []struct {
+		name           string
+		payload        map[string]interface{}
+		expectedStatus int
+		expectedBody   string
+		mockBehavior   func()
+	}{
+		{
+			name: "Valid currency with period",
+			payload: map[string]interface{}{
+				"code":        "USD",
+				"rate_to_usd": 1.0,
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"message":"currency added successfully"}`,
+			mockBehavior: func() {
+				mockService.On("AddCurrency", mock.Anything, mock.MatchedBy(func(c *model.Currency) bool {
+					return c.Code == "USD" && c.Rate.Equal(decimal.RequireFromString("1"))
+				})).Return(nil).Once()
+			},
+		},
+		{
+			name: "Valid currency with comma",
+			payload: map[string]interface{}{
+				"code":        "EUR",
+				"rate_to_usd": "0,85",
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"message":"currency added successfully"}`,
+			mockBehavior: func() {
+				mockService.On("AddCurrency", mock.Anything, mock.MatchedBy(func(c *model.Currency) bool {
+					return c.Code == "EUR" && c.Rate.Equal(decimal.RequireFromString("0.85"))
+				})).Return(nil).Once()
+			},
+		},
+		{
+			name: "Valid currency with metadata",
+			payload: map[string]interface{}{
+				"code":        "HURB",
+				"rate_to_usd": 2.5,
+				"name":        "Hurb Coin",
+				"symbol":      "H$",
+				"minor_units": 0,
+				"countries":   []string{"br"},
+				"kind":        "Fictional",
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"message":"currency added successfully"}`,
+			mockBehavior: func() {
+				mockService.On("AddCurrency", mock.Anything, mock.MatchedBy(func(c *model.Currency) bool {
+					return c.Code == "HURB" && c.Name == "Hurb Coin" && c.Symbol == "H$" && *c.MinorUnits == 0 &&
+						len(c.Countries) == 1 && c.Countries[0] == "BR" && c.Kind == model.CurrencyKindFictional
+				})).Return(nil).Once()
+			},
+		},
+		{
+			name: "Metadata defaults",
+			payload: map[string]interface{}{
+				"code":        "GTA",
+				"rate_to_usd": 10,
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"message":"currency added successfully"}`,
+			mockBehavior: func() {
+				mockService.On("AddCurrency", mock.Anything, mock.MatchedBy(func(c *model.Currency) bool {
+					return c.Code == "GTA" && *c.MinorUnits == commons.DefaultMinorUnits && c.Countries != nil && c.Kind == model.CurrencyKindFictional
+				})).Return(nil).Once()
+			},
+		},
+		{
+			name: "Invalid kind",
+			payload: map[string]interface{}{
+				"code":        "GTA",
+				"rate_to_usd": 10,
+				"kind":        "metal",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid kind, must be one of fiat, crypto, fictional or commodity"}`,
+			mockBehavior:   func() {},
+		},
+		{
+			name: "Invalid minor units",
+			payload: map[string]interface{}{
+				"code":        "GTA",
+				"rate_to_usd": 10,
+				"minor_units": 30,
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   fmt.Sprintf(`{"error":"invalid minor_units, must be between 0 and %d"}`, commons.MaxMinorUnits),
+			mockBehavior:   func() {},
+		},
+		{
+			name: "Invalid country",
+			payload: map[string]interface{}{
+				"code":        "GTA",
+				"rate_to_usd": 10,
+				"countries":   []string{"BRA"},
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid country \"BRA\", must be an ISO 3166-1 alpha-2 code"}`,
+			mockBehavior:   func() {},
+		},
+		{
+			name: "Negative rate",
+			payload: map[string]interface{}{
+				"code":        "USD",
+				"rate_to_usd": -1.0,
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"rate must be positive"}`,
+			mockBehavior:   func() {},
+		},
+		{
+			name: "Invalid rate type",
+			payload: map[string]interface{}{
+				"code":        "USD",
+				"rate_to_usd": "invalid",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid rate: can't convert invalid to decimal"}`,
+			mockBehavior:   func() {},
+		},
+		{
+			name: "Missing code",
+			payload: map[string]interface{}{
+				"rate_to_usd": 1.0,
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid currency code"}`,
+			mockBehavior:   func() {},
+		},
+		{
+			name: "Invalid code length - Above Maximum Allowed",
+			payload: map[string]interface{}{
+				"code":        "USDDDD",
+				"rate_to_usd": 1.0,
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   fmt.Sprintf(`{"error":"invalid currency code, must be up to %d characters"}`, commons.AllowedCurrencyLength),
+			mockBehavior:   func() {},
+		},
+		{
+			name: "Invalid code length - Below Minimum Allowed",
+			payload: map[string]interface{}{
+				"code":        "US",
+				"rate_to_usd": 1.0,
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   fmt.Sprintf(`{"error":"invalid currency code, must be at least %d characters"}`, commons.MinimumCurrencyLength),
+			mockBehavior:   func() {},
+		},
+		{
+			name: "Valid pegged currency",
+			payload: map[string]interface{}{
+				"code": "HURB",
+				"peg":  map[string]interface{}{"anchor": "eur", "ratio": "0.5"},
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"message":"currency added successfully"}`,
+			mockBehavior: func() {
+				mockService.On("AddCurrency", mock.Anything, mock.MatchedBy(func(c *model.Currency) bool {
+					return c.Code == "HURB" && c.Rate.IsZero() && c.Peg != nil && c.Peg.Anchor == "EUR" &&
+						c.Peg.Ratio.Equal(decimal.RequireFromString("0.5"))
+				})).Return(nil).Once()
+			},
+		},
+		{
+			name: "Rate and peg together",
+			payload: map[string]interface{}{
+				"code":        "HURB",
+				"rate_to_usd": 1.0,
+				"peg":         map[string]interface{}{"anchor": "EUR", "ratio": 0.5},
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"rate_to_usd and peg cannot be used together"}`,
+			mockBehavior:   func() {},
+		},
+		{
+			name: "Non-positive peg ratio",
 			payload: map[string]interface{}{
-				"code":        "USDDDD",
-				"rate_to_usd": 1.0,
+				"code": "HURB",
+				"peg":  map[string]interface{}{"anchor": "EUR", "ratio": 0},
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   fmt.Sprintf(`{"error":"invalid currency code, must be up to %d characters"}`, commons.AllowedCurrencyLength),
+			expectedBody:   `{"error":"peg ratio must be positive"}`,
 			mockBehavior:   func() {},
 		},
 		{
-			name: "Invalid code length - Below Minimum Allowed",
+			name: "Invalid peg anchor",
 			payload: map[string]interface{}{
-				"code":        "US",
-				"rate_to_usd": 1.0,
+				"code": "HURB",
+				"peg":  map[string]interface{}{"anchor": "XYZ", "ratio": 0.5},
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   fmt.Sprintf(`{"error":"invalid currency code, must be at least %d characters"}`, commons.MinimumCurrencyLength),
-			mockBehavior:   func() {},
+			expectedBody:   `{"error":"invalid peg: anchor currency XYZ not found"}`,
+			mockBehavior: func() {
+				mockService.On("AddCurrency", mock.Anything, mock.MatchedBy(func(c *model.Currency) bool {
+					return c.Code == "HURB"
+				})).Return(fmt.Errorf("%w: anchor currency XYZ not found", model.ErrInvalidPeg)).Once()
+			},
 		},
 	}
 
@@ -310,6 +1276,24 @@ func TestRemoveCurrency(t *testing.T) {
 		assert.JSONEq(t, fmt.Sprintf(`{"error":"invalid currency code, must be up to %d characters"}`, commons.AllowedCurrencyLength), rr.Body.String())
 
 	})
+	t.Run("Anchor of pegged currencies", func(t *testing.T) {
+		req, err := http.NewRequest("DELETE", "/currency/EUR", nil)
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		mockService.On("RemoveCurrency", mock.Anything, "EUR").Return(fmt.Errorf("%w: EUR is the anchor of HURB", model.ErrCurrencyInUse)).Once()
+
+		router := chi.NewRouter()
+		router.Delete("/currency/{code}", h.RemoveCurrency)
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.JSONEq(t, `{"error":"currency in use: EUR is the anchor of HURB"}`, rr.Body.String())
+	})
+}
+
+func rateOf(rate decimal.Decimal) *decimal.Decimal {
+	return &rate
 }
 
 func TestUpdateCurrency(t *testing.T) {
@@ -333,7 +1317,7 @@ func TestUpdateCurrency(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectedBody:   `{"message":"currency updated successfully"}`,
 			mockBehavior: func() {
-				mockService.On("UpdateCurrency", mock.Anything, "USD", 1.5, mock.AnythingOfType("uuid.UUID")).Return(nil).Once()
+				mockService.On("UpdateCurrency", mock.Anything, "USD", model.CurrencyUpdate{Rate: rateOf(decimal.RequireFromString("1.5"))}, mock.AnythingOfType("uuid.UUID")).Return(nil).Once()
 			},
 		},
 		{
@@ -345,7 +1329,52 @@ func TestUpdateCurrency(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectedBody:   `{"message":"currency updated successfully"}`,
 			mockBehavior: func() {
-				mockService.On("UpdateCurrency", mock.Anything, "EUR", 0.95, mock.AnythingOfType("uuid.UUID")).Return(nil).Once()
+				mockService.On("UpdateCurrency", mock.Anything, "EUR", model.CurrencyUpdate{Rate: rateOf(decimal.RequireFromString("0.95"))}, mock.AnythingOfType("uuid.UUID")).Return(nil).Once()
+			},
+		},
+		{
+			name: "Valid update with metadata",
+			code: "BTC",
+			payload: map[string]interface{}{
+				"rate_to_usd": 0.000016,
+				"name":        "Bitcoin",
+				"minor_units": 8,
+				"kind":        "crypto",
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"message":"currency updated successfully"}`,
+			mockBehavior: func() {
+				mockService.On("UpdateCurrency", mock.Anything, "BTC", mock.MatchedBy(func(u model.CurrencyUpdate) bool {
+					return u.Rate.Equal(decimal.RequireFromString("0.000016")) && *u.Name == "Bitcoin" && *u.MinorUnits == 8 && *u.Kind == model.CurrencyKindCrypto &&
+						u.Symbol == nil && u.Countries == nil
+				}), mock.AnythingOfType("uuid.UUID")).Return(nil).Once()
+			},
+		},
+		{
+			name: "Valid update with peg",
+			code: "HURB",
+			payload: map[string]interface{}{
+				"peg": map[string]interface{}{"anchor": "USD", "ratio": "2"},
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"message":"currency updated successfully"}`,
+			mockBehavior: func() {
+				mockService.On("UpdateCurrency", mock.Anything, "HURB", mock.MatchedBy(func(u model.CurrencyUpdate) bool {
+					return u.Peg != nil && u.Peg.Anchor == "USD" && u.Peg.Ratio.Equal(decimal.NewFromInt(2))
+				}), mock.AnythingOfType("uuid.UUID")).Return(nil).Once()
+			},
+		},
+		{
+			name: "Pegged to a pegged currency",
+			code: "GOLD",
+			payload: map[string]interface{}{
+				"peg": map[string]interface{}{"anchor": "HURB", "ratio": "2"},
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid peg: anchor currency HURB is itself pegged to USD"}`,
+			mockBehavior: func() {
+				mockService.On("UpdateCurrency", mock.Anything, "GOLD", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+					Return(fmt.Errorf("%w: anchor currency HURB is itself pegged to USD", model.ErrInvalidPeg)).Once()
 			},
 		},
 		{
@@ -365,15 +1394,40 @@ func TestUpdateCurrency(t *testing.T) {
 				"rate_to_usd": "invalid",
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"error":"invalid rate: strconv.ParseFloat: parsing \"invalid\": invalid syntax"}`,
+			expectedBody:   `{"error":"invalid rate: can't convert invalid to decimal"}`,
 			mockBehavior:   func() {},
 		},
 		{
-			name:           "Missing rate",
+			name: "Metadata only",
+			code: "CAD",
+			payload: map[string]interface{}{
+				"name": "Canadian Dollar",
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"message":"currency updated successfully"}`,
+			mockBehavior: func() {
+				mockService.On("UpdateCurrency", mock.Anything, "CAD", mock.MatchedBy(func(u model.CurrencyUpdate) bool {
+					return u.Rate == nil && u.Peg == nil && *u.Name == "Canadian Dollar"
+				}), mock.AnythingOfType("uuid.UUID")).Return(nil).Once()
+			},
+		},
+		{
+			name:           "Nothing to update",
 			code:           "CAD",
 			payload:        map[string]interface{}{},
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"error":"invalid rate: unsupported rate type"}`,
+			expectedBody:   `{"error":"rate_to_usd, peg or a metadata field is required"}`,
+			mockBehavior:   func() {},
+		},
+		{
+			name: "Scheduled update without rate",
+			code: "CAD",
+			payload: map[string]interface{}{
+				"name":         "Canadian Dollar",
+				"effective_at": "2099-01-01",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"effective_at can only be used with rate_to_usd"}`,
 			mockBehavior:   func() {},
 		},
 		{
@@ -385,8 +1439,88 @@ func TestUpdateCurrency(t *testing.T) {
 			expectedStatus: http.StatusNotFound,
 			expectedBody:   `{"error":"currency not found"}`,
 			mockBehavior: func() {
-				mockService.On("UpdateCurrency", mock.Anything, "XYZ", 1.0, mock.AnythingOfType("uuid.UUID")).Return(model.ErrCurrencyNotFound).Once()
+				mockService.On("UpdateCurrency", mock.Anything, "XYZ", model.CurrencyUpdate{Rate: rateOf(decimal.RequireFromString("1"))}, mock.AnythingOfType("uuid.UUID")).Return(model.ErrCurrencyNotFound).Once()
+			},
+		},
+		{
+			name: "Scheduled update",
+			code: "HURB",
+			payload: map[string]interface{}{
+				"rate_to_usd":  "4.5",
+				"effective_at": "2099-01-01T03:00:00+03:00",
+			},
+			expectedStatus: http.StatusAccepted,
+			expectedBody: `{"id":"7f0c2d44-9a5e-4b1a-8c1e-2f6f0e7a1b3c","code":"HURB","rate":"4.5","effective_at":"2099-01-01T00:00:00Z",
+				"created_by":"00000000-0000-0000-0000-000000000000","created_at":"2099-01-01T00:00:00Z"}`,
+			mockBehavior: func() {
+				mockService.On("ScheduleRateChange", mock.Anything, mock.MatchedBy(func(c *model.ScheduledRateChange) bool {
+					return c.Code == "HURB" && c.Rate.Equal(decimal.RequireFromString("4.5")) && c.CreatedBy != uuid.Nil &&
+						c.EffectiveAt.Equal(time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC))
+				})).Run(func(args mock.Arguments) {
+					change := args.Get(1).(*model.ScheduledRateChange)
+					change.ID = uuid.MustParse("7f0c2d44-9a5e-4b1a-8c1e-2f6f0e7a1b3c")
+					change.CreatedBy = uuid.Nil
+					change.CreatedAt = change.EffectiveAt
+				}).Return(nil).Once()
+			},
+		},
+		{
+			name: "Scheduled update for unknown currency",
+			code: "XYZ",
+			payload: map[string]interface{}{
+				"rate_to_usd":  1.0,
+				"effective_at": "2099-01-01",
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"currency not found"}`,
+			mockBehavior: func() {
+				mockService.On("ScheduleRateChange", mock.Anything, mock.Anything).Return(fmt.Errorf("%w: XYZ", model.ErrCurrencyNotFound)).Once()
+			},
+		},
+		{
+			name: "Scheduled update in the past",
+			code: "HURB",
+			payload: map[string]interface{}{
+				"rate_to_usd":  1.0,
+				"effective_at": "2020-01-01",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"effective_at must be in the future"}`,
+			mockBehavior:   func() {},
+		},
+		{
+			name: "Invalid effective_at",
+			code: "HURB",
+			payload: map[string]interface{}{
+				"rate_to_usd":  1.0,
+				"effective_at": "tomorrow",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid effective_at, must be an RFC 3339 timestamp or YYYY-MM-DD date"}`,
+			mockBehavior:   func() {},
+		},
+		{
+			name: "Scheduled update with metadata",
+			code: "HURB",
+			payload: map[string]interface{}{
+				"rate_to_usd":  1.0,
+				"name":         "Hurb Coin",
+				"effective_at": "2099-01-01",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"effective_at can only be used with rate_to_usd"}`,
+			mockBehavior:   func() {},
+		},
+		{
+			name: "Scheduled update with peg",
+			code: "HURB",
+			payload: map[string]interface{}{
+				"peg":          map[string]interface{}{"anchor": "USD", "ratio": "2"},
+				"effective_at": "2099-01-01",
 			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"effective_at can only be used with rate_to_usd"}`,
+			mockBehavior:   func() {},
 		},
 	}
 
@@ -417,3 +1551,271 @@ func TestUpdateCurrency(t *testing.T) {
 		})
 	}
 }
+
+func TestSetCurrencyLock(t *testing.T) {
+	mockService := new(MockCurrencyService)
+	h := handler.NewCurrencyHandler(mockService)
+
+	router := chi.NewRouter()
+	router.Put("/currency/{code}/lock", h.LockCurrency)
+	router.Delete("/currency/{code}/lock", h.UnlockCurrency)
+
+	t.Run("Lock", func(t *testing.T) {
+		mockService.On("SetCurrencyLock", mock.Anything, "ARS", true).Return(nil).Once()
+
+		req, err := http.NewRequest("PUT", "/currency/ars/lock", nil)
+		assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"message":"currency locked successfully"}`, rr.Body.String())
+	})
+
+	t.Run("Unlock", func(t *testing.T) {
+		mockService.On("SetCurrencyLock", mock.Anything, "ARS", false).Return(nil).Once()
+
+		req, err := http.NewRequest("DELETE", "/currency/ARS/lock", nil)
+		assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"message":"currency unlocked successfully"}`, rr.Body.String())
+	})
+
+	t.Run("Currency not found", func(t *testing.T) {
+		mockService.On("SetCurrencyLock", mock.Anything, "XYZ", true).Return(fmt.Errorf("%w: XYZ", model.ErrCurrencyNotFound)).Once()
+
+		req, err := http.NewRequest("PUT", "/currency/XYZ/lock", nil)
+		assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Invalid code", func(t *testing.T) {
+		req, err := http.NewRequest("PUT", "/currency/R/lock", nil)
+		assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	mockService.AssertExpectations(t)
+}
+
+func TestScheduledRateChanges(t *testing.T) {
+	mockService := new(MockCurrencyService)
+	h := handler.NewCurrencyHandler(mockService)
+
+	router := chi.NewRouter()
+	router.Get("/currency/scheduled", h.ListScheduledRateChanges)
+	router.Delete("/currency/scheduled/{id}", h.CancelScheduledRateChange)
+
+	id := uuid.MustParse("7f0c2d44-9a5e-4b1a-8c1e-2f6f0e7a1b3c")
+
+	t.Run("List", func(t *testing.T) {
+		effectiveAt := time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC)
+		mockService.On("ListScheduledRateChanges", mock.Anything, "HURB").Return([]model.ScheduledRateChange{
+			{ID: id, Code: "HURB", Rate: decimal.RequireFromString("4.5"), EffectiveAt: effectiveAt, CreatedAt: effectiveAt},
+		}, nil).Once()
+
+		req, _ := http.NewRequest("GET", "/currency/scheduled?code=hurb", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"scheduled_changes":[{"id":"7f0c2d44-9a5e-4b1a-8c1e-2f6f0e7a1b3c","code":"HURB","rate":"4.5",
+			"effective_at":"2099-01-01T00:00:00Z","created_by":"00000000-0000-0000-0000-000000000000","created_at":"2099-01-01T00:00:00Z"}]}`, rr.Body.String())
+	})
+
+	t.Run("List with invalid code", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/currency/scheduled?code=TOOLONG", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		mockService.On("CancelScheduledRateChange", mock.Anything, id).Return(nil).Once()
+
+		req, _ := http.NewRequest("DELETE", "/currency/scheduled/"+id.String(), nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"message":"scheduled rate change canceled successfully"}`, rr.Body.String())
+	})
+
+	t.Run("Cancel unknown change", func(t *testing.T) {
+		mockService.On("CancelScheduledRateChange", mock.Anything, id).Return(fmt.Errorf("%w: %s", model.ErrScheduledChangeNotFound, id)).Once()
+
+		req, _ := http.NewRequest("DELETE", "/currency/scheduled/"+id.String(), nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Cancel with invalid id", func(t *testing.T) {
+		req, _ := http.NewRequest("DELETE", "/currency/scheduled/abc", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.JSONEq(t, `{"error":"invalid scheduled change id"}`, rr.Body.String())
+	})
+
+	mockService.AssertExpectations(t)
+}
+
+func TestJumpThreshold(t *testing.T) {
+	mockService := new(MockCurrencyService)
+	h := handler.NewCurrencyHandler(mockService)
+
+	router := chi.NewRouter()
+	router.Put("/currency/{code}/jump-threshold", h.SetJumpThreshold)
+	router.Delete("/currency/{code}/jump-threshold", h.ResetJumpThreshold)
+
+	t.Run("Set", func(t *testing.T) {
+		mockService.On("SetJumpThreshold", mock.Anything, "ARS", mock.MatchedBy(func(threshold *decimal.Decimal) bool {
+			return threshold != nil && threshold.Equal(decimal.RequireFromString("0.5"))
+		})).Return(nil).Once()
+
+		req, _ := http.NewRequest("PUT", "/currency/ars/jump-threshold", strings.NewReader(`{"threshold":"0.5"}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"message":"jump threshold updated successfully"}`, rr.Body.String())
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		mockService.On("SetJumpThreshold", mock.Anything, "ARS", (*decimal.Decimal)(nil)).Return(nil).Once()
+
+		req, _ := http.NewRequest("DELETE", "/currency/ARS/jump-threshold", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"message":"jump threshold reset successfully"}`, rr.Body.String())
+	})
+
+	t.Run("Non-positive threshold", func(t *testing.T) {
+		req, _ := http.NewRequest("PUT", "/currency/ARS/jump-threshold", strings.NewReader(`{"threshold":0}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.JSONEq(t, `{"error":"threshold must be positive"}`, rr.Body.String())
+	})
+
+	t.Run("Currency not found", func(t *testing.T) {
+		mockService.On("SetJumpThreshold", mock.Anything, "XYZ", mock.Anything).Return(fmt.Errorf("%w: XYZ", model.ErrCurrencyNotFound)).Once()
+
+		req, _ := http.NewRequest("PUT", "/currency/XYZ/jump-threshold", strings.NewReader(`{"threshold":0.1}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	mockService.AssertExpectations(t)
+}
+
+func TestQuarantinedRates(t *testing.T) {
+	mockService := new(MockCurrencyService)
+	h := handler.NewCurrencyHandler(mockService)
+
+	router := chi.NewRouter()
+	router.Get("/currency/quarantine", h.ListQuarantinedRates)
+	router.Post("/currency/quarantine/{id}/approve", h.ApproveQuarantinedRate)
+	router.Delete("/currency/quarantine/{id}", h.RejectQuarantinedRate)
+
+	id := uuid.MustParse("3b1f6c8e-2d4a-4e5f-9a7b-1c2d3e4f5a6b")
+	recordedAt := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+	quarantined := model.QuarantinedRate{
+		ID:           id,
+		Code:         "ARS",
+		PreviousRate: decimal.RequireFromString("900"),
+		Rate:         decimal.RequireFromString("1350"),
+		Change:       decimal.RequireFromString("0.5"),
+		Providers:    []string{"ecb"},
+		RecordedAt:   recordedAt,
+		CreatedAt:    recordedAt,
+	}
+	user := model.User{ID: uuid.New(), Role: model.RoleAdmin}
+
+	t.Run("List", func(t *testing.T) {
+		mockService.On("ListQuarantinedRates", mock.Anything, "ARS").Return([]model.QuarantinedRate{quarantined}, nil).Once()
+
+		req, _ := http.NewRequest("GET", "/currency/quarantine?code=ars", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"quarantined_rates":[{"id":"3b1f6c8e-2d4a-4e5f-9a7b-1c2d3e4f5a6b","code":"ARS","previous_rate":"900",
+			"rate":"1350","change":"0.5","providers":["ecb"],"recorded_at":"2024-08-10T12:00:00Z","created_at":"2024-08-10T12:00:00Z"}]}`, rr.Body.String())
+	})
+
+	t.Run("Approve", func(t *testing.T) {
+		mockService.On("ApproveQuarantinedRate", mock.Anything, id, user.ID).Return(&quarantined, nil).Once()
+
+		req, _ := http.NewRequest("POST", "/currency/quarantine/"+id.String()+"/approve", nil)
+		req = req.WithContext(context.WithValue(req.Context(), commons.UserContextKey, user))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"rate":"1350"`)
+	})
+
+	t.Run("Approve unknown entry", func(t *testing.T) {
+		mockService.On("ApproveQuarantinedRate", mock.Anything, id, user.ID).Return(nil, fmt.Errorf("%w: %s", model.ErrQuarantinedRateNotFound, id)).Once()
+
+		req, _ := http.NewRequest("POST", "/currency/quarantine/"+id.String()+"/approve", nil)
+		req = req.WithContext(context.WithValue(req.Context(), commons.UserContextKey, user))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Approve locked currency", func(t *testing.T) {
+		mockService.On("ApproveQuarantinedRate", mock.Anything, id, user.ID).Return(nil, fmt.Errorf("%w: ARS", model.ErrCurrencyLocked)).Once()
+
+		req, _ := http.NewRequest("POST", "/currency/quarantine/"+id.String()+"/approve", nil)
+		req = req.WithContext(context.WithValue(req.Context(), commons.UserContextKey, user))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.JSONEq(t, `{"error":"currency locked: ARS"}`, rr.Body.String())
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		mockService.On("RejectQuarantinedRate", mock.Anything, id).Return(nil).Once()
+
+		req, _ := http.NewRequest("DELETE", "/currency/quarantine/"+id.String(), nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"message":"quarantined rate rejected successfully"}`, rr.Body.String())
+	})
+
+	t.Run("Reject with invalid id", func(t *testing.T) {
+		req, _ := http.NewRequest("DELETE", "/currency/quarantine/abc", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.JSONEq(t, `{"error":"invalid quarantined rate id"}`, rr.Body.String())
+	})
+
+	mockService.AssertExpectations(t)
+}
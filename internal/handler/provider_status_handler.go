@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Lutefd/challenge-bravo/internal/commons"
+	"github.com/Lutefd/challenge-bravo/internal/service"
+)
+
+type ProviderStatusHandler struct {
+	statusService service.ProviderStatusServiceInterface
+}
+
+func NewProviderStatusHandler(statusService service.ProviderStatusServiceInterface) *ProviderStatusHandler {
+	return &ProviderStatusHandler{statusService: statusService}
+}
+
+func (h *ProviderStatusHandler) ListProviderStatuses(w http.ResponseWriter, r *http.Request) {
+	statuses, err := h.statusService.ListProviderStatuses(r.Context())
+	if err != nil {
+		commons.RespondWithError(w, http.StatusInternalServerError, "failed to list provider statuses")
+		return
+	}
+
+	commons.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"providers": statuses})
+}
@@ -0,0 +1,68 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Lutefd/challenge-bravo/internal/handler"
+	"github.com/Lutefd/challenge-bravo/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockProviderStatusService struct {
+	mock.Mock
+}
+
+func (m *MockProviderStatusService) ListProviderStatuses(ctx context.Context) ([]model.ProviderStatus, error) {
+	args := m.Called(ctx)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.ProviderStatus), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestListProviderStatuses(t *testing.T) {
+	openedAt := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockProviderStatusService)
+		h := handler.NewProviderStatusHandler(mockService)
+		mockService.On("ListProviderStatuses", mock.Anything).Return([]model.ProviderStatus{
+			{Provider: "ecb", State: model.CircuitStateOpen, ConsecutiveFailures: 3, OpenedAt: &openedAt, UpdatedAt: openedAt},
+			{Provider: "frankfurter", State: model.CircuitStateClosed, UpdatedAt: openedAt},
+		}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/providers/status", nil)
+		rr := httptest.NewRecorder()
+		h.ListProviderStatuses(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var response struct {
+			Providers []model.ProviderStatus `json:"providers"`
+		}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Len(t, response.Providers, 2)
+		assert.Equal(t, model.CircuitStateOpen, response.Providers[0].State)
+		assert.Equal(t, openedAt, *response.Providers[0].OpenedAt)
+		assert.Nil(t, response.Providers[1].OpenedAt)
+	})
+
+	t.Run("Service error", func(t *testing.T) {
+		mockService := new(MockProviderStatusService)
+		h := handler.NewProviderStatusHandler(mockService)
+		mockService.On("ListProviderStatuses", mock.Anything).Return(nil, errors.New("database error"))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/providers/status", nil)
+		rr := httptest.NewRecorder()
+		h.ListProviderStatuses(rr, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.JSONEq(t, `{"error":"failed to list provider statuses"}`, rr.Body.String())
+	})
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Lutefd/challenge-bravo/internal/commons"
+	"github.com/Lutefd/challenge-bravo/internal/model"
+)
+
+type WorkerStatusSource interface {
+	Status() model.WorkerStatus
+}
+
+type WorkerStatusHandler struct {
+	source WorkerStatusSource
+}
+
+func NewWorkerStatusHandler(source WorkerStatusSource) *WorkerStatusHandler {
+	return &WorkerStatusHandler{source: source}
+}
+
+func (h *WorkerStatusHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	status := h.source.Status()
+	if !status.Ready {
+		commons.RespondWithJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"status":  "not ready",
+			"reasons": status.Reasons,
+		})
+		return
+	}
+
+	commons.RespondWithJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+}
+
+func (h *WorkerStatusHandler) Status(w http.ResponseWriter, r *http.Request) {
+	commons.RespondWithJSON(w, http.StatusOK, h.source.Status())
+}
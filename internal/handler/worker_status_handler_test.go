@@ -0,0 +1,70 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Lutefd/challenge-bravo/internal/handler"
+	"github.com/Lutefd/challenge-bravo/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubWorkerStatusSource struct {
+	status model.WorkerStatus
+}
+
+func (s stubWorkerStatusSource) Status() model.WorkerStatus {
+	return s.status
+}
+
+func TestWorkerStatusHandler_Readiness(t *testing.T) {
+	t.Run("Ready", func(t *testing.T) {
+		h := handler.NewWorkerStatusHandler(stubWorkerStatusSource{status: model.WorkerStatus{Ready: true}})
+
+		rr := httptest.NewRecorder()
+		h.Readiness(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"status":"ready"}`, rr.Body.String())
+	})
+
+	t.Run("Not ready", func(t *testing.T) {
+		h := handler.NewWorkerStatusHandler(stubWorkerStatusSource{status: model.WorkerStatus{
+			Reasons: []string{"rate updater has not completed its first run"},
+		}})
+
+		rr := httptest.NewRecorder()
+		h.Readiness(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.JSONEq(t, `{"status":"not ready","reasons":["rate updater has not completed its first run"]}`, rr.Body.String())
+	})
+}
+
+func TestWorkerStatusHandler_Status(t *testing.T) {
+	fetchedAt := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+	h := handler.NewWorkerStatusHandler(stubWorkerStatusSource{status: model.WorkerStatus{
+		Ready:     true,
+		StartedAt: fetchedAt.Add(-time.Hour),
+		RateUpdater: model.RateUpdaterStatus{
+			Started:           true,
+			LastFetchAt:       &fetchedAt,
+			CurrenciesUpdated: 170,
+		},
+		PartitionManager: model.PartitionManagerStatus{State: model.PartitionManagerStateRunning},
+	}})
+
+	rr := httptest.NewRecorder()
+	h.Status(rr, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var status model.WorkerStatus
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &status))
+	assert.True(t, status.Ready)
+	assert.Equal(t, fetchedAt, *status.RateUpdater.LastFetchAt)
+	assert.Equal(t, 170, status.RateUpdater.CurrenciesUpdated)
+	assert.Equal(t, model.PartitionManagerStateRunning, status.PartitionManager.State)
+}
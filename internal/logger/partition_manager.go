@@ -3,8 +3,10 @@ package logger
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/Lutefd/challenge-bravo/internal/model"
 	"github.com/Lutefd/challenge-bravo/internal/repository"
 	"github.com/robfig/cron/v3"
 )
@@ -12,13 +14,18 @@ import (
 type PartitionManager struct {
 	repo repository.LogRepository
 	cron *cron.Cron
+
+	mu     sync.Mutex
+	term   uint64
+	status model.PartitionManagerStatus
 }
 
 func NewPartitionManager(repo repository.LogRepository) *PartitionManager {
 	c := cron.New()
 	pm := &PartitionManager{
-		repo: repo,
-		cron: c,
+		repo:   repo,
+		cron:   c,
+		status: model.PartitionManagerStatus{State: model.PartitionManagerStateStarting},
 	}
 
 	_, err := c.AddFunc("0 0 1 * *", pm.createNextMonthPartitionWrapper)
@@ -30,21 +37,63 @@ func NewPartitionManager(repo repository.LogRepository) *PartitionManager {
 }
 
 func (pm *PartitionManager) Start(ctx context.Context) error {
-	if err := pm.createInitialPartitions(ctx); err != nil {
+	pm.mu.Lock()
+	pm.term++
+	term := pm.term
+	pm.mu.Unlock()
+
+	err := pm.createInitialPartitions(ctx)
+	pm.recordRun(err)
+	if err != nil {
+		pm.setState(model.PartitionManagerStateFailed)
 		Errorf("failed to create initial partitions: %s", err)
 		return fmt.Errorf("failed to create initial partitions: %w", err)
 	}
 
 	pm.cron.Start()
+	pm.setState(model.PartitionManagerStateRunning)
 
 	go func() {
 		<-ctx.Done()
-		pm.cron.Stop()
+		pm.stop(term)
 	}()
 
 	return nil
 }
 
+func (pm *PartitionManager) stop(term uint64) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	if term != pm.term {
+		return
+	}
+	pm.cron.Stop()
+	pm.status.State = model.PartitionManagerStateStopped
+}
+
+func (pm *PartitionManager) Status() model.PartitionManagerStatus {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	return pm.status
+}
+
+func (pm *PartitionManager) setState(state model.PartitionManagerState) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.status.State = state
+}
+
+func (pm *PartitionManager) recordRun(err error) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	ranAt := time.Now().UTC()
+	pm.status.LastRunAt = &ranAt
+	pm.status.LastError = ""
+	if err != nil {
+		pm.status.LastError = err.Error()
+	}
+}
+
 func (pm *PartitionManager) createInitialPartitions(ctx context.Context) error {
 	now := time.Now()
 	for i := 0; i < 3; i++ {
@@ -63,7 +112,9 @@ func (pm *PartitionManager) createNextMonthPartition(ctx context.Context) error
 
 func (pm *PartitionManager) createNextMonthPartitionWrapper() {
 	ctx := context.Background()
-	if err := pm.createNextMonthPartition(ctx); err != nil {
+	err := pm.createNextMonthPartition(ctx)
+	pm.recordRun(err)
+	if err != nil {
 		Errorf("failed to create next month partition: %v\n", err)
 	}
 }
@@ -64,14 +64,45 @@ func TestPartitionManager_Start(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	assert.Equal(t, model.PartitionManagerStateStarting, pm.Status().State)
+
 	err := pm.Start(ctx)
 	assert.NoError(t, err)
 
 	time.Sleep(LoggerSleepDuration)
 
 	mockRepo.AssertExpectations(t)
+	status := pm.Status()
+	assert.Equal(t, model.PartitionManagerStateRunning, status.State)
+	assert.NotNil(t, status.LastRunAt)
+	assert.Empty(t, status.LastError)
 
 	cancel()
+	time.Sleep(LoggerSleepDuration)
+	assert.Equal(t, model.PartitionManagerStateStopped, pm.Status().State)
+}
+
+func TestPartitionManager_Start_NewTerm(t *testing.T) {
+	mockRepo := new(MockLogRepository)
+	pm := NewPartitionManager(mockRepo)
+
+	mockRepo.On("CreatePartition", mock.Anything, mock.AnythingOfType("time.Time")).Return(nil)
+
+	firstCtx, firstCancel := context.WithCancel(context.Background())
+	defer firstCancel()
+	secondCtx, secondCancel := context.WithCancel(context.Background())
+	defer secondCancel()
+
+	assert.NoError(t, pm.Start(firstCtx))
+	assert.NoError(t, pm.Start(secondCtx))
+
+	firstCancel()
+	time.Sleep(LoggerSleepDuration)
+	assert.Equal(t, model.PartitionManagerStateRunning, pm.Status().State)
+
+	secondCancel()
+	time.Sleep(LoggerSleepDuration)
+	assert.Equal(t, model.PartitionManagerStateStopped, pm.Status().State)
 }
 
 func TestPartitionManager_createInitialPartitions(t *testing.T) {
@@ -124,6 +155,9 @@ func TestPartitionManager_Start_FailedInitialPartition(t *testing.T) {
 	assert.Contains(t, err.Error(), "failed to create initial partitions")
 
 	mockRepo.AssertExpectations(t)
+	status := pm.Status()
+	assert.Equal(t, model.PartitionManagerStateFailed, status.State)
+	assert.Equal(t, assert.AnError.Error(), status.LastError)
 }
 
 func TestPartitionManager_cronJob(t *testing.T) {
@@ -2,24 +2,257 @@ package model
 
 import (
 	"errors"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 type Currency struct {
-	Code      string    `json:"code"`
-	Rate      float64   `json:"rate"`
-	UpdatedAt time.Time `json:"updated_at"`
-	CreatedBy uuid.UUID `json:"created_by"`
-	UpdatedBy uuid.UUID `json:"updated_by"`
-	CreatedAt time.Time `json:"created_at"`
+	Code          string           `json:"code"`
+	Rate          decimal.Decimal  `json:"rate"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+	CreatedBy     uuid.UUID        `json:"created_by"`
+	UpdatedBy     uuid.UUID        `json:"updated_by"`
+	CreatedAt     time.Time        `json:"created_at"`
+	Source        CurrencySource   `json:"source"`
+	Locked        bool             `json:"locked"`
+	Providers     []string         `json:"providers,omitempty"`
+	JumpThreshold *decimal.Decimal `json:"jump_threshold,omitempty"`
+	Peg           *CurrencyPeg     `json:"peg,omitempty"`
+	CurrencyMetadata
+}
+
+type CurrencyPeg struct {
+	Code   string          `json:"-"`
+	Anchor string          `json:"anchor"`
+	Ratio  decimal.Decimal `json:"ratio"`
+}
+
+func (p CurrencyPeg) RateFrom(anchorRate decimal.Decimal, precision int32) decimal.Decimal {
+	return anchorRate.DivRound(p.Ratio, precision)
+}
+
+type CurrencyKind string
+
+const (
+	CurrencyKindFiat      CurrencyKind = "fiat"
+	CurrencyKindCrypto    CurrencyKind = "crypto"
+	CurrencyKindFictional CurrencyKind = "fictional"
+	CurrencyKindCommodity CurrencyKind = "commodity"
+)
+
+func (k CurrencyKind) IsValid() bool {
+	switch k {
+	case CurrencyKindFiat, CurrencyKindCrypto, CurrencyKindFictional, CurrencyKindCommodity:
+		return true
+	}
+	return false
+}
+
+type CurrencyMetadata struct {
+	Name       string       `json:"name"`
+	Symbol     string       `json:"symbol"`
+	MinorUnits *int         `json:"minor_units,omitempty"`
+	Countries  []string     `json:"countries"`
+	Kind       CurrencyKind `json:"kind,omitempty"`
+}
+
+type CurrencyInfo struct {
+	Code string `json:"code"`
+	CurrencyMetadata
+}
+
+type CurrencyUpdate struct {
+	Rate       *decimal.Decimal
+	Peg        *CurrencyPeg
+	Name       *string
+	Symbol     *string
+	MinorUnits *int
+	Countries  []string
+	Kind       *CurrencyKind
+}
+
+type ConversionRequest struct {
+	From   string
+	To     string
+	Amount decimal.Decimal
+}
+
+type Conversion struct {
+	From      CurrencyInfo    `json:"from"`
+	To        CurrencyInfo    `json:"to"`
+	Amount    decimal.Decimal `json:"amount"`
+	Result    decimal.Decimal `json:"result"`
+	Rounding  RoundingMode    `json:"round"`
+	Precision int32           `json:"precision"`
+	FromRate  RateLeg         `json:"from_rate"`
+	ToRate    RateLeg         `json:"to_rate"`
+	Stale     bool            `json:"stale"`
+	Version   uint64          `json:"rate_version,omitempty"`
+}
+
+type RateLeg struct {
+	Code      string          `json:"code"`
+	Rate      decimal.Decimal `json:"rate"`
+	UpdatedAt *time.Time      `json:"updated_at,omitempty"`
+	Source    CurrencySource  `json:"source,omitempty"`
+	Stale     bool            `json:"stale"`
+}
+
+func (c *Conversion) StaleCodes() []string {
+	var codes []string
+	for _, leg := range []RateLeg{c.FromRate, c.ToRate} {
+		if leg.Stale && !slices.Contains(codes, leg.Code) {
+			codes = append(codes, leg.Code)
+		}
+	}
+	return codes
+}
+
+func (c *Conversion) Round(mode RoundingMode) {
+	if c.To.MinorUnits == nil {
+		mode = RoundingNone
+	}
+	c.Rounding = mode
+	if mode == RoundingNone {
+		_, fraction, _ := strings.Cut(c.Result.String(), ".")
+		c.Precision = int32(len(fraction))
+		return
+	}
+	c.Precision = int32(*c.To.MinorUnits)
+	c.Result = mode.Round(c.Result, c.Precision)
+}
+
+func (c *Conversion) FormattedResult() string {
+	return c.Result.StringFixed(c.Precision)
+}
+
+type RoundingMode string
+
+const (
+	RoundingHalfUp   RoundingMode = "half-up"
+	RoundingHalfEven RoundingMode = "half-even"
+	RoundingDown     RoundingMode = "down"
+	RoundingUp       RoundingMode = "up"
+	RoundingNone     RoundingMode = "none"
+)
+
+const DefaultRoundingMode = RoundingHalfUp
+
+func (m RoundingMode) IsValid() bool {
+	switch m {
+	case RoundingHalfUp, RoundingHalfEven, RoundingDown, RoundingUp, RoundingNone:
+		return true
+	}
+	return false
+}
+
+func (m RoundingMode) Round(value decimal.Decimal, places int32) decimal.Decimal {
+	switch m {
+	case RoundingHalfUp:
+		return value.Round(places)
+	case RoundingHalfEven:
+		return value.RoundBank(places)
+	case RoundingDown:
+		return value.RoundDown(places)
+	case RoundingUp:
+		return value.RoundUp(places)
+	}
+	return value
+}
+
+type RateMatrix struct {
+	Codes       []string                              `json:"codes"`
+	Rates       map[string]map[string]decimal.Decimal `json:"rates"`
+	Version     uint64                                `json:"rate_version,omitempty"`
+	GeneratedAt time.Time                             `json:"generated_at"`
+}
+
+type StaleRatePolicy string
+
+const (
+	StaleRatePolicyFlag   StaleRatePolicy = "flag"
+	StaleRatePolicyReject StaleRatePolicy = "reject"
+)
+
+const DefaultStaleRatePolicy = StaleRatePolicyFlag
+
+func (p StaleRatePolicy) IsValid() bool {
+	switch p {
+	case StaleRatePolicyFlag, StaleRatePolicyReject:
+		return true
+	}
+	return false
+}
+
+type CurrencySource string
+
+const (
+	CurrencySourceProvider CurrencySource = "provider"
+	CurrencySourceManual   CurrencySource = "manual"
+)
+
+var CurrencySortFields = map[string]bool{
+	"code":       true,
+	"rate":       true,
+	"updated_at": true,
+	"created_at": true,
+}
+
+type CurrencyListOptions struct {
+	Source       CurrencySource
+	UpdatedSince time.Time
+	SortBy       string
+	Descending   bool
+	Limit        int
+	Offset       int
+}
+
+type ScheduledRateChange struct {
+	ID          uuid.UUID       `json:"id"`
+	Code        string          `json:"code"`
+	Rate        decimal.Decimal `json:"rate"`
+	EffectiveAt time.Time       `json:"effective_at"`
+	CreatedBy   uuid.UUID       `json:"created_by"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+type QuarantinedRate struct {
+	ID           uuid.UUID       `json:"id"`
+	Code         string          `json:"code"`
+	PreviousRate decimal.Decimal `json:"previous_rate"`
+	Rate         decimal.Decimal `json:"rate"`
+	Change       decimal.Decimal `json:"change"`
+	Providers    []string        `json:"providers,omitempty"`
+	RecordedAt   time.Time       `json:"recorded_at"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+type RateHistory struct {
+	Code       string          `json:"code"`
+	Rate       decimal.Decimal `json:"rate"`
+	RecordedAt time.Time       `json:"recorded_at"`
+	UpdatedBy  uuid.UUID       `json:"updated_by"`
+	Providers  []string        `json:"providers,omitempty"`
 }
 
 type ExchangeRates struct {
-	Timestamp int64              `json:"timestamp"`
-	Base      string             `json:"base"`
-	Rates     map[string]float64 `json:"rates"`
+	Timestamp int64                      `json:"timestamp"`
+	Base      string                     `json:"base"`
+	Rates     map[string]decimal.Decimal `json:"rates"`
+	Providers map[string][]string        `json:"-"`
 }
 
-var ErrCurrencyNotFound = errors.New("currency not found")
+var (
+	ErrCurrencyNotFound        = errors.New("currency not found")
+	ErrPegNotFound             = errors.New("peg not found")
+	ErrInvalidPeg              = errors.New("invalid peg")
+	ErrCurrencyInUse           = errors.New("currency in use")
+	ErrScheduledChangeNotFound = errors.New("scheduled rate change not found")
+	ErrQuarantinedRateNotFound = errors.New("quarantined rate not found")
+	ErrCurrencyLocked          = errors.New("currency locked")
+	ErrInvalidRate             = errors.New("invalid rate")
+)
@@ -0,0 +1,42 @@
+package model
+
+import "strings"
+
+var knownCurrencies = map[string]CurrencyMetadata{
+	"BTC": {MinorUnits: MinorUnits(8), Kind: CurrencyKindCrypto},
+	"ETH": {MinorUnits: MinorUnits(18), Kind: CurrencyKindCrypto},
+	"XAU": {Kind: CurrencyKindCommodity},
+	"XAG": {Kind: CurrencyKindCommodity},
+	"XPT": {Kind: CurrencyKindCommodity},
+	"XPD": {Kind: CurrencyKindCommodity},
+}
+
+var fiatMinorUnits = map[int]string{
+	0: "BIF BYR CLP DJF GNF ISK JPY KMF KRW PYG RWF UGX UYI VND VUV XAF XOF XPF",
+	2: "AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BMD BND BOB BOV BRL BSD BTN BWP BYN BZD " +
+		"CAD CDF CHE CHF CHW CNH CNY COP COU CRC CUC CUP CVE CZK DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL " +
+		"GGP GHS GIP GMD GTQ GYD HKD HNL HTG HUF IDR ILS IMP INR IRR JEP JMD KES KGS KHR KPW KYD KZT LAK LBP " +
+		"LKR LRD LSL MAD MDL MGA MKD MMK MNT MOP MRO MRU MUR MVR MWK MXN MXV MYR MZN NAD NGN NIO NOK NPR NZD " +
+		"PAB PEN PGK PHP PKR PLN QAR RON RSD RUB SAR SBD SCR SDG SEK SGD SHP SLE SLL SOS SRD SSP STD STN SVC " +
+		"SYP SZL THB TJS TMT TOP TRY TTD TWD TZS UAH USD USN UYU UZS VED VEF VES WST XCD YER ZAR ZMK ZMW ZWL",
+	3: "BHD IQD JOD KWD LYD OMR TND",
+	4: "CLF UYW",
+}
+
+func init() {
+	for units, codes := range fiatMinorUnits {
+		for _, code := range strings.Fields(codes) {
+			knownCurrencies[code] = CurrencyMetadata{MinorUnits: MinorUnits(units), Kind: CurrencyKindFiat}
+		}
+	}
+}
+
+func KnownCurrencyMetadata(code string) CurrencyMetadata {
+	metadata := knownCurrencies[strings.ToUpper(code)]
+	metadata.Countries = []string{}
+	return metadata
+}
+
+func MinorUnits(units int) *int {
+	return &units
+}
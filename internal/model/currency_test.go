@@ -0,0 +1,95 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/Lutefd/challenge-bravo/internal/model"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoundingMode_Round(t *testing.T) {
+	tests := []struct {
+		mode     model.RoundingMode
+		value    string
+		places   int32
+		expected string
+	}{
+		{model.RoundingHalfUp, "2.345", 2, "2.35"},
+		{model.RoundingHalfUp, "-2.345", 2, "-2.35"},
+		{model.RoundingHalfEven, "2.345", 2, "2.34"},
+		{model.RoundingHalfEven, "2.355", 2, "2.36"},
+		{model.RoundingDown, "2.349", 2, "2.34"},
+		{model.RoundingDown, "-2.349", 2, "-2.34"},
+		{model.RoundingUp, "2.341", 2, "2.35"},
+		{model.RoundingUp, "2.5", 0, "3"},
+		{model.RoundingNone, "2.3456789", 2, "2.3456789"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode)+" "+tt.value, func(t *testing.T) {
+			result := tt.mode.Round(decimal.RequireFromString(tt.value), tt.places)
+			assert.Equal(t, tt.expected, result.String())
+		})
+	}
+}
+
+func TestRoundingMode_IsValid(t *testing.T) {
+	assert.True(t, model.RoundingHalfEven.IsValid())
+	assert.True(t, model.RoundingNone.IsValid())
+	assert.False(t, model.RoundingMode("ceiling").IsValid())
+}
+
+func TestConversion_Round(t *testing.T) {
+	conversion := model.Conversion{
+		To:     model.CurrencyInfo{Code: "JPY", CurrencyMetadata: model.CurrencyMetadata{MinorUnits: model.MinorUnits(0)}},
+		Result: decimal.RequireFromString("1234.5"),
+	}
+	conversion.Round(model.RoundingHalfEven)
+	assert.Equal(t, "1234", conversion.FormattedResult())
+	assert.Equal(t, int32(0), conversion.Precision)
+
+	conversion = model.Conversion{
+		To:     model.CurrencyInfo{Code: "EUR", CurrencyMetadata: model.CurrencyMetadata{MinorUnits: model.MinorUnits(2)}},
+		Result: decimal.RequireFromString("85"),
+	}
+	conversion.Round(model.RoundingHalfUp)
+	assert.Equal(t, "85.00", conversion.FormattedResult())
+
+	conversion.Result = decimal.RequireFromString("0.123400")
+	conversion.Round(model.RoundingNone)
+	assert.Equal(t, "0.1234", conversion.FormattedResult())
+	assert.Equal(t, int32(4), conversion.Precision)
+
+	conversion = model.Conversion{
+		To:     model.CurrencyInfo{Code: "XDR", CurrencyMetadata: model.KnownCurrencyMetadata("XDR")},
+		Result: decimal.RequireFromString("0.000123456789"),
+	}
+	conversion.Round(model.RoundingHalfUp)
+	assert.Equal(t, "0.000123456789", conversion.FormattedResult())
+	assert.Equal(t, model.RoundingNone, conversion.Rounding)
+}
+
+func TestKnownCurrencyMetadata(t *testing.T) {
+	tests := []struct {
+		code       string
+		minorUnits *int
+		kind       model.CurrencyKind
+	}{
+		{"USD", model.MinorUnits(2), model.CurrencyKindFiat},
+		{"jpy", model.MinorUnits(0), model.CurrencyKindFiat},
+		{"KWD", model.MinorUnits(3), model.CurrencyKindFiat},
+		{"BTC", model.MinorUnits(8), model.CurrencyKindCrypto},
+		{"XAU", nil, model.CurrencyKindCommodity},
+		{"XDR", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			metadata := model.KnownCurrencyMetadata(tt.code)
+			assert.Equal(t, tt.minorUnits, metadata.MinorUnits)
+			assert.Equal(t, tt.kind, metadata.Kind)
+			assert.Equal(t, []string{}, metadata.Countries)
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+type CircuitState string
+
+const (
+	CircuitStateClosed   CircuitState = "closed"
+	CircuitStateOpen     CircuitState = "open"
+	CircuitStateHalfOpen CircuitState = "half_open"
+)
+
+type ProviderStatus struct {
+	Provider            string       `json:"provider"`
+	State               CircuitState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	OpenedAt            *time.Time   `json:"opened_at,omitempty"`
+	UpdatedAt           time.Time    `json:"updated_at"`
+}
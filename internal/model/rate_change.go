@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type RateChangeEvent struct {
+	Code      string          `json:"code"`
+	Rate      decimal.Decimal `json:"rate"`
+	Deleted   bool            `json:"deleted,omitempty"`
+	Version   uint64          `json:"version,omitempty"`
+	ChangedAt time.Time       `json:"changed_at"`
+}
+
+type RateSnapshot struct {
+	Version    uint64
+	Currencies map[string]Currency
+}
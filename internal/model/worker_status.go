@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+type PartitionManagerState string
+
+const (
+	PartitionManagerStateStarting PartitionManagerState = "starting"
+	PartitionManagerStateRunning  PartitionManagerState = "running"
+	PartitionManagerStateFailed   PartitionManagerState = "failed"
+	PartitionManagerStateStopped  PartitionManagerState = "stopped"
+)
+
+type PartitionManagerStatus struct {
+	State     PartitionManagerState `json:"state"`
+	LastRunAt *time.Time            `json:"last_run_at,omitempty"`
+	LastError string                `json:"last_error,omitempty"`
+}
+
+type RateUpdaterStatus struct {
+	Started           bool       `json:"started"`
+	LastFetchAt       *time.Time `json:"last_fetch_at,omitempty"`
+	LastError         string     `json:"last_error,omitempty"`
+	LastErrorAt       *time.Time `json:"last_error_at,omitempty"`
+	NextRunAt         *time.Time `json:"next_run_at,omitempty"`
+	CurrenciesUpdated int        `json:"currencies_updated"`
+}
+
+type WorkerStatus struct {
+	Ready            bool                   `json:"ready"`
+	Reasons          []string               `json:"reasons,omitempty"`
+	Leader           bool                   `json:"leader"`
+	StartedAt        time.Time              `json:"started_at"`
+	RateUpdater      RateUpdaterStatus      `json:"rate_updater"`
+	PartitionManager PartitionManagerStatus `json:"partition_manager"`
+}
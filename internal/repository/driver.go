@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+type Config struct {
+	Driver       string
+	PostgresConn string
+	SQLitePath   string
+	SQLiteDB     *sql.DB
+}
+
+func OpenStorage(config Config) (Config, error) {
+	if config.Driver != DriverSQLite || config.SQLiteDB != nil {
+		return config, nil
+	}
+	db, err := openSQLite(config.SQLitePath)
+	if err != nil {
+		return Config{}, err
+	}
+	config.SQLiteDB = db
+	return config, nil
+}
+
+func (c Config) Close() error {
+	if c.SQLiteDB == nil {
+		return nil
+	}
+	return c.SQLiteDB.Close()
+}
+
+func NewCurrencyRepository(config Config) (CurrencyRepository, error) {
+	if config.Driver == DriverSQLite {
+		repo, err := NewSQLiteCurrencyRepository(config.SQLitePath, config.SQLiteDB)
+		if err != nil {
+			return nil, err
+		}
+		return repo, nil
+	}
+	repo, err := NewPostgresCurrencyRepository(config.PostgresConn, nil)
+	if err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+func NewUserRepository(config Config) (UserRepository, error) {
+	if config.Driver == DriverSQLite {
+		repo, err := NewSQLiteUserRepository(config.SQLitePath, config.SQLiteDB)
+		if err != nil {
+			return nil, err
+		}
+		return repo, nil
+	}
+	repo, err := NewPostgresUserRepository(config.PostgresConn, nil)
+	if err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+func NewLogRepository(config Config) (LogRepository, error) {
+	if config.Driver == DriverSQLite {
+		repo, err := NewSQLiteLogRepository(config.SQLitePath, config.SQLiteDB)
+		if err != nil {
+			return nil, err
+		}
+		return repo, nil
+	}
+	repo, err := NewPostgresLogRepository(config.PostgresConn, nil)
+	if err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+func NewProviderStatusRepository(config Config) (ProviderStatusRepository, error) {
+	if config.Driver == DriverSQLite {
+		repo, err := NewSQLiteProviderStatusRepository(config.SQLitePath, config.SQLiteDB)
+		if err != nil {
+			return nil, err
+		}
+		return repo, nil
+	}
+	repo, err := NewPostgresProviderStatusRepository(config.PostgresConn, nil)
+	if err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+func NewLeaderLockRepository(config Config, key int64, ttl time.Duration) (LeaderLockRepository, error) {
+	if config.Driver == DriverSQLite {
+		repo, err := NewSQLiteLeaderLockRepository(config.SQLitePath, config.SQLiteDB, ttl)
+		if err != nil {
+			return nil, err
+		}
+		return repo, nil
+	}
+	repo, err := NewPostgresLeaderLockRepository(config.PostgresConn, nil, key)
+	if err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
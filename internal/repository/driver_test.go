@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenStorage(t *testing.T) {
+	t.Run("Shares one SQLite handle", func(t *testing.T) {
+		storage, err := OpenStorage(Config{Driver: DriverSQLite, SQLitePath: filepath.Join(t.TempDir(), "test.db")})
+		require.NoError(t, err)
+		t.Cleanup(func() { storage.SQLiteDB.Close() })
+
+		currencies, err := NewCurrencyRepository(storage)
+		require.NoError(t, err)
+		users, err := NewUserRepository(storage)
+		require.NoError(t, err)
+
+		assert.Same(t, storage.SQLiteDB, currencies.(*SQLiteCurrencyRepository).db)
+		assert.Same(t, storage.SQLiteDB, users.(*SQLiteUserRepository).db)
+	})
+
+	t.Run("Repositories leave the shared handle open", func(t *testing.T) {
+		storage, err := OpenStorage(Config{Driver: DriverSQLite, SQLitePath: filepath.Join(t.TempDir(), "test.db")})
+		require.NoError(t, err)
+
+		currencies, err := NewCurrencyRepository(storage)
+		require.NoError(t, err)
+		logs, err := NewLogRepository(storage)
+		require.NoError(t, err)
+
+		require.NoError(t, currencies.Close())
+		require.NoError(t, logs.Close())
+		assert.NoError(t, storage.SQLiteDB.Ping())
+
+		require.NoError(t, storage.Close())
+		assert.Error(t, storage.SQLiteDB.Ping())
+	})
+
+	t.Run("Leaves Postgres untouched", func(t *testing.T) {
+		storage, err := OpenStorage(Config{Driver: DriverPostgres, PostgresConn: "postgres://localhost/test"})
+		require.NoError(t, err)
+		assert.Nil(t, storage.SQLiteDB)
+		assert.NoError(t, storage.Close())
+	})
+}
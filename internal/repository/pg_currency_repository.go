@@ -4,9 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/Lutefd/challenge-bravo/internal/model"
-	_ "github.com/lib/pq"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
 )
 
 type PostgresCurrencyRepository struct {
@@ -31,41 +36,361 @@ func NewPostgresCurrencyRepository(connURL string, db *sql.DB) (*PostgresCurrenc
 }
 
 func (r *PostgresCurrencyRepository) GetByCode(ctx context.Context, code string) (*model.Currency, error) {
-	query := `SELECT code, rate, updated_at, created_by, updated_by, created_at FROM currencies WHERE code = $1`
-	var currency model.Currency
-	err := r.db.QueryRowContext(ctx, query, code).Scan(
-		&currency.Code, &currency.Rate, &currency.UpdatedAt,
-		&currency.CreatedBy, &currency.UpdatedBy, &currency.CreatedAt,
-	)
+	query := `SELECT ` + currencyColumns + ` FROM currencies WHERE code = $1`
+	currency, err := scanCurrency(r.db.QueryRowContext(ctx, query, code))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, model.ErrCurrencyNotFound
 		}
 		return nil, fmt.Errorf("failed to get currency: %w", err)
 	}
+	return currency, nil
+}
+
+func (r *PostgresCurrencyRepository) GetByCodes(ctx context.Context, codes []string) ([]model.Currency, error) {
+	query := `SELECT ` + currencyColumns + ` FROM currencies WHERE code = ANY($1)`
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(codes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get currencies: %w", err)
+	}
+	defer rows.Close()
+
+	currencies := []model.Currency{}
+	for rows.Next() {
+		currency, err := scanCurrency(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan currency: %w", err)
+		}
+		currencies = append(currencies, *currency)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate currencies: %w", err)
+	}
+	return currencies, nil
+}
+
+const currencyColumns = `code, rate, updated_at, created_by, updated_by, created_at, name, symbol, minor_units, countries, kind, source, locked, providers, jump_threshold`
+
+var currencySortColumns = map[string]string{
+	"code":       "code",
+	"rate":       "rate",
+	"updated_at": "updated_at",
+	"created_at": "created_at",
+}
+
+func (r *PostgresCurrencyRepository) List(ctx context.Context, opts model.CurrencyListOptions) ([]model.Currency, int, error) {
+	var conditions []string
+	var args []interface{}
+
+	switch opts.Source {
+	case model.CurrencySourceProvider, model.CurrencySourceManual:
+		args = append(args, opts.Source)
+		conditions = append(conditions, fmt.Sprintf("source = $%d", len(args)))
+	}
+	if !opts.UpdatedSince.IsZero() {
+		args = append(args, opts.UpdatedSince)
+		conditions = append(conditions, fmt.Sprintf("updated_at >= $%d", len(args)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM currencies` + where
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count currencies: %w", err)
+	}
+
+	sortColumn, ok := currencySortColumns[opts.SortBy]
+	if !ok {
+		sortColumn = "code"
+	}
+	direction := "ASC"
+	if opts.Descending {
+		direction = "DESC"
+	}
+
+	args = append(args, opts.Limit, opts.Offset)
+	query := fmt.Sprintf(`SELECT %s FROM currencies%s ORDER BY %s %s, code LIMIT $%d OFFSET $%d`,
+		currencyColumns, where, sortColumn, direction, len(args)-1, len(args))
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list currencies: %w", err)
+	}
+	defer rows.Close()
+
+	currencies := []model.Currency{}
+	for rows.Next() {
+		currency, err := scanCurrency(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan currency: %w", err)
+		}
+		currencies = append(currencies, *currency)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate currencies: %w", err)
+	}
+	return currencies, total, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func scanCurrency(row rowScanner) (*model.Currency, error) {
+	var currency model.Currency
+	var kind sql.NullString
+	var jumpThreshold decimal.NullDecimal
+	err := row.Scan(
+		&currency.Code, &currency.Rate, &currency.UpdatedAt,
+		&currency.CreatedBy, &currency.UpdatedBy, &currency.CreatedAt,
+		&currency.Name, &currency.Symbol, &currency.MinorUnits,
+		pq.Array(&currency.Countries), &kind,
+		&currency.Source, &currency.Locked, pq.Array(&currency.Providers),
+		&jumpThreshold,
+	)
+	if err != nil {
+		return nil, err
+	}
+	currency.Code = strings.TrimSpace(currency.Code)
+	currency.Kind = model.CurrencyKind(kind.String)
+	if jumpThreshold.Valid {
+		currency.JumpThreshold = &jumpThreshold.Decimal
+	}
+	if currency.Countries == nil {
+		currency.Countries = []string{}
+	}
 	return &currency, nil
 }
 
 func (r *PostgresCurrencyRepository) Create(ctx context.Context, currency *model.Currency) error {
-	query := `INSERT INTO currencies (code, rate, updated_at, created_by, updated_by, created_at)
-              VALUES ($1, $2, $3, $4, $5, $6)`
-	_, err := r.db.ExecContext(ctx, query,
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO currencies (code, rate, updated_at, created_by, updated_by, created_at, name, symbol, minor_units, countries, kind, source, locked, providers)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
+	_, err = tx.ExecContext(ctx, query,
 		currency.Code, currency.Rate, currency.UpdatedAt,
 		currency.CreatedBy, currency.UpdatedBy, currency.CreatedAt,
+		currency.Name, currency.Symbol, currency.MinorUnits,
+		pq.Array(stringsOrEmpty(currency.Countries)), kindOrNull(currency.Kind),
+		sourceOrDefault(currency.Source), currency.Locked, pq.Array(stringsOrEmpty(currency.Providers)),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create currency: %w", err)
 	}
+
+	if err := insertRateHistory(ctx, tx, currency); err != nil {
+		return err
+	}
+
+	if currency.Peg != nil {
+		if err := upsertPeg(ctx, tx, currency.Code, currency.Peg); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
 
 func (r *PostgresCurrencyRepository) Update(ctx context.Context, currency *model.Currency) error {
-	query := `UPDATE currencies SET rate = $2, updated_at = $3, updated_by = $4 WHERE code = $1`
-	result, err := r.db.ExecContext(ctx, query,
-		currency.Code, currency.Rate, currency.UpdatedAt, currency.UpdatedBy,
-	)
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to update currency: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := updateCurrencyRate(ctx, tx, currency); err != nil {
+		return err
+	}
+
+	if currency.Peg != nil {
+		if err := upsertPeg(ctx, tx, currency.Code, currency.Peg); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresCurrencyRepository) Replace(ctx context.Context, currency *model.Currency) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := updateCurrencyRate(ctx, tx, currency); err != nil {
+		return err
+	}
+
+	if err := updateCurrencyMetadata(ctx, tx, currency.Code, currency.CurrencyMetadata); err != nil {
+		return err
+	}
+
+	if currency.Peg != nil {
+		if err := upsertPeg(ctx, tx, currency.Code, currency.Peg); err != nil {
+			return err
+		}
+	} else if _, err := tx.ExecContext(ctx, `DELETE FROM currency_pegs WHERE code = $1`, currency.Code); err != nil {
+		return fmt.Errorf("failed to remove peg: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresCurrencyRepository) UpsertRates(ctx context.Context, currencies []model.Currency) (int, error) {
+	if len(currencies) == 0 {
+		return 0, nil
+	}
+
+	sorted := make([]model.Currency, len(currencies))
+	copy(sorted, currencies)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Code < sorted[j].Code
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows := make([]string, 0, len(sorted))
+	args := make([]interface{}, 0, len(sorted)*14)
+	for _, currency := range sorted {
+		rows = append(rows, placeholders(len(args), 14))
+		args = append(args,
+			currency.Code, currency.Rate, currency.UpdatedAt,
+			currency.CreatedBy, currency.UpdatedBy, currency.CreatedAt,
+			currency.Name, currency.Symbol, currency.MinorUnits,
+			pq.Array(stringsOrEmpty(currency.Countries)), kindOrNull(currency.Kind),
+			sourceOrDefault(currency.Source), currency.Locked, pq.Array(stringsOrEmpty(currency.Providers)),
+		)
+	}
+	query := `INSERT INTO currencies (code, rate, updated_at, created_by, updated_by, created_at, name, symbol, minor_units, countries, kind, source, locked, providers)
+              VALUES ` + strings.Join(rows, ", ") + `
+              ON CONFLICT (code) DO UPDATE SET rate = EXCLUDED.rate, updated_at = EXCLUDED.updated_at, updated_by = EXCLUDED.updated_by,
+              source = EXCLUDED.source, locked = currencies.locked OR EXCLUDED.locked, providers = EXCLUDED.providers
+              WHERE NOT currencies.locked
+              RETURNING code`
+	written, err := queryCodes(ctx, tx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to upsert currencies: %w", err)
+	}
+
+	rows = rows[:0]
+	args = args[:0]
+	for _, currency := range sorted {
+		if !written[currency.Code] {
+			continue
+		}
+		rows = append(rows, placeholders(len(args), 5))
+		args = append(args,
+			currency.Code, currency.Rate, currency.UpdatedAt, currency.UpdatedBy, pq.Array(stringsOrEmpty(currency.Providers)),
+		)
+	}
+	if len(rows) > 0 {
+		query = `INSERT INTO currency_rate_history (code, rate, recorded_at, updated_by, providers) VALUES ` + strings.Join(rows, ", ")
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return 0, fmt.Errorf("failed to record rate history: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return len(written), nil
+}
+
+func (r *PostgresCurrencyRepository) UpdatePeggedRates(ctx context.Context, currencies []model.Currency) error {
+	if len(currencies) == 0 {
+		return nil
+	}
+
+	sorted := make([]model.Currency, len(currencies))
+	copy(sorted, currencies)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Code < sorted[j].Code
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE currencies SET rate = $2, updated_at = $3, updated_by = $4
+              WHERE code = $1 AND EXISTS (SELECT 1 FROM currency_pegs WHERE currency_pegs.code = currencies.code)`
+	for i := range sorted {
+		currency := &sorted[i]
+		result, err := tx.ExecContext(ctx, query, currency.Code, currency.Rate, currency.UpdatedAt, currency.UpdatedBy)
+		if err != nil {
+			return fmt.Errorf("failed to update pegged currency: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			continue
+		}
+		if err := insertRateHistory(ctx, tx, currency); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func queryCodes(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) (map[string]bool, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	codes := make(map[string]bool)
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, err
+		}
+		codes[strings.TrimSpace(code)] = true
+	}
+	return codes, rows.Err()
+}
+
+func (r *PostgresCurrencyRepository) UpdateMetadata(ctx context.Context, code string, metadata model.CurrencyMetadata) error {
+	return updateCurrencyMetadata(ctx, r.db, code, metadata)
+}
+
+func (r *PostgresCurrencyRepository) SetLocked(ctx context.Context, code string, locked bool) error {
+	query := `UPDATE currencies SET locked = $2 WHERE code = $1`
+	result, err := r.db.ExecContext(ctx, query, code, locked)
+	if err != nil {
+		return fmt.Errorf("failed to update currency lock: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -76,7 +401,24 @@ func (r *PostgresCurrencyRepository) Update(ctx context.Context, currency *model
 	if rowsAffected == 0 {
 		return model.ErrCurrencyNotFound
 	}
+	return nil
+}
+
+func (r *PostgresCurrencyRepository) SetJumpThreshold(ctx context.Context, code string, threshold *decimal.Decimal) error {
+	query := `UPDATE currencies SET jump_threshold = $2 WHERE code = $1`
+	result, err := r.db.ExecContext(ctx, query, code, decimalOrNull(threshold))
+	if err != nil {
+		return fmt.Errorf("failed to update jump threshold: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
 
+	if rowsAffected == 0 {
+		return model.ErrCurrencyNotFound
+	}
 	return nil
 }
 
@@ -89,6 +431,504 @@ func (r *PostgresCurrencyRepository) Delete(ctx context.Context, code string) er
 	return nil
 }
 
+func (r *PostgresCurrencyRepository) GetPeg(ctx context.Context, code string) (*model.CurrencyPeg, error) {
+	query := `SELECT code, anchor, ratio FROM currency_pegs WHERE code = $1`
+	var peg model.CurrencyPeg
+	err := r.db.QueryRowContext(ctx, query, code).Scan(&peg.Code, &peg.Anchor, &peg.Ratio)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, model.ErrPegNotFound
+		}
+		return nil, fmt.Errorf("failed to get peg: %w", err)
+	}
+	peg.Code = strings.TrimSpace(peg.Code)
+	peg.Anchor = strings.TrimSpace(peg.Anchor)
+	return &peg, nil
+}
+
+func (r *PostgresCurrencyRepository) ListPegs(ctx context.Context) ([]model.CurrencyPeg, error) {
+	query := `SELECT code, anchor, ratio FROM currency_pegs ORDER BY code`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pegs: %w", err)
+	}
+	defer rows.Close()
+
+	pegs := []model.CurrencyPeg{}
+	for rows.Next() {
+		var peg model.CurrencyPeg
+		if err := rows.Scan(&peg.Code, &peg.Anchor, &peg.Ratio); err != nil {
+			return nil, fmt.Errorf("failed to scan peg: %w", err)
+		}
+		peg.Code = strings.TrimSpace(peg.Code)
+		peg.Anchor = strings.TrimSpace(peg.Anchor)
+		pegs = append(pegs, peg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate pegs: %w", err)
+	}
+	return pegs, nil
+}
+
+func (r *PostgresCurrencyRepository) GetRateHistory(ctx context.Context, code string, from, to time.Time) ([]model.RateHistory, error) {
+	query := `SELECT code, rate, recorded_at, updated_by, providers FROM currency_rate_history
+              WHERE code = $1 AND recorded_at >= $2 AND recorded_at < $3 ORDER BY recorded_at`
+	rows, err := r.db.QueryContext(ctx, query, code, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rate history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []model.RateHistory{}
+	for rows.Next() {
+		var entry model.RateHistory
+		if err := rows.Scan(&entry.Code, &entry.Rate, &entry.RecordedAt, &entry.UpdatedBy, pq.Array(&entry.Providers)); err != nil {
+			return nil, fmt.Errorf("failed to scan rate history: %w", err)
+		}
+		history = append(history, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rate history: %w", err)
+	}
+	return history, nil
+}
+
+func (r *PostgresCurrencyRepository) GetRatesBefore(ctx context.Context, codes []string, before time.Time) ([]model.RateHistory, error) {
+	query := `SELECT DISTINCT ON (code) code, rate, recorded_at, updated_by, providers FROM currency_rate_history
+              WHERE code = ANY($1) AND recorded_at < $2 ORDER BY code, recorded_at DESC`
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(codes), before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get historical rates: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []model.RateHistory{}
+	for rows.Next() {
+		var entry model.RateHistory
+		if err := rows.Scan(&entry.Code, &entry.Rate, &entry.RecordedAt, &entry.UpdatedBy, pq.Array(&entry.Providers)); err != nil {
+			return nil, fmt.Errorf("failed to scan historical rate: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate historical rates: %w", err)
+	}
+	return entries, nil
+}
+
+func (r *PostgresCurrencyRepository) InsertRateHistory(ctx context.Context, entries []model.RateHistory) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO currency_rate_history (code, rate, recorded_at, updated_by, providers)
+              SELECT $1, $2, $3, $4, $5
+              WHERE NOT EXISTS (SELECT 1 FROM currency_rate_history WHERE code = $1 AND recorded_at = $3)`
+	inserted := 0
+	for _, entry := range entries {
+		result, err := tx.ExecContext(ctx, query,
+			entry.Code, entry.Rate, entry.RecordedAt, entry.UpdatedBy, pq.Array(stringsOrEmpty(entry.Providers)),
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert rate history for %s: %w", entry.Code, err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		inserted += int(rowsAffected)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return inserted, nil
+}
+
+func (r *PostgresCurrencyRepository) GetLastRateSync(ctx context.Context) (time.Time, error) {
+	var lastSyncedAt time.Time
+	err := r.db.QueryRowContext(ctx, `SELECT last_synced_at FROM rate_sync_state`).Scan(&lastSyncedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("failed to get last rate sync: %w", err)
+	}
+	return lastSyncedAt, nil
+}
+
+func (r *PostgresCurrencyRepository) SetLastRateSync(ctx context.Context, at time.Time) error {
+	query := `INSERT INTO rate_sync_state (id, last_synced_at, updated_at) VALUES (TRUE, $1, $2)
+              ON CONFLICT (id) DO UPDATE SET last_synced_at = GREATEST(rate_sync_state.last_synced_at, EXCLUDED.last_synced_at),
+              updated_at = EXCLUDED.updated_at`
+	if _, err := r.db.ExecContext(ctx, query, at, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to set last rate sync: %w", err)
+	}
+	return nil
+}
+
+const scheduledRateChangeColumns = `id, code, rate, effective_at, created_by, created_at`
+
+func (r *PostgresCurrencyRepository) ScheduleRateChange(ctx context.Context, change *model.ScheduledRateChange) error {
+	query := `INSERT INTO scheduled_rate_changes (` + scheduledRateChangeColumns + `) VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := r.db.ExecContext(ctx, query,
+		change.ID, change.Code, change.Rate, change.EffectiveAt, change.CreatedBy, change.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to schedule rate change: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresCurrencyRepository) ListScheduledRateChanges(ctx context.Context, code string) ([]model.ScheduledRateChange, error) {
+	if code == "" {
+		query := `SELECT ` + scheduledRateChangeColumns + ` FROM scheduled_rate_changes ORDER BY effective_at, code`
+		return r.queryScheduledRateChanges(ctx, query)
+	}
+	query := `SELECT ` + scheduledRateChangeColumns + ` FROM scheduled_rate_changes WHERE code = $1 ORDER BY effective_at`
+	return r.queryScheduledRateChanges(ctx, query, code)
+}
+
+func (r *PostgresCurrencyRepository) ListDueRateChanges(ctx context.Context, at time.Time) ([]model.ScheduledRateChange, error) {
+	query := `SELECT ` + scheduledRateChangeColumns + ` FROM scheduled_rate_changes
+              WHERE effective_at <= $1 ORDER BY effective_at, code`
+	return r.queryScheduledRateChanges(ctx, query, at)
+}
+
+func (r *PostgresCurrencyRepository) queryScheduledRateChanges(ctx context.Context, query string, args ...interface{}) ([]model.ScheduledRateChange, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled rate changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []model.ScheduledRateChange{}
+	for rows.Next() {
+		var change model.ScheduledRateChange
+		if err := rows.Scan(
+			&change.ID, &change.Code, &change.Rate, &change.EffectiveAt, &change.CreatedBy, &change.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan scheduled rate change: %w", err)
+		}
+		change.Code = strings.TrimSpace(change.Code)
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate scheduled rate changes: %w", err)
+	}
+	return changes, nil
+}
+
+func (r *PostgresCurrencyRepository) CancelScheduledRateChange(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM scheduled_rate_changes WHERE id = $1`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to cancel scheduled rate change: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return model.ErrScheduledChangeNotFound
+	}
+	return nil
+}
+
+func (r *PostgresCurrencyRepository) ApplyScheduledRateChange(ctx context.Context, change model.ScheduledRateChange) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM scheduled_rate_changes WHERE id = $1`, change.ID)
+	if err != nil {
+		return fmt.Errorf("failed to remove scheduled rate change: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return model.ErrScheduledChangeNotFound
+	}
+
+	currency := &model.Currency{
+		Code:      change.Code,
+		Rate:      change.Rate,
+		UpdatedAt: change.EffectiveAt,
+		UpdatedBy: change.CreatedBy,
+	}
+	query := `UPDATE currencies SET rate = $2, updated_at = $3, updated_by = $4, source = $5, locked = TRUE, providers = '{}' WHERE code = $1`
+	result, err = tx.ExecContext(ctx, query,
+		currency.Code, currency.Rate, currency.UpdatedAt, currency.UpdatedBy, model.CurrencySourceManual,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update currency: %w", err)
+	}
+	rowsAffected, err = result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return model.ErrCurrencyNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM currency_pegs WHERE code = $1`, currency.Code); err != nil {
+		return fmt.Errorf("failed to remove peg: %w", err)
+	}
+
+	if err := insertRateHistory(ctx, tx, currency); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+const quarantinedRateColumns = `id, code, previous_rate, rate, change, providers, recorded_at, created_at`
+
+func (r *PostgresCurrencyRepository) QuarantineRate(ctx context.Context, rate *model.QuarantinedRate) error {
+	query := `INSERT INTO quarantined_rates (` + quarantinedRateColumns + `)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+              ON CONFLICT (code) DO UPDATE SET id = EXCLUDED.id, previous_rate = EXCLUDED.previous_rate, rate = EXCLUDED.rate,
+              change = EXCLUDED.change, providers = EXCLUDED.providers, recorded_at = EXCLUDED.recorded_at, created_at = EXCLUDED.created_at`
+	_, err := r.db.ExecContext(ctx, query,
+		rate.ID, rate.Code, rate.PreviousRate, rate.Rate, rate.Change,
+		pq.Array(stringsOrEmpty(rate.Providers)), rate.RecordedAt, rate.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to quarantine rate: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresCurrencyRepository) ListQuarantinedRates(ctx context.Context, code string) ([]model.QuarantinedRate, error) {
+	if code == "" {
+		query := `SELECT ` + quarantinedRateColumns + ` FROM quarantined_rates ORDER BY created_at, code`
+		return r.queryQuarantinedRates(ctx, query)
+	}
+	query := `SELECT ` + quarantinedRateColumns + ` FROM quarantined_rates WHERE code = $1 ORDER BY created_at`
+	return r.queryQuarantinedRates(ctx, query, code)
+}
+
+func (r *PostgresCurrencyRepository) queryQuarantinedRates(ctx context.Context, query string, args ...interface{}) ([]model.QuarantinedRate, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quarantined rates: %w", err)
+	}
+	defer rows.Close()
+
+	rates := []model.QuarantinedRate{}
+	for rows.Next() {
+		rate, err := scanQuarantinedRate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan quarantined rate: %w", err)
+		}
+		rates = append(rates, *rate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate quarantined rates: %w", err)
+	}
+	return rates, nil
+}
+
+func scanQuarantinedRate(row rowScanner) (*model.QuarantinedRate, error) {
+	var rate model.QuarantinedRate
+	err := row.Scan(
+		&rate.ID, &rate.Code, &rate.PreviousRate, &rate.Rate, &rate.Change,
+		pq.Array(&rate.Providers), &rate.RecordedAt, &rate.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	rate.Code = strings.TrimSpace(rate.Code)
+	return &rate, nil
+}
+
+func (r *PostgresCurrencyRepository) ApproveQuarantinedRate(ctx context.Context, id, approvedBy uuid.UUID) (*model.QuarantinedRate, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `DELETE FROM quarantined_rates WHERE id = $1 RETURNING ` + quarantinedRateColumns
+	rate, err := scanQuarantinedRate(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, model.ErrQuarantinedRateNotFound
+		}
+		return nil, fmt.Errorf("failed to remove quarantined rate: %w", err)
+	}
+
+	var locked bool
+	if err := tx.QueryRowContext(ctx, `SELECT locked FROM currencies WHERE code = $1 FOR UPDATE`, rate.Code).Scan(&locked); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, model.ErrCurrencyNotFound
+		}
+		return nil, fmt.Errorf("failed to get currency: %w", err)
+	}
+	if locked {
+		return nil, fmt.Errorf("%w: %s", model.ErrCurrencyLocked, rate.Code)
+	}
+
+	currency := &model.Currency{
+		Code:      rate.Code,
+		Rate:      rate.Rate,
+		UpdatedAt: rate.RecordedAt,
+		UpdatedBy: approvedBy,
+		Providers: rate.Providers,
+	}
+	query = `UPDATE currencies SET rate = $2, updated_at = $3, updated_by = $4, source = $5, providers = $6 WHERE code = $1`
+	result, err := tx.ExecContext(ctx, query,
+		currency.Code, currency.Rate, currency.UpdatedAt, currency.UpdatedBy,
+		model.CurrencySourceProvider, pq.Array(stringsOrEmpty(currency.Providers)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update currency: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, model.ErrCurrencyNotFound
+	}
+
+	if err := insertRateHistory(ctx, tx, currency); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return rate, nil
+}
+
+func (r *PostgresCurrencyRepository) RejectQuarantinedRate(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM quarantined_rates WHERE id = $1`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to reject quarantined rate: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return model.ErrQuarantinedRateNotFound
+	}
+	return nil
+}
+
+func insertRateHistory(ctx context.Context, tx *sql.Tx, currency *model.Currency) error {
+	query := `INSERT INTO currency_rate_history (code, rate, recorded_at, updated_by, providers) VALUES ($1, $2, $3, $4, $5)`
+	_, err := tx.ExecContext(ctx, query,
+		currency.Code, currency.Rate, currency.UpdatedAt, currency.UpdatedBy, pq.Array(stringsOrEmpty(currency.Providers)),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record rate history: %w", err)
+	}
+	return nil
+}
+
+func updateCurrencyRate(ctx context.Context, tx *sql.Tx, currency *model.Currency) error {
+	query := `UPDATE currencies SET rate = $2, updated_at = $3, updated_by = $4, source = $5, locked = locked OR $6, providers = $7 WHERE code = $1`
+	result, err := tx.ExecContext(ctx, query,
+		currency.Code, currency.Rate, currency.UpdatedAt, currency.UpdatedBy,
+		sourceOrDefault(currency.Source), currency.Locked, pq.Array(stringsOrEmpty(currency.Providers)),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update currency: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return model.ErrCurrencyNotFound
+	}
+
+	return insertRateHistory(ctx, tx, currency)
+}
+
+func updateCurrencyMetadata(ctx context.Context, db execer, code string, metadata model.CurrencyMetadata) error {
+	query := `UPDATE currencies SET name = $2, symbol = $3, minor_units = $4, countries = $5, kind = $6 WHERE code = $1`
+	result, err := db.ExecContext(ctx, query,
+		code, metadata.Name, metadata.Symbol, metadata.MinorUnits,
+		pq.Array(stringsOrEmpty(metadata.Countries)), kindOrNull(metadata.Kind),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update currency metadata: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return model.ErrCurrencyNotFound
+	}
+
+	return nil
+}
+
+func upsertPeg(ctx context.Context, tx *sql.Tx, code string, peg *model.CurrencyPeg) error {
+	query := `INSERT INTO currency_pegs (code, anchor, ratio) VALUES ($1, $2, $3)
+              ON CONFLICT (code) DO UPDATE SET anchor = EXCLUDED.anchor, ratio = EXCLUDED.ratio`
+	_, err := tx.ExecContext(ctx, query, code, peg.Anchor, peg.Ratio)
+	if err != nil {
+		return fmt.Errorf("failed to save peg: %w", err)
+	}
+	return nil
+}
+
+func placeholders(offset, count int) string {
+	params := make([]string, count)
+	for i := range params {
+		params[i] = fmt.Sprintf("$%d", offset+i+1)
+	}
+	return "(" + strings.Join(params, ", ") + ")"
+}
+
+func stringsOrEmpty(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func decimalOrNull(value *decimal.Decimal) decimal.NullDecimal {
+	if value == nil {
+		return decimal.NullDecimal{}
+	}
+	return decimal.NullDecimal{Decimal: *value, Valid: true}
+}
+
+func kindOrNull(kind model.CurrencyKind) sql.NullString {
+	return sql.NullString{String: string(kind), Valid: kind != ""}
+}
+
+func sourceOrDefault(source model.CurrencySource) model.CurrencySource {
+	if source == "" {
+		return model.CurrencySourceProvider
+	}
+	return source
+}
+
 func (r *PostgresCurrencyRepository) Close() error {
 	return r.db.Close()
 }
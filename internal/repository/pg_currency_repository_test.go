@@ -3,12 +3,14 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/Lutefd/challenge-bravo/internal/model"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -38,6 +40,10 @@ func TestNewPostgresCurrencyRepository(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+func newCurrencyRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"code", "rate", "updated_at", "created_by", "updated_by", "created_at", "name", "symbol", "minor_units", "countries", "kind", "source", "locked", "providers", "jump_threshold"})
+}
+
 func TestPostgresCurrencyRepository_GetByCode(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
@@ -46,10 +52,10 @@ func TestPostgresCurrencyRepository_GetByCode(t *testing.T) {
 	repo := &PostgresCurrencyRepository{db: db}
 
 	t.Run("Successful retrieval", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"code", "rate", "updated_at", "created_by", "updated_by", "created_at"}).
-			AddRow("USD", 1.0, time.Now(), uuid.New(), uuid.New(), time.Now())
+		rows := newCurrencyRows().
+			AddRow("USD", "1", time.Now(), uuid.New(), uuid.New(), time.Now(), "US Dollar", "$", 2, "{US,EC}", "fiat", "provider", false, "{}", nil)
 
-		mock.ExpectQuery("SELECT code, rate, updated_at, created_by, updated_by, created_at FROM currencies WHERE code = \\$1").
+		mock.ExpectQuery("SELECT code, rate, updated_at, created_by, updated_by, created_at, name, symbol, minor_units, countries, kind, source, locked, providers, jump_threshold FROM currencies WHERE code = \\$1").
 			WithArgs("USD").
 			WillReturnRows(rows)
 
@@ -57,10 +63,13 @@ func TestPostgresCurrencyRepository_GetByCode(t *testing.T) {
 		assert.NoError(t, err)
 		assert.NotNil(t, currency)
 		assert.Equal(t, "USD", currency.Code)
+		assert.Equal(t, "US Dollar", currency.Name)
+		assert.Equal(t, []string{"US", "EC"}, currency.Countries)
+		assert.Equal(t, model.CurrencyKindFiat, currency.Kind)
 	})
 
 	t.Run("Currency not found", func(t *testing.T) {
-		mock.ExpectQuery("SELECT code, rate, updated_at, created_by, updated_by, created_at FROM currencies WHERE code = \\$1").
+		mock.ExpectQuery("SELECT code, rate, updated_at, created_by, updated_by, created_at, name, symbol, minor_units, countries, kind, source, locked, providers, jump_threshold FROM currencies WHERE code = \\$1").
 			WithArgs("EUR").
 			WillReturnError(sql.ErrNoRows)
 
@@ -71,6 +80,110 @@ func TestPostgresCurrencyRepository_GetByCode(t *testing.T) {
 	})
 }
 
+func TestPostgresCurrencyRepository_GetByCodes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &PostgresCurrencyRepository{db: db}
+
+	t.Run("Successful retrieval", func(t *testing.T) {
+		rows := newCurrencyRows().
+			AddRow("USD", "1", time.Now(), uuid.New(), uuid.New(), time.Now(), "US Dollar", "$", 2, "{US}", "fiat", "provider", false, "{}", nil).
+			AddRow("BTC", "0.000016", time.Now(), uuid.New(), uuid.New(), time.Now(), "Bitcoin", "₿", 8, "{}", "crypto", "provider", false, "{}", "0.5")
+
+		mock.ExpectQuery("SELECT code, rate, updated_at, created_by, updated_by, created_at, name, symbol, minor_units, countries, kind, source, locked, providers, jump_threshold FROM currencies WHERE code = ANY\\(\\$1\\)").
+			WithArgs("{\"USD\",\"BTC\",\"XYZ\"}").
+			WillReturnRows(rows)
+
+		currencies, err := repo.GetByCodes(context.Background(), []string{"USD", "BTC", "XYZ"})
+		assert.NoError(t, err)
+		assert.Len(t, currencies, 2)
+		assert.Equal(t, "USD", currencies[0].Code)
+		assert.Equal(t, "0.000016", currencies[1].Rate.String())
+		assert.Equal(t, model.MinorUnits(8), currencies[1].MinorUnits)
+		assert.Nil(t, currencies[0].JumpThreshold)
+		assert.Equal(t, "0.5", currencies[1].JumpThreshold.String())
+	})
+
+	t.Run("Database error", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM currencies WHERE code = ANY").
+			WillReturnError(errors.New("database error"))
+
+		currencies, err := repo.GetByCodes(context.Background(), []string{"USD"})
+		assert.Error(t, err)
+		assert.Nil(t, currencies)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresCurrencyRepository_List(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &PostgresCurrencyRepository{db: db}
+
+	t.Run("Default options", func(t *testing.T) {
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM currencies$").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		rows := newCurrencyRows().
+			AddRow("EUR  ", "0.85", time.Now(), uuid.New(), uuid.New(), time.Now(), "Euro", "€", 2, "{DE,FR}", "fiat", "manual", true, "{}", nil).
+			AddRow("USD  ", "1", time.Now(), uuid.New(), uuid.New(), time.Now(), "US Dollar", "$", 2, "{}", "fiat", "provider", false, "{}", nil)
+		mock.ExpectQuery("SELECT code, rate, updated_at, created_by, updated_by, created_at, name, symbol, minor_units, countries, kind, source, locked, providers, jump_threshold FROM currencies ORDER BY code ASC, code LIMIT \\$1 OFFSET \\$2").
+			WithArgs(20, 0).
+			WillReturnRows(rows)
+
+		currencies, total, err := repo.List(context.Background(), model.CurrencyListOptions{Limit: 20})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, total)
+		assert.Len(t, currencies, 2)
+		assert.Equal(t, "EUR", currencies[0].Code)
+		assert.Equal(t, model.CurrencySourceManual, currencies[0].Source)
+		assert.True(t, currencies[0].Locked)
+		assert.False(t, currencies[1].Locked)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Filtered and sorted", func(t *testing.T) {
+		since := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM currencies WHERE source = \\$1 AND updated_at >= \\$2").
+			WithArgs(model.CurrencySourceManual, since).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery("SELECT .* FROM currencies WHERE .* ORDER BY rate DESC, code LIMIT \\$3 OFFSET \\$4").
+			WithArgs(model.CurrencySourceManual, since, 10, 10).
+			WillReturnRows(newCurrencyRows())
+
+		currencies, total, err := repo.List(context.Background(), model.CurrencyListOptions{
+			Source:       model.CurrencySourceManual,
+			UpdatedSince: since,
+			SortBy:       "rate",
+			Descending:   true,
+			Limit:        10,
+			Offset:       10,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, total)
+		assert.NotNil(t, currencies)
+		assert.Empty(t, currencies)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unknown sort field falls back to code", func(t *testing.T) {
+		mock.ExpectQuery("SELECT COUNT").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery("ORDER BY code ASC").
+			WithArgs(5, 0).
+			WillReturnRows(newCurrencyRows())
+
+		_, _, err := repo.List(context.Background(), model.CurrencyListOptions{SortBy: "code; DROP TABLE currencies", Limit: 5})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 func TestPostgresCurrencyRepository_Create(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
@@ -81,19 +194,73 @@ func TestPostgresCurrencyRepository_Create(t *testing.T) {
 	t.Run("Successful creation", func(t *testing.T) {
 		currency := &model.Currency{
 			Code:      "EUR",
-			Rate:      0.85,
+			Rate:      decimal.RequireFromString("0.85"),
 			UpdatedAt: time.Now(),
 			CreatedBy: uuid.New(),
 			UpdatedBy: uuid.New(),
 			CreatedAt: time.Now(),
 		}
 
+		mock.ExpectBegin()
 		mock.ExpectExec("INSERT INTO currencies").
-			WithArgs(currency.Code, currency.Rate, currency.UpdatedAt, currency.CreatedBy, currency.UpdatedBy, currency.CreatedAt).
+			WithArgs(currency.Code, currency.Rate, currency.UpdatedAt, currency.CreatedBy, currency.UpdatedBy, currency.CreatedAt,
+				currency.Name, currency.Symbol, nil, "{}", nil, model.CurrencySourceProvider, false, "{}").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO currency_rate_history").
+			WithArgs(currency.Code, currency.Rate, currency.UpdatedAt, currency.UpdatedBy, "{}").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := repo.Create(context.Background(), currency)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Successful creation with peg", func(t *testing.T) {
+		currency := &model.Currency{
+			Code:      "HURB",
+			Rate:      decimal.RequireFromString("1.7"),
+			UpdatedAt: time.Now(),
+			CreatedAt: time.Now(),
+			Peg:       &model.CurrencyPeg{Anchor: "EUR", Ratio: decimal.RequireFromString("0.5")},
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO currencies").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO currency_rate_history").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO currency_pegs \\(code, anchor, ratio\\) VALUES \\(\\$1, \\$2, \\$3\\)\\s+ON CONFLICT \\(code\\) DO UPDATE").
+			WithArgs("HURB", "EUR", currency.Peg.Ratio).
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
 
 		err := repo.Create(context.Background(), currency)
 		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("History insert failure rolls back", func(t *testing.T) {
+		currency := &model.Currency{
+			Code:      "GBP",
+			Rate:      decimal.RequireFromString("0.75"),
+			UpdatedAt: time.Now(),
+			CreatedBy: uuid.New(),
+			UpdatedBy: uuid.New(),
+			CreatedAt: time.Now(),
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO currencies").
+			WithArgs(currency.Code, currency.Rate, currency.UpdatedAt, currency.CreatedBy, currency.UpdatedBy, currency.CreatedAt,
+				currency.Name, currency.Symbol, nil, "{}", nil, model.CurrencySourceProvider, false, "{}").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO currency_rate_history").
+			WillReturnError(errors.New("insert failed"))
+		mock.ExpectRollback()
+
+		err := repo.Create(context.Background(), currency)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to record rate history")
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
 
@@ -107,34 +274,402 @@ func TestPostgresCurrencyRepository_Update(t *testing.T) {
 	t.Run("Successful update", func(t *testing.T) {
 		currency := &model.Currency{
 			Code:      "USD",
-			Rate:      1.1,
+			Rate:      decimal.RequireFromString("1.1"),
 			UpdatedAt: time.Now(),
 			UpdatedBy: uuid.New(),
+			Source:    model.CurrencySourceManual,
+			Locked:    true,
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE currencies SET rate = \\$2, updated_at = \\$3, updated_by = \\$4, source = \\$5, locked = locked OR \\$6, providers = \\$7 WHERE code = \\$1").
+			WithArgs(currency.Code, currency.Rate, currency.UpdatedAt, currency.UpdatedBy, currency.Source, currency.Locked, "{}").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO currency_rate_history").
+			WithArgs(currency.Code, currency.Rate, currency.UpdatedAt, currency.UpdatedBy, "{}").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := repo.Update(context.Background(), currency)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Provider update records contributing providers", func(t *testing.T) {
+		currency := &model.Currency{
+			Code:      "EUR",
+			Rate:      decimal.RequireFromString("0.85"),
+			UpdatedAt: time.Now(),
+			UpdatedBy: uuid.Nil,
+			Source:    model.CurrencySourceProvider,
+			Providers: []string{"ecb", "frankfurter"},
 		}
 
-		mock.ExpectExec("UPDATE currencies SET").
-			WithArgs(currency.Code, currency.Rate, currency.UpdatedAt, currency.UpdatedBy).
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE currencies SET rate = \\$2, updated_at = \\$3, updated_by = \\$4, source = \\$5, locked = locked OR \\$6, providers = \\$7 WHERE code = \\$1").
+			WithArgs(currency.Code, currency.Rate, currency.UpdatedAt, currency.UpdatedBy, currency.Source, false, "{\"ecb\",\"frankfurter\"}").
 			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO currency_rate_history").
+			WithArgs(currency.Code, currency.Rate, currency.UpdatedAt, currency.UpdatedBy, "{\"ecb\",\"frankfurter\"}").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
 
 		err := repo.Update(context.Background(), currency)
 		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("Currency not found", func(t *testing.T) {
 		currency := &model.Currency{
 			Code:      "XYZ",
-			Rate:      1.0,
+			Rate:      decimal.RequireFromString("1"),
 			UpdatedAt: time.Now(),
 			UpdatedBy: uuid.New(),
+			Source:    model.CurrencySourceProvider,
 		}
 
-		mock.ExpectExec("UPDATE currencies SET").
-			WithArgs(currency.Code, currency.Rate, currency.UpdatedAt, currency.UpdatedBy).
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE currencies SET rate = \\$2, updated_at = \\$3, updated_by = \\$4, source = \\$5, locked = locked OR \\$6, providers = \\$7 WHERE code = \\$1").
+			WithArgs(currency.Code, currency.Rate, currency.UpdatedAt, currency.UpdatedBy, currency.Source, currency.Locked, "{}").
 			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
 
 		err := repo.Update(context.Background(), currency)
 		assert.Error(t, err)
 		assert.Equal(t, model.ErrCurrencyNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresCurrencyRepository_Replace(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &PostgresCurrencyRepository{db: db}
+
+	currency := &model.Currency{
+		Code:      "HURB",
+		Rate:      decimal.RequireFromString("3"),
+		UpdatedAt: time.Now(),
+		UpdatedBy: uuid.New(),
+		Source:    model.CurrencySourceManual,
+		Locked:    true,
+		CurrencyMetadata: model.CurrencyMetadata{
+			Name:       "Hurb Coin",
+			MinorUnits: model.MinorUnits(2),
+			Countries:  []string{},
+			Kind:       model.CurrencyKindFictional,
+		},
+	}
+
+	expectRateAndMetadata := func() {
+		mock.ExpectExec("UPDATE currencies SET rate = \\$2, updated_at = \\$3, updated_by = \\$4, source = \\$5, locked = locked OR \\$6, providers = \\$7 WHERE code = \\$1").
+			WithArgs(currency.Code, currency.Rate, currency.UpdatedAt, currency.UpdatedBy, currency.Source, true, "{}").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO currency_rate_history").
+			WithArgs(currency.Code, currency.Rate, currency.UpdatedAt, currency.UpdatedBy, "{}").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("UPDATE currencies SET name = \\$2, symbol = \\$3, minor_units = \\$4, countries = \\$5, kind = \\$6 WHERE code = \\$1").
+			WithArgs("HURB", "Hurb Coin", "", 2, "{}", model.CurrencyKindFictional).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	t.Run("Rate removes the peg", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectRateAndMetadata()
+		mock.ExpectExec("DELETE FROM currency_pegs WHERE code = \\$1").
+			WithArgs("HURB").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.Replace(context.Background(), currency)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Peg is saved", func(t *testing.T) {
+		pegged := *currency
+		pegged.Peg = &model.CurrencyPeg{Anchor: "EUR", Ratio: decimal.RequireFromString("0.5")}
+
+		mock.ExpectBegin()
+		expectRateAndMetadata()
+		mock.ExpectExec("INSERT INTO currency_pegs").
+			WithArgs("HURB", "EUR", pegged.Peg.Ratio).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.Replace(context.Background(), &pegged)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Metadata failure rolls back the rate", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE currencies SET rate").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO currency_rate_history").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("UPDATE currencies SET name").WillReturnError(errors.New("constraint violation"))
+		mock.ExpectRollback()
+
+		err := repo.Replace(context.Background(), currency)
+		assert.ErrorContains(t, err, "failed to update currency metadata")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresCurrencyRepository_UpsertRates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &PostgresCurrencyRepository{db: db}
+
+	updatedAt := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+	currencies := []model.Currency{
+		{
+			Code:      "EUR",
+			Rate:      decimal.RequireFromString("0.85"),
+			UpdatedAt: updatedAt,
+			CreatedAt: updatedAt,
+			Source:    model.CurrencySourceProvider,
+			Providers: []string{"ecb"},
+			CurrencyMetadata: model.CurrencyMetadata{
+				MinorUnits: model.MinorUnits(2),
+				Kind:       model.CurrencyKindFiat,
+			},
+		},
+		{
+			Code:      "BRL",
+			Rate:      decimal.RequireFromString("5.4"),
+			UpdatedAt: updatedAt,
+			Source:    model.CurrencySourceManual,
+		},
+	}
+
+	t.Run("Successful upsert", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO currencies \\(code, rate, updated_at, created_by, updated_by, created_at, name, symbol, minor_units, countries, kind, source, locked, providers\\)\\s+"+
+			"VALUES \\(\\$1, .*, \\$14\\), \\(\\$15, .*, \\$28\\)\\s+"+
+			"ON CONFLICT \\(code\\) DO UPDATE SET rate = EXCLUDED.rate, updated_at = EXCLUDED.updated_at, updated_by = EXCLUDED.updated_by,\\s+"+
+			"source = EXCLUDED.source, locked = currencies.locked OR EXCLUDED.locked, providers = EXCLUDED.providers\\s+"+
+			"WHERE NOT currencies.locked\\s+RETURNING code").
+			WithArgs(
+				"BRL", currencies[1].Rate, updatedAt, uuid.Nil, uuid.Nil, time.Time{}, "", "", nil, "{}", nil, model.CurrencySourceManual, false, "{}",
+				"EUR", currencies[0].Rate, updatedAt, uuid.Nil, uuid.Nil, updatedAt, "", "", 2, "{}", "fiat", model.CurrencySourceProvider, false, "{\"ecb\"}",
+			).
+			WillReturnRows(sqlmock.NewRows([]string{"code"}).AddRow("BRL  ").AddRow("EUR  "))
+		mock.ExpectExec("INSERT INTO currency_rate_history \\(code, rate, recorded_at, updated_by, providers\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5\\), \\(\\$6, \\$7, \\$8, \\$9, \\$10\\)").
+			WithArgs(
+				"BRL", currencies[1].Rate, updatedAt, uuid.Nil, "{}",
+				"EUR", currencies[0].Rate, updatedAt, uuid.Nil, "{\"ecb\"}",
+			).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		written, err := repo.UpsertRates(context.Background(), currencies)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, written)
+		assert.Equal(t, "EUR", currencies[0].Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Skips locked currencies", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO currencies").
+			WillReturnRows(sqlmock.NewRows([]string{"code"}).AddRow("EUR  "))
+		mock.ExpectExec("INSERT INTO currency_rate_history \\(code, rate, recorded_at, updated_by, providers\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5\\)$").
+			WithArgs("EUR", currencies[0].Rate, updatedAt, uuid.Nil, "{\"ecb\"}").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		written, err := repo.UpsertRates(context.Background(), currencies)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, written)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Every currency locked", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO currencies").
+			WillReturnRows(sqlmock.NewRows([]string{"code"}))
+		mock.ExpectCommit()
+
+		written, err := repo.UpsertRates(context.Background(), currencies)
+		assert.NoError(t, err)
+		assert.Zero(t, written)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failed history insert rolls back", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO currencies").
+			WillReturnRows(sqlmock.NewRows([]string{"code"}).AddRow("BRL").AddRow("EUR"))
+		mock.ExpectExec("INSERT INTO currency_rate_history").
+			WillReturnError(errors.New("database error"))
+		mock.ExpectRollback()
+
+		_, err := repo.UpsertRates(context.Background(), currencies)
+		assert.ErrorContains(t, err, "failed to record rate history")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Nothing to upsert", func(t *testing.T) {
+		written, err := repo.UpsertRates(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.Zero(t, written)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresCurrencyRepository_UpdatePeggedRates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &PostgresCurrencyRepository{db: db}
+
+	updatedAt := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+	currencies := []model.Currency{
+		{Code: "HURB", Rate: decimal.RequireFromString("1.9"), UpdatedAt: updatedAt},
+		{Code: "DKK", Rate: decimal.RequireFromString("7.09"), UpdatedAt: updatedAt},
+	}
+	query := "UPDATE currencies SET rate = \\$2, updated_at = \\$3, updated_by = \\$4\\s+" +
+		"WHERE code = \\$1 AND EXISTS \\(SELECT 1 FROM currency_pegs WHERE currency_pegs.code = currencies.code\\)"
+
+	t.Run("Successful update", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(query).
+			WithArgs("DKK", currencies[1].Rate, updatedAt, uuid.Nil).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(query).
+			WithArgs("HURB", currencies[0].Rate, updatedAt, uuid.Nil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO currency_rate_history \\(code, rate, recorded_at, updated_by, providers\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5\\)").
+			WithArgs("HURB", currencies[0].Rate, updatedAt, uuid.Nil, "{}").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.UpdatePeggedRates(context.Background(), currencies)
+		assert.NoError(t, err)
+		assert.Equal(t, "HURB", currencies[0].Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failed update rolls back", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE currencies").
+			WillReturnError(errors.New("database error"))
+		mock.ExpectRollback()
+
+		err := repo.UpdatePeggedRates(context.Background(), currencies)
+		assert.ErrorContains(t, err, "failed to update pegged currency")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Nothing to update", func(t *testing.T) {
+		err := repo.UpdatePeggedRates(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresCurrencyRepository_UpdateMetadata(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &PostgresCurrencyRepository{db: db}
+
+	metadata := model.CurrencyMetadata{
+		Name:       "Bitcoin",
+		Symbol:     "₿",
+		MinorUnits: model.MinorUnits(8),
+		Countries:  []string{"SV"},
+		Kind:       model.CurrencyKindCrypto,
+	}
+
+	t.Run("Successful update", func(t *testing.T) {
+		mock.ExpectExec("UPDATE currencies SET name = \\$2, symbol = \\$3, minor_units = \\$4, countries = \\$5, kind = \\$6 WHERE code = \\$1").
+			WithArgs("BTC", "Bitcoin", "₿", 8, "{\"SV\"}", model.CurrencyKindCrypto).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.UpdateMetadata(context.Background(), "BTC", metadata)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Currency not found", func(t *testing.T) {
+		mock.ExpectExec("UPDATE currencies SET name").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.UpdateMetadata(context.Background(), "XYZ", metadata)
+		assert.Equal(t, model.ErrCurrencyNotFound, err)
+	})
+}
+
+func TestPostgresCurrencyRepository_SetLocked(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &PostgresCurrencyRepository{db: db}
+
+	t.Run("Successful lock", func(t *testing.T) {
+		mock.ExpectExec("UPDATE currencies SET locked = \\$2 WHERE code = \\$1").
+			WithArgs("ARS", true).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.SetLocked(context.Background(), "ARS", true)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Currency not found", func(t *testing.T) {
+		mock.ExpectExec("UPDATE currencies SET locked").
+			WithArgs("XYZ", false).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.SetLocked(context.Background(), "XYZ", false)
+		assert.Equal(t, model.ErrCurrencyNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresCurrencyRepository_SetJumpThreshold(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &PostgresCurrencyRepository{db: db}
+
+	t.Run("Successful update", func(t *testing.T) {
+		threshold := decimal.RequireFromString("0.2")
+		mock.ExpectExec("UPDATE currencies SET jump_threshold = \\$2 WHERE code = \\$1").
+			WithArgs("ARS", "0.2").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.SetJumpThreshold(context.Background(), "ARS", &threshold)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Reset to default", func(t *testing.T) {
+		mock.ExpectExec("UPDATE currencies SET jump_threshold = \\$2 WHERE code = \\$1").
+			WithArgs("ARS", nil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.SetJumpThreshold(context.Background(), "ARS", nil)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Currency not found", func(t *testing.T) {
+		mock.ExpectExec("UPDATE currencies SET jump_threshold").
+			WithArgs("XYZ", nil).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.SetJumpThreshold(context.Background(), "XYZ", nil)
+		assert.Equal(t, model.ErrCurrencyNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
 
@@ -155,6 +690,443 @@ func TestPostgresCurrencyRepository_Delete(t *testing.T) {
 	})
 }
 
+func TestPostgresCurrencyRepository_Pegs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &PostgresCurrencyRepository{db: db}
+
+	t.Run("Get peg", func(t *testing.T) {
+		mock.ExpectQuery("SELECT code, anchor, ratio FROM currency_pegs WHERE code = \\$1").
+			WithArgs("HURB").
+			WillReturnRows(sqlmock.NewRows([]string{"code", "anchor", "ratio"}).AddRow("HURB ", "EUR  ", "0.5"))
+
+		peg, err := repo.GetPeg(context.Background(), "HURB")
+		assert.NoError(t, err)
+		assert.Equal(t, "HURB", peg.Code)
+		assert.Equal(t, "EUR", peg.Anchor)
+		assert.Equal(t, "0.5", peg.Ratio.String())
+	})
+
+	t.Run("Peg not found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT code, anchor, ratio FROM currency_pegs WHERE code = \\$1").
+			WithArgs("USD").
+			WillReturnError(sql.ErrNoRows)
+
+		peg, err := repo.GetPeg(context.Background(), "USD")
+		assert.Nil(t, peg)
+		assert.Equal(t, model.ErrPegNotFound, err)
+	})
+
+	t.Run("List pegs", func(t *testing.T) {
+		mock.ExpectQuery("SELECT code, anchor, ratio FROM currency_pegs ORDER BY code").
+			WillReturnRows(sqlmock.NewRows([]string{"code", "anchor", "ratio"}).
+				AddRow("GOLD ", "USD  ", "0.0005").
+				AddRow("HURB ", "EUR  ", "0.5"))
+
+		pegs, err := repo.ListPegs(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, pegs, 2)
+		assert.Equal(t, "GOLD", pegs[0].Code)
+		assert.Equal(t, "EUR", pegs[1].Anchor)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresCurrencyRepository_GetRateHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &PostgresCurrencyRepository{db: db}
+
+	from := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Successful retrieval", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"code", "rate", "recorded_at", "updated_by", "providers"}).
+			AddRow("EUR", "0.85", from.Add(24*time.Hour), uuid.New(), "{openexchangerates,ecb}").
+			AddRow("EUR", "0.860000000000000000123", from.Add(48*time.Hour), uuid.New(), "{}")
+
+		mock.ExpectQuery("SELECT code, rate, recorded_at, updated_by, providers FROM currency_rate_history").
+			WithArgs("EUR", from, to).
+			WillReturnRows(rows)
+
+		history, err := repo.GetRateHistory(context.Background(), "EUR", from, to)
+		assert.NoError(t, err)
+		assert.Len(t, history, 2)
+		assert.Equal(t, []string{"openexchangerates", "ecb"}, history[0].Providers)
+		assert.Equal(t, "0.860000000000000000123", history[1].Rate.String())
+	})
+
+	t.Run("Empty history", func(t *testing.T) {
+		mock.ExpectQuery("SELECT code, rate, recorded_at, updated_by, providers FROM currency_rate_history").
+			WithArgs("XYZ", from, to).
+			WillReturnRows(sqlmock.NewRows([]string{"code", "rate", "recorded_at", "updated_by", "providers"}))
+
+		history, err := repo.GetRateHistory(context.Background(), "XYZ", from, to)
+		assert.NoError(t, err)
+		assert.NotNil(t, history)
+		assert.Empty(t, history)
+	})
+}
+
+func TestPostgresCurrencyRepository_GetRatesBefore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &PostgresCurrencyRepository{db: db}
+
+	before := time.Date(2024, 8, 11, 0, 0, 0, 0, time.UTC)
+	query := "SELECT DISTINCT ON \\(code\\) code, rate, recorded_at, updated_by, providers FROM currency_rate_history\\s+" +
+		"WHERE code = ANY\\(\\$1\\) AND recorded_at < \\$2 ORDER BY code, recorded_at DESC"
+
+	t.Run("Successful retrieval", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"code", "rate", "recorded_at", "updated_by", "providers"}).
+			AddRow("EUR", "0.85", before.Add(-time.Hour), uuid.New(), "{}").
+			AddRow("GBP", "0.78", before.Add(-2*time.Hour), uuid.New(), "{ecb}")
+
+		mock.ExpectQuery(query).
+			WithArgs("{\"EUR\",\"GBP\",\"JPY\"}", before).
+			WillReturnRows(rows)
+
+		entries, err := repo.GetRatesBefore(context.Background(), []string{"EUR", "GBP", "JPY"}, before)
+		assert.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "0.85", entries[0].Rate.String())
+		assert.Equal(t, []string{"ecb"}, entries[1].Providers)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Database error", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs("{\"EUR\"}", before).
+			WillReturnError(errors.New("database error"))
+
+		entries, err := repo.GetRatesBefore(context.Background(), []string{"EUR"}, before)
+		assert.Nil(t, entries)
+		assert.ErrorContains(t, err, "failed to get historical rates")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresCurrencyRepository_InsertRateHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &PostgresCurrencyRepository{db: db}
+
+	recordedAt := time.Date(2024, 8, 10, 23, 59, 0, 0, time.UTC)
+	entries := []model.RateHistory{
+		{Code: "EUR", Rate: decimal.RequireFromString("0.91"), RecordedAt: recordedAt, Providers: []string{"ecb"}},
+		{Code: "BRL", Rate: decimal.RequireFromString("5.4"), RecordedAt: recordedAt},
+	}
+
+	t.Run("Skips entries already recorded", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO currency_rate_history \\(code, rate, recorded_at, updated_by, providers\\)\\s+SELECT \\$1, \\$2, \\$3, \\$4, \\$5\\s+WHERE NOT EXISTS").
+			WithArgs("EUR", entries[0].Rate, recordedAt, uuid.Nil, "{\"ecb\"}").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO currency_rate_history").
+			WithArgs("BRL", entries[1].Rate, recordedAt, uuid.Nil, "{}").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		inserted, err := repo.InsertRateHistory(context.Background(), entries)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, inserted)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Insert failure rolls back", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO currency_rate_history").
+			WillReturnError(errors.New("insert failed"))
+		mock.ExpectRollback()
+
+		inserted, err := repo.InsertRateHistory(context.Background(), entries)
+		assert.ErrorContains(t, err, "failed to insert rate history for EUR")
+		assert.Zero(t, inserted)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresCurrencyRepository_LastRateSync(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &PostgresCurrencyRepository{db: db}
+
+	syncedAt := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Never synced", func(t *testing.T) {
+		mock.ExpectQuery("SELECT last_synced_at FROM rate_sync_state").
+			WillReturnError(sql.ErrNoRows)
+
+		last, err := repo.GetLastRateSync(context.Background())
+		assert.NoError(t, err)
+		assert.True(t, last.IsZero())
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		mock.ExpectQuery("SELECT last_synced_at FROM rate_sync_state").
+			WillReturnRows(sqlmock.NewRows([]string{"last_synced_at"}).AddRow(syncedAt))
+
+		last, err := repo.GetLastRateSync(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, syncedAt, last)
+	})
+
+	t.Run("Set", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO rate_sync_state \\(id, last_synced_at, updated_at\\) VALUES \\(TRUE, \\$1, \\$2\\)\\s+ON CONFLICT \\(id\\) DO UPDATE SET last_synced_at = GREATEST").
+			WithArgs(syncedAt, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := repo.SetLastRateSync(context.Background(), syncedAt)
+		assert.NoError(t, err)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresCurrencyRepository_ScheduledRateChanges(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &PostgresCurrencyRepository{db: db}
+
+	change := model.ScheduledRateChange{
+		ID:          uuid.New(),
+		Code:        "HURB",
+		Rate:        decimal.RequireFromString("4.5"),
+		EffectiveAt: time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC),
+		CreatedBy:   uuid.New(),
+		CreatedAt:   time.Now(),
+	}
+	newRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "code", "rate", "effective_at", "created_by", "created_at"}).
+			AddRow(change.ID, "HURB ", "4.5", change.EffectiveAt, change.CreatedBy, change.CreatedAt)
+	}
+
+	t.Run("Schedule", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO scheduled_rate_changes \\(id, code, rate, effective_at, created_by, created_at\\)").
+			WithArgs(change.ID, change.Code, change.Rate, change.EffectiveAt, change.CreatedBy, change.CreatedAt).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := repo.ScheduleRateChange(context.Background(), &change)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("List all", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, code, rate, effective_at, created_by, created_at FROM scheduled_rate_changes ORDER BY effective_at, code").
+			WillReturnRows(newRows())
+
+		changes, err := repo.ListScheduledRateChanges(context.Background(), "")
+		assert.NoError(t, err)
+		assert.Len(t, changes, 1)
+		assert.Equal(t, "HURB", changes[0].Code)
+		assert.Equal(t, "4.5", changes[0].Rate.String())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("List by code", func(t *testing.T) {
+		mock.ExpectQuery("FROM scheduled_rate_changes WHERE code = \\$1 ORDER BY effective_at").
+			WithArgs("HURB").
+			WillReturnRows(newRows())
+
+		changes, err := repo.ListScheduledRateChanges(context.Background(), "HURB")
+		assert.NoError(t, err)
+		assert.Len(t, changes, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("List due", func(t *testing.T) {
+		at := time.Date(2024, 9, 1, 0, 1, 0, 0, time.UTC)
+		mock.ExpectQuery("FROM scheduled_rate_changes WHERE effective_at <= \\$1 ORDER BY effective_at, code").
+			WithArgs(at).
+			WillReturnRows(newRows())
+
+		changes, err := repo.ListDueRateChanges(context.Background(), at)
+		assert.NoError(t, err)
+		assert.Equal(t, change.ID, changes[0].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		mock.ExpectExec("DELETE FROM scheduled_rate_changes WHERE id = \\$1").
+			WithArgs(change.ID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM scheduled_rate_changes WHERE id = \\$1").
+			WithArgs(change.ID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.NoError(t, repo.CancelScheduledRateChange(context.Background(), change.ID))
+		assert.Equal(t, model.ErrScheduledChangeNotFound, repo.CancelScheduledRateChange(context.Background(), change.ID))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Apply", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM scheduled_rate_changes WHERE id = \\$1").
+			WithArgs(change.ID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE currencies SET rate = \\$2, updated_at = \\$3, updated_by = \\$4, source = \\$5, locked = TRUE, providers = '{}' WHERE code = \\$1").
+			WithArgs("HURB", change.Rate, change.EffectiveAt, change.CreatedBy, model.CurrencySourceManual).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM currency_pegs WHERE code = \\$1").
+			WithArgs("HURB").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO currency_rate_history").
+			WithArgs("HURB", change.Rate, change.EffectiveAt, change.CreatedBy, "{}").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := repo.ApplyScheduledRateChange(context.Background(), change)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Apply canceled change", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM scheduled_rate_changes WHERE id = \\$1").
+			WithArgs(change.ID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := repo.ApplyScheduledRateChange(context.Background(), change)
+		assert.Equal(t, model.ErrScheduledChangeNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresCurrencyRepository_QuarantinedRates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &PostgresCurrencyRepository{db: db}
+
+	quarantined := model.QuarantinedRate{
+		ID:           uuid.New(),
+		Code:         "ARS",
+		PreviousRate: decimal.RequireFromString("900"),
+		Rate:         decimal.RequireFromString("1350"),
+		Change:       decimal.RequireFromString("0.5"),
+		Providers:    []string{"ecb", "frankfurter"},
+		RecordedAt:   time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC),
+		CreatedAt:    time.Date(2024, 8, 10, 12, 1, 0, 0, time.UTC),
+	}
+	columns := []string{"id", "code", "previous_rate", "rate", "change", "providers", "recorded_at", "created_at"}
+	newRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows(columns).
+			AddRow(quarantined.ID, "ARS  ", "900", "1350", "0.5", "{ecb,frankfurter}", quarantined.RecordedAt, quarantined.CreatedAt)
+	}
+	approvedBy := uuid.New()
+
+	t.Run("Quarantine", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO quarantined_rates \\(id, code, previous_rate, rate, change, providers, recorded_at, created_at\\)\\s+VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5, \\$6, \\$7, \\$8\\)\\s+ON CONFLICT \\(code\\) DO UPDATE").
+			WithArgs(quarantined.ID, "ARS", quarantined.PreviousRate, quarantined.Rate, quarantined.Change, "{\"ecb\",\"frankfurter\"}", quarantined.RecordedAt, quarantined.CreatedAt).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := repo.QuarantineRate(context.Background(), &quarantined)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("List all", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, code, previous_rate, rate, change, providers, recorded_at, created_at FROM quarantined_rates ORDER BY created_at, code").
+			WillReturnRows(newRows())
+
+		rates, err := repo.ListQuarantinedRates(context.Background(), "")
+		require.NoError(t, err)
+		require.Len(t, rates, 1)
+		assert.Equal(t, quarantined, rates[0])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("List by code", func(t *testing.T) {
+		mock.ExpectQuery("FROM quarantined_rates WHERE code = \\$1 ORDER BY created_at").
+			WithArgs("EUR").
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		rates, err := repo.ListQuarantinedRates(context.Background(), "EUR")
+		assert.NoError(t, err)
+		assert.Empty(t, rates)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Approve", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("DELETE FROM quarantined_rates WHERE id = \\$1 RETURNING id, code").
+			WithArgs(quarantined.ID).
+			WillReturnRows(newRows())
+		mock.ExpectQuery("SELECT locked FROM currencies WHERE code = \\$1 FOR UPDATE").
+			WithArgs("ARS").
+			WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+		mock.ExpectExec("UPDATE currencies SET rate = \\$2, updated_at = \\$3, updated_by = \\$4, source = \\$5, providers = \\$6 WHERE code = \\$1").
+			WithArgs("ARS", quarantined.Rate, quarantined.RecordedAt, approvedBy, model.CurrencySourceProvider, "{\"ecb\",\"frankfurter\"}").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO currency_rate_history").
+			WithArgs("ARS", quarantined.Rate, quarantined.RecordedAt, approvedBy, "{\"ecb\",\"frankfurter\"}").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		rate, err := repo.ApproveQuarantinedRate(context.Background(), quarantined.ID, approvedBy)
+		require.NoError(t, err)
+		assert.Equal(t, quarantined, *rate)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Approve locked currency", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("DELETE FROM quarantined_rates WHERE id = \\$1").
+			WithArgs(quarantined.ID).
+			WillReturnRows(newRows())
+		mock.ExpectQuery("SELECT locked FROM currencies WHERE code = \\$1 FOR UPDATE").
+			WithArgs("ARS").
+			WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+		mock.ExpectRollback()
+
+		rate, err := repo.ApproveQuarantinedRate(context.Background(), quarantined.ID, approvedBy)
+		assert.Nil(t, rate)
+		assert.ErrorIs(t, err, model.ErrCurrencyLocked)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Approve missing entry", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("DELETE FROM quarantined_rates WHERE id = \\$1").
+			WithArgs(quarantined.ID).
+			WillReturnRows(sqlmock.NewRows(columns))
+		mock.ExpectRollback()
+
+		rate, err := repo.ApproveQuarantinedRate(context.Background(), quarantined.ID, approvedBy)
+		assert.Nil(t, rate)
+		assert.Equal(t, model.ErrQuarantinedRateNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		mock.ExpectExec("DELETE FROM quarantined_rates WHERE id = \\$1").
+			WithArgs(quarantined.ID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM quarantined_rates WHERE id = \\$1").
+			WithArgs(quarantined.ID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.NoError(t, repo.RejectQuarantinedRate(context.Background(), quarantined.ID))
+		assert.Equal(t, model.ErrQuarantinedRateNotFound, repo.RejectQuarantinedRate(context.Background(), quarantined.ID))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 func TestPostgresCurrencyRepository_Close(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+)
+
+type PostgresLeaderLockRepository struct {
+	db  *sql.DB
+	key int64
+
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+func NewPostgresLeaderLockRepository(connURL string, db *sql.DB, key int64) (*PostgresLeaderLockRepository, error) {
+	if db == nil {
+		var err error
+		db, err = sql.Open("postgres", connURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to database: %w", err)
+		}
+
+		err = db.Ping()
+		if err != nil {
+			return nil, fmt.Errorf("failed to ping database: %w", err)
+		}
+	}
+
+	return &PostgresLeaderLockRepository{db: db, key: key}, nil
+}
+
+func (r *PostgresLeaderLockRepository) TryAcquire(ctx context.Context) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conn != nil {
+		err := r.conn.PingContext(ctx)
+		if err == nil {
+			return true, nil
+		}
+		discardConn(r.conn)
+		r.conn = nil
+		return false, fmt.Errorf("lost leader lock session: %w", err)
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to open leader lock session: %w", err)
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, r.key).Scan(&acquired); err != nil {
+		conn.Close()
+		return false, fmt.Errorf("failed to acquire leader lock: %w", err)
+	}
+	if !acquired {
+		conn.Close()
+		return false, nil
+	}
+
+	r.conn = conn
+	return true, nil
+}
+
+func (r *PostgresLeaderLockRepository) Release(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conn == nil {
+		return nil
+	}
+	conn := r.conn
+	r.conn = nil
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, r.key); err != nil {
+		discardConn(conn)
+		return fmt.Errorf("failed to release leader lock: %w", err)
+	}
+	return conn.Close()
+}
+
+func (r *PostgresLeaderLockRepository) Close() error {
+	if err := r.Release(context.Background()); err != nil {
+		r.db.Close()
+		return err
+	}
+	return r.db.Close()
+}
+
+func discardConn(conn *sql.Conn) {
+	conn.Raw(func(interface{}) error {
+		return driver.ErrBadConn
+	})
+	conn.Close()
+}
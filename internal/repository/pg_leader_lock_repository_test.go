@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresLeaderLockRepository_TryAcquire(t *testing.T) {
+	t.Run("Lock acquired and kept", func(t *testing.T) {
+		db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		require.NoError(t, err)
+		defer db.Close()
+
+		repo := &PostgresLeaderLockRepository{db: db, key: 42}
+
+		mock.ExpectQuery("SELECT pg_try_advisory_lock\\(\\$1\\)").
+			WithArgs(int64(42)).
+			WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(true))
+		mock.ExpectPing()
+
+		acquired, err := repo.TryAcquire(context.Background())
+		assert.NoError(t, err)
+		assert.True(t, acquired)
+
+		acquired, err = repo.TryAcquire(context.Background())
+		assert.NoError(t, err)
+		assert.True(t, acquired)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Lock held by another instance", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		repo := &PostgresLeaderLockRepository{db: db, key: 42}
+
+		mock.ExpectQuery("SELECT pg_try_advisory_lock\\(\\$1\\)").
+			WithArgs(int64(42)).
+			WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(false))
+
+		acquired, err := repo.TryAcquire(context.Background())
+		assert.NoError(t, err)
+		assert.False(t, acquired)
+		assert.Nil(t, repo.conn)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Session lost", func(t *testing.T) {
+		db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		require.NoError(t, err)
+		defer db.Close()
+
+		repo := &PostgresLeaderLockRepository{db: db, key: 42}
+
+		mock.ExpectQuery("SELECT pg_try_advisory_lock\\(\\$1\\)").
+			WithArgs(int64(42)).
+			WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(true))
+		mock.ExpectPing().WillReturnError(errors.New("connection reset by peer"))
+
+		_, err = repo.TryAcquire(context.Background())
+		require.NoError(t, err)
+
+		acquired, err := repo.TryAcquire(context.Background())
+		assert.ErrorContains(t, err, "lost leader lock session")
+		assert.False(t, acquired)
+		assert.Nil(t, repo.conn)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Database error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		repo := &PostgresLeaderLockRepository{db: db, key: 42}
+
+		mock.ExpectQuery("SELECT pg_try_advisory_lock").
+			WillReturnError(errors.New("database error"))
+
+		acquired, err := repo.TryAcquire(context.Background())
+		assert.ErrorContains(t, err, "failed to acquire leader lock")
+		assert.False(t, acquired)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresLeaderLockRepository_Release(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &PostgresLeaderLockRepository{db: db, key: 42}
+
+	t.Run("Not held", func(t *testing.T) {
+		assert.NoError(t, repo.Release(context.Background()))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Held", func(t *testing.T) {
+		mock.ExpectQuery("SELECT pg_try_advisory_lock\\(\\$1\\)").
+			WithArgs(int64(42)).
+			WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(true))
+		mock.ExpectExec("SELECT pg_advisory_unlock\\(\\$1\\)").
+			WithArgs(int64(42)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		_, err := repo.TryAcquire(context.Background())
+		require.NoError(t, err)
+
+		assert.NoError(t, repo.Release(context.Background()))
+		assert.Nil(t, repo.conn)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
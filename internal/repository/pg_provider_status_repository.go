@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Lutefd/challenge-bravo/internal/model"
+)
+
+type PostgresProviderStatusRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresProviderStatusRepository(connURL string, db *sql.DB) (*PostgresProviderStatusRepository, error) {
+	if db == nil {
+		var err error
+		db, err = sql.Open("postgres", connURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to database: %w", err)
+		}
+
+		err = db.Ping()
+		if err != nil {
+			return nil, fmt.Errorf("failed to ping database: %w", err)
+		}
+	}
+
+	return &PostgresProviderStatusRepository{db: db}, nil
+}
+
+func (r *PostgresProviderStatusRepository) SaveStatus(ctx context.Context, status model.ProviderStatus) error {
+	query := `INSERT INTO provider_status (provider, state, consecutive_failures, opened_at, updated_at)
+              VALUES ($1, $2, $3, $4, $5)
+              ON CONFLICT (provider) DO UPDATE SET state = EXCLUDED.state, consecutive_failures = EXCLUDED.consecutive_failures,
+              opened_at = EXCLUDED.opened_at, updated_at = EXCLUDED.updated_at`
+	_, err := r.db.ExecContext(ctx, query,
+		status.Provider, status.State, status.ConsecutiveFailures, status.OpenedAt, status.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save provider status: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresProviderStatusRepository) ListStatuses(ctx context.Context) ([]model.ProviderStatus, error) {
+	query := `SELECT provider, state, consecutive_failures, opened_at, updated_at FROM provider_status ORDER BY provider`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list provider statuses: %w", err)
+	}
+	defer rows.Close()
+
+	statuses := []model.ProviderStatus{}
+	for rows.Next() {
+		var status model.ProviderStatus
+		var openedAt sql.NullTime
+		if err := rows.Scan(&status.Provider, &status.State, &status.ConsecutiveFailures, &openedAt, &status.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan provider status: %w", err)
+		}
+		if openedAt.Valid {
+			status.OpenedAt = &openedAt.Time
+		}
+		statuses = append(statuses, status)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate provider statuses: %w", err)
+	}
+	return statuses, nil
+}
+
+func (r *PostgresProviderStatusRepository) Close() error {
+	return r.db.Close()
+}
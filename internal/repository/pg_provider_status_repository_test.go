@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Lutefd/challenge-bravo/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresProviderStatusRepository_SaveStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &PostgresProviderStatusRepository{db: db}
+
+	openedAt := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+	status := model.ProviderStatus{
+		Provider:            "ecb",
+		State:               model.CircuitStateOpen,
+		ConsecutiveFailures: 3,
+		OpenedAt:            &openedAt,
+		UpdatedAt:           openedAt,
+	}
+
+	t.Run("Successful save", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO provider_status \\(provider, state, consecutive_failures, opened_at, updated_at\\)\\s+VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5\\)\\s+ON CONFLICT \\(provider\\) DO UPDATE").
+			WithArgs("ecb", model.CircuitStateOpen, 3, &openedAt, openedAt).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := repo.SaveStatus(context.Background(), status)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Database error", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO provider_status").
+			WillReturnError(errors.New("database error"))
+
+		err := repo.SaveStatus(context.Background(), status)
+		assert.ErrorContains(t, err, "failed to save provider status")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresProviderStatusRepository_ListStatuses(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &PostgresProviderStatusRepository{db: db}
+
+	openedAt := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"provider", "state", "consecutive_failures", "opened_at", "updated_at"}).
+		AddRow("ecb", "open", 3, openedAt, openedAt).
+		AddRow("frankfurter", "closed", 0, nil, openedAt)
+
+	mock.ExpectQuery("SELECT provider, state, consecutive_failures, opened_at, updated_at FROM provider_status ORDER BY provider").
+		WillReturnRows(rows)
+
+	statuses, err := repo.ListStatuses(context.Background())
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.Equal(t, model.CircuitStateOpen, statuses[0].State)
+	assert.Equal(t, openedAt, *statuses[0].OpenedAt)
+	assert.Equal(t, model.CircuitStateClosed, statuses[1].State)
+	assert.Nil(t, statuses[1].OpenedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
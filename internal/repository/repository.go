@@ -5,13 +5,39 @@ import (
 	"time"
 
 	"github.com/Lutefd/challenge-bravo/internal/model"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 type CurrencyRepository interface {
 	GetByCode(ctx context.Context, code string) (*model.Currency, error)
+	GetByCodes(ctx context.Context, codes []string) ([]model.Currency, error)
+	List(ctx context.Context, opts model.CurrencyListOptions) ([]model.Currency, int, error)
 	Create(ctx context.Context, currency *model.Currency) error
 	Update(ctx context.Context, currency *model.Currency) error
+	Replace(ctx context.Context, currency *model.Currency) error
+	UpsertRates(ctx context.Context, currencies []model.Currency) (int, error)
+	UpdatePeggedRates(ctx context.Context, currencies []model.Currency) error
+	UpdateMetadata(ctx context.Context, code string, metadata model.CurrencyMetadata) error
+	SetLocked(ctx context.Context, code string, locked bool) error
+	SetJumpThreshold(ctx context.Context, code string, threshold *decimal.Decimal) error
 	Delete(ctx context.Context, code string) error
+	GetPeg(ctx context.Context, code string) (*model.CurrencyPeg, error)
+	ListPegs(ctx context.Context) ([]model.CurrencyPeg, error)
+	GetRateHistory(ctx context.Context, code string, from, to time.Time) ([]model.RateHistory, error)
+	GetRatesBefore(ctx context.Context, codes []string, before time.Time) ([]model.RateHistory, error)
+	InsertRateHistory(ctx context.Context, entries []model.RateHistory) (int, error)
+	GetLastRateSync(ctx context.Context) (time.Time, error)
+	SetLastRateSync(ctx context.Context, at time.Time) error
+	ScheduleRateChange(ctx context.Context, change *model.ScheduledRateChange) error
+	ListScheduledRateChanges(ctx context.Context, code string) ([]model.ScheduledRateChange, error)
+	ListDueRateChanges(ctx context.Context, at time.Time) ([]model.ScheduledRateChange, error)
+	CancelScheduledRateChange(ctx context.Context, id uuid.UUID) error
+	ApplyScheduledRateChange(ctx context.Context, change model.ScheduledRateChange) error
+	QuarantineRate(ctx context.Context, rate *model.QuarantinedRate) error
+	ListQuarantinedRates(ctx context.Context, code string) ([]model.QuarantinedRate, error)
+	ApproveQuarantinedRate(ctx context.Context, id, approvedBy uuid.UUID) (*model.QuarantinedRate, error)
+	RejectQuarantinedRate(ctx context.Context, id uuid.UUID) error
 	Close() error
 }
 
@@ -29,3 +55,15 @@ type LogRepository interface {
 	CreatePartition(ctx context.Context, month time.Time) error
 	Close() error
 }
+
+type ProviderStatusRepository interface {
+	SaveStatus(ctx context.Context, status model.ProviderStatus) error
+	ListStatuses(ctx context.Context) ([]model.ProviderStatus, error)
+	Close() error
+}
+
+type LeaderLockRepository interface {
+	TryAcquire(ctx context.Context) (bool, error)
+	Release(ctx context.Context) error
+	Close() error
+}
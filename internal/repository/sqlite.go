@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+//go:embed sqlite_schema.sql
+var sqliteSchema string
+
+func openSQLite(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", sqliteDSN(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to apply database schema: %w", err)
+	}
+	return db, nil
+}
+
+func sqliteDSN(path string) string {
+	return fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate", path)
+}
+
+type sqliteStrings []string
+
+func (s sqliteStrings) Value() (driver.Value, error) {
+	encoded, err := json.Marshal(stringsOrEmpty(s))
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+func (s *sqliteStrings) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(src), (*[]string)(s))
+	case []byte:
+		return json.Unmarshal(src, (*[]string)(s))
+	default:
+		return fmt.Errorf("cannot scan %T into a string list", src)
+	}
+}
+
+func sqliteTime(t time.Time) time.Time {
+	return t.UTC()
+}
+
+func sqliteNullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+func sqlitePlaceholders(offset, count int) string {
+	params := make([]string, count)
+	for i := range params {
+		params[i] = fmt.Sprintf("?%d", offset+i+1)
+	}
+	return "(" + strings.Join(params, ", ") + ")"
+}
@@ -0,0 +1,883 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Lutefd/challenge-bravo/internal/model"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type SQLiteCurrencyRepository struct {
+	db     *sql.DB
+	ownsDB bool
+}
+
+func NewSQLiteCurrencyRepository(path string, db *sql.DB) (*SQLiteCurrencyRepository, error) {
+	ownsDB := db == nil
+	if ownsDB {
+		var err error
+		db, err = openSQLite(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &SQLiteCurrencyRepository{db: db, ownsDB: ownsDB}, nil
+}
+
+func (r *SQLiteCurrencyRepository) GetByCode(ctx context.Context, code string) (*model.Currency, error) {
+	query := `SELECT ` + currencyColumns + ` FROM currencies WHERE code = ?1`
+	currency, err := scanSQLiteCurrency(r.db.QueryRowContext(ctx, query, code))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, model.ErrCurrencyNotFound
+		}
+		return nil, fmt.Errorf("failed to get currency: %w", err)
+	}
+	return currency, nil
+}
+
+func (r *SQLiteCurrencyRepository) GetByCodes(ctx context.Context, codes []string) ([]model.Currency, error) {
+	currencies := []model.Currency{}
+	if len(codes) == 0 {
+		return currencies, nil
+	}
+
+	args := make([]interface{}, len(codes))
+	for i, code := range codes {
+		args[i] = code
+	}
+	query := `SELECT ` + currencyColumns + ` FROM currencies WHERE code IN ` + sqlitePlaceholders(0, len(codes))
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get currencies: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		currency, err := scanSQLiteCurrency(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan currency: %w", err)
+		}
+		currencies = append(currencies, *currency)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate currencies: %w", err)
+	}
+	return currencies, nil
+}
+
+var sqliteCurrencySortColumns = map[string]string{
+	"code":       "code",
+	"rate":       "CAST(rate AS REAL)",
+	"updated_at": "updated_at",
+	"created_at": "created_at",
+}
+
+func (r *SQLiteCurrencyRepository) List(ctx context.Context, opts model.CurrencyListOptions) ([]model.Currency, int, error) {
+	var conditions []string
+	var args []interface{}
+
+	switch opts.Source {
+	case model.CurrencySourceProvider, model.CurrencySourceManual:
+		args = append(args, opts.Source)
+		conditions = append(conditions, fmt.Sprintf("source = ?%d", len(args)))
+	}
+	if !opts.UpdatedSince.IsZero() {
+		args = append(args, sqliteTime(opts.UpdatedSince))
+		conditions = append(conditions, fmt.Sprintf("updated_at >= ?%d", len(args)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM currencies` + where
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count currencies: %w", err)
+	}
+
+	sortColumn, ok := sqliteCurrencySortColumns[opts.SortBy]
+	if !ok {
+		sortColumn = "code"
+	}
+	direction := "ASC"
+	if opts.Descending {
+		direction = "DESC"
+	}
+
+	args = append(args, opts.Limit, opts.Offset)
+	query := fmt.Sprintf(`SELECT %s FROM currencies%s ORDER BY %s %s, code LIMIT ?%d OFFSET ?%d`,
+		currencyColumns, where, sortColumn, direction, len(args)-1, len(args))
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list currencies: %w", err)
+	}
+	defer rows.Close()
+
+	currencies := []model.Currency{}
+	for rows.Next() {
+		currency, err := scanSQLiteCurrency(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan currency: %w", err)
+		}
+		currencies = append(currencies, *currency)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate currencies: %w", err)
+	}
+	return currencies, total, nil
+}
+
+func scanSQLiteCurrency(row rowScanner) (*model.Currency, error) {
+	var currency model.Currency
+	var kind sql.NullString
+	var jumpThreshold decimal.NullDecimal
+	err := row.Scan(
+		&currency.Code, &currency.Rate, &currency.UpdatedAt,
+		&currency.CreatedBy, &currency.UpdatedBy, &currency.CreatedAt,
+		&currency.Name, &currency.Symbol, &currency.MinorUnits,
+		(*sqliteStrings)(&currency.Countries), &kind,
+		&currency.Source, &currency.Locked, (*sqliteStrings)(&currency.Providers),
+		&jumpThreshold,
+	)
+	if err != nil {
+		return nil, err
+	}
+	currency.Kind = model.CurrencyKind(kind.String)
+	if jumpThreshold.Valid {
+		currency.JumpThreshold = &jumpThreshold.Decimal
+	}
+	if currency.Countries == nil {
+		currency.Countries = []string{}
+	}
+	return &currency, nil
+}
+
+func (r *SQLiteCurrencyRepository) Create(ctx context.Context, currency *model.Currency) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO currencies (code, rate, updated_at, created_by, updated_by, created_at, name, symbol, minor_units, countries, kind, source, locked, providers)
+              VALUES ` + sqlitePlaceholders(0, 14)
+	_, err = tx.ExecContext(ctx, query, sqliteCurrencyArgs(currency)...)
+	if err != nil {
+		return fmt.Errorf("failed to create currency: %w", err)
+	}
+
+	if err := insertSQLiteRateHistory(ctx, tx, currency); err != nil {
+		return err
+	}
+
+	if currency.Peg != nil {
+		if err := upsertSQLitePeg(ctx, tx, currency.Code, currency.Peg); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteCurrencyRepository) Update(ctx context.Context, currency *model.Currency) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := updateSQLiteCurrencyRate(ctx, tx, currency); err != nil {
+		return err
+	}
+
+	if currency.Peg != nil {
+		if err := upsertSQLitePeg(ctx, tx, currency.Code, currency.Peg); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteCurrencyRepository) Replace(ctx context.Context, currency *model.Currency) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := updateSQLiteCurrencyRate(ctx, tx, currency); err != nil {
+		return err
+	}
+
+	if err := updateSQLiteCurrencyMetadata(ctx, tx, currency.Code, currency.CurrencyMetadata); err != nil {
+		return err
+	}
+
+	if currency.Peg != nil {
+		if err := upsertSQLitePeg(ctx, tx, currency.Code, currency.Peg); err != nil {
+			return err
+		}
+	} else if _, err := tx.ExecContext(ctx, `DELETE FROM currency_pegs WHERE code = ?1`, currency.Code); err != nil {
+		return fmt.Errorf("failed to remove peg: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteCurrencyRepository) UpsertRates(ctx context.Context, currencies []model.Currency) (int, error) {
+	if len(currencies) == 0 {
+		return 0, nil
+	}
+
+	sorted := make([]model.Currency, len(currencies))
+	copy(sorted, currencies)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Code < sorted[j].Code
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows := make([]string, 0, len(sorted))
+	args := make([]interface{}, 0, len(sorted)*14)
+	for i := range sorted {
+		rows = append(rows, sqlitePlaceholders(len(args), 14))
+		args = append(args, sqliteCurrencyArgs(&sorted[i])...)
+	}
+	query := `INSERT INTO currencies (code, rate, updated_at, created_by, updated_by, created_at, name, symbol, minor_units, countries, kind, source, locked, providers)
+              VALUES ` + strings.Join(rows, ", ") + `
+              ON CONFLICT (code) DO UPDATE SET rate = excluded.rate, updated_at = excluded.updated_at, updated_by = excluded.updated_by,
+              source = excluded.source, locked = currencies.locked OR excluded.locked, providers = excluded.providers
+              WHERE NOT currencies.locked
+              RETURNING code`
+	written, err := queryCodes(ctx, tx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to upsert currencies: %w", err)
+	}
+
+	rows = rows[:0]
+	args = args[:0]
+	for _, currency := range sorted {
+		if !written[currency.Code] {
+			continue
+		}
+		rows = append(rows, sqlitePlaceholders(len(args), 5))
+		args = append(args,
+			currency.Code, currency.Rate, sqliteTime(currency.UpdatedAt), currency.UpdatedBy, sqliteStrings(currency.Providers),
+		)
+	}
+	if len(rows) > 0 {
+		query = `INSERT INTO currency_rate_history (code, rate, recorded_at, updated_by, providers) VALUES ` + strings.Join(rows, ", ")
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return 0, fmt.Errorf("failed to record rate history: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return len(written), nil
+}
+
+func (r *SQLiteCurrencyRepository) UpdatePeggedRates(ctx context.Context, currencies []model.Currency) error {
+	if len(currencies) == 0 {
+		return nil
+	}
+
+	sorted := make([]model.Currency, len(currencies))
+	copy(sorted, currencies)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Code < sorted[j].Code
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE currencies SET rate = ?2, updated_at = ?3, updated_by = ?4
+              WHERE code = ?1 AND EXISTS (SELECT 1 FROM currency_pegs WHERE currency_pegs.code = currencies.code)`
+	for i := range sorted {
+		currency := &sorted[i]
+		result, err := tx.ExecContext(ctx, query, currency.Code, currency.Rate, sqliteTime(currency.UpdatedAt), currency.UpdatedBy)
+		if err != nil {
+			return fmt.Errorf("failed to update pegged currency: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			continue
+		}
+		if err := insertSQLiteRateHistory(ctx, tx, currency); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteCurrencyRepository) UpdateMetadata(ctx context.Context, code string, metadata model.CurrencyMetadata) error {
+	return updateSQLiteCurrencyMetadata(ctx, r.db, code, metadata)
+}
+
+func (r *SQLiteCurrencyRepository) SetLocked(ctx context.Context, code string, locked bool) error {
+	query := `UPDATE currencies SET locked = ?2 WHERE code = ?1`
+	result, err := r.db.ExecContext(ctx, query, code, locked)
+	if err != nil {
+		return fmt.Errorf("failed to update currency lock: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return model.ErrCurrencyNotFound
+	}
+	return nil
+}
+
+func (r *SQLiteCurrencyRepository) SetJumpThreshold(ctx context.Context, code string, threshold *decimal.Decimal) error {
+	query := `UPDATE currencies SET jump_threshold = ?2 WHERE code = ?1`
+	result, err := r.db.ExecContext(ctx, query, code, decimalOrNull(threshold))
+	if err != nil {
+		return fmt.Errorf("failed to update jump threshold: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return model.ErrCurrencyNotFound
+	}
+	return nil
+}
+
+func (r *SQLiteCurrencyRepository) Delete(ctx context.Context, code string) error {
+	query := `DELETE FROM currencies WHERE code = ?1`
+	_, err := r.db.ExecContext(ctx, query, code)
+	if err != nil {
+		return fmt.Errorf("failed to delete currency: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteCurrencyRepository) GetPeg(ctx context.Context, code string) (*model.CurrencyPeg, error) {
+	query := `SELECT code, anchor, ratio FROM currency_pegs WHERE code = ?1`
+	var peg model.CurrencyPeg
+	err := r.db.QueryRowContext(ctx, query, code).Scan(&peg.Code, &peg.Anchor, &peg.Ratio)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, model.ErrPegNotFound
+		}
+		return nil, fmt.Errorf("failed to get peg: %w", err)
+	}
+	return &peg, nil
+}
+
+func (r *SQLiteCurrencyRepository) ListPegs(ctx context.Context) ([]model.CurrencyPeg, error) {
+	query := `SELECT code, anchor, ratio FROM currency_pegs ORDER BY code`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pegs: %w", err)
+	}
+	defer rows.Close()
+
+	pegs := []model.CurrencyPeg{}
+	for rows.Next() {
+		var peg model.CurrencyPeg
+		if err := rows.Scan(&peg.Code, &peg.Anchor, &peg.Ratio); err != nil {
+			return nil, fmt.Errorf("failed to scan peg: %w", err)
+		}
+		pegs = append(pegs, peg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate pegs: %w", err)
+	}
+	return pegs, nil
+}
+
+func (r *SQLiteCurrencyRepository) GetRateHistory(ctx context.Context, code string, from, to time.Time) ([]model.RateHistory, error) {
+	query := `SELECT code, rate, recorded_at, updated_by, providers FROM currency_rate_history
+              WHERE code = ?1 AND recorded_at >= ?2 AND recorded_at < ?3 ORDER BY recorded_at`
+	rows, err := r.db.QueryContext(ctx, query, code, sqliteTime(from), sqliteTime(to))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rate history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []model.RateHistory{}
+	for rows.Next() {
+		var entry model.RateHistory
+		if err := rows.Scan(&entry.Code, &entry.Rate, &entry.RecordedAt, &entry.UpdatedBy, (*sqliteStrings)(&entry.Providers)); err != nil {
+			return nil, fmt.Errorf("failed to scan rate history: %w", err)
+		}
+		history = append(history, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rate history: %w", err)
+	}
+	return history, nil
+}
+
+func (r *SQLiteCurrencyRepository) GetRatesBefore(ctx context.Context, codes []string, before time.Time) ([]model.RateHistory, error) {
+	entries := []model.RateHistory{}
+	if len(codes) == 0 {
+		return entries, nil
+	}
+
+	args := make([]interface{}, 0, len(codes)+1)
+	for _, code := range codes {
+		args = append(args, code)
+	}
+	args = append(args, sqliteTime(before))
+	query := `SELECT code, rate, recorded_at, updated_by, providers FROM (
+                  SELECT code, rate, recorded_at, updated_by, providers,
+                  ROW_NUMBER() OVER (PARTITION BY code ORDER BY recorded_at DESC) AS position
+                  FROM currency_rate_history
+                  WHERE code IN ` + sqlitePlaceholders(0, len(codes)) + fmt.Sprintf(` AND recorded_at < ?%d`, len(codes)+1) + `
+              ) WHERE position = 1 ORDER BY code`
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get historical rates: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry model.RateHistory
+		if err := rows.Scan(&entry.Code, &entry.Rate, &entry.RecordedAt, &entry.UpdatedBy, (*sqliteStrings)(&entry.Providers)); err != nil {
+			return nil, fmt.Errorf("failed to scan historical rate: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate historical rates: %w", err)
+	}
+	return entries, nil
+}
+
+func (r *SQLiteCurrencyRepository) InsertRateHistory(ctx context.Context, entries []model.RateHistory) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO currency_rate_history (code, rate, recorded_at, updated_by, providers)
+              SELECT ?1, ?2, ?3, ?4, ?5
+              WHERE NOT EXISTS (SELECT 1 FROM currency_rate_history WHERE code = ?1 AND recorded_at = ?3)`
+	inserted := 0
+	for _, entry := range entries {
+		result, err := tx.ExecContext(ctx, query,
+			entry.Code, entry.Rate, sqliteTime(entry.RecordedAt), entry.UpdatedBy, sqliteStrings(entry.Providers),
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert rate history for %s: %w", entry.Code, err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		inserted += int(rowsAffected)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return inserted, nil
+}
+
+func (r *SQLiteCurrencyRepository) GetLastRateSync(ctx context.Context) (time.Time, error) {
+	var lastSyncedAt time.Time
+	err := r.db.QueryRowContext(ctx, `SELECT last_synced_at FROM rate_sync_state`).Scan(&lastSyncedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("failed to get last rate sync: %w", err)
+	}
+	return lastSyncedAt, nil
+}
+
+func (r *SQLiteCurrencyRepository) SetLastRateSync(ctx context.Context, at time.Time) error {
+	query := `INSERT INTO rate_sync_state (id, last_synced_at, updated_at) VALUES (TRUE, ?1, ?2)
+              ON CONFLICT (id) DO UPDATE SET last_synced_at = MAX(rate_sync_state.last_synced_at, excluded.last_synced_at),
+              updated_at = excluded.updated_at`
+	if _, err := r.db.ExecContext(ctx, query, sqliteTime(at), time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to set last rate sync: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteCurrencyRepository) ScheduleRateChange(ctx context.Context, change *model.ScheduledRateChange) error {
+	query := `INSERT INTO scheduled_rate_changes (` + scheduledRateChangeColumns + `) VALUES ` + sqlitePlaceholders(0, 6)
+	_, err := r.db.ExecContext(ctx, query,
+		change.ID, change.Code, change.Rate, sqliteTime(change.EffectiveAt), change.CreatedBy, sqliteTime(change.CreatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to schedule rate change: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteCurrencyRepository) ListScheduledRateChanges(ctx context.Context, code string) ([]model.ScheduledRateChange, error) {
+	if code == "" {
+		query := `SELECT ` + scheduledRateChangeColumns + ` FROM scheduled_rate_changes ORDER BY effective_at, code`
+		return r.queryScheduledRateChanges(ctx, query)
+	}
+	query := `SELECT ` + scheduledRateChangeColumns + ` FROM scheduled_rate_changes WHERE code = ?1 ORDER BY effective_at`
+	return r.queryScheduledRateChanges(ctx, query, code)
+}
+
+func (r *SQLiteCurrencyRepository) ListDueRateChanges(ctx context.Context, at time.Time) ([]model.ScheduledRateChange, error) {
+	query := `SELECT ` + scheduledRateChangeColumns + ` FROM scheduled_rate_changes
+              WHERE effective_at <= ?1 ORDER BY effective_at, code`
+	return r.queryScheduledRateChanges(ctx, query, sqliteTime(at))
+}
+
+func (r *SQLiteCurrencyRepository) queryScheduledRateChanges(ctx context.Context, query string, args ...interface{}) ([]model.ScheduledRateChange, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled rate changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []model.ScheduledRateChange{}
+	for rows.Next() {
+		var change model.ScheduledRateChange
+		if err := rows.Scan(
+			&change.ID, &change.Code, &change.Rate, &change.EffectiveAt, &change.CreatedBy, &change.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan scheduled rate change: %w", err)
+		}
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate scheduled rate changes: %w", err)
+	}
+	return changes, nil
+}
+
+func (r *SQLiteCurrencyRepository) CancelScheduledRateChange(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM scheduled_rate_changes WHERE id = ?1`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to cancel scheduled rate change: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return model.ErrScheduledChangeNotFound
+	}
+	return nil
+}
+
+func (r *SQLiteCurrencyRepository) ApplyScheduledRateChange(ctx context.Context, change model.ScheduledRateChange) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM scheduled_rate_changes WHERE id = ?1`, change.ID)
+	if err != nil {
+		return fmt.Errorf("failed to remove scheduled rate change: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return model.ErrScheduledChangeNotFound
+	}
+
+	currency := &model.Currency{
+		Code:      change.Code,
+		Rate:      change.Rate,
+		UpdatedAt: change.EffectiveAt,
+		UpdatedBy: change.CreatedBy,
+	}
+	query := `UPDATE currencies SET rate = ?2, updated_at = ?3, updated_by = ?4, source = ?5, locked = TRUE, providers = '[]' WHERE code = ?1`
+	result, err = tx.ExecContext(ctx, query,
+		currency.Code, currency.Rate, sqliteTime(currency.UpdatedAt), currency.UpdatedBy, model.CurrencySourceManual,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update currency: %w", err)
+	}
+	rowsAffected, err = result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return model.ErrCurrencyNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM currency_pegs WHERE code = ?1`, currency.Code); err != nil {
+		return fmt.Errorf("failed to remove peg: %w", err)
+	}
+
+	if err := insertSQLiteRateHistory(ctx, tx, currency); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteCurrencyRepository) QuarantineRate(ctx context.Context, rate *model.QuarantinedRate) error {
+	query := `INSERT INTO quarantined_rates (` + quarantinedRateColumns + `)
+              VALUES ` + sqlitePlaceholders(0, 8) + `
+              ON CONFLICT (code) DO UPDATE SET id = excluded.id, previous_rate = excluded.previous_rate, rate = excluded.rate,
+              change = excluded.change, providers = excluded.providers, recorded_at = excluded.recorded_at, created_at = excluded.created_at`
+	_, err := r.db.ExecContext(ctx, query,
+		rate.ID, rate.Code, rate.PreviousRate, rate.Rate, rate.Change,
+		sqliteStrings(rate.Providers), sqliteTime(rate.RecordedAt), sqliteTime(rate.CreatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to quarantine rate: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteCurrencyRepository) ListQuarantinedRates(ctx context.Context, code string) ([]model.QuarantinedRate, error) {
+	if code == "" {
+		query := `SELECT ` + quarantinedRateColumns + ` FROM quarantined_rates ORDER BY created_at, code`
+		return r.queryQuarantinedRates(ctx, query)
+	}
+	query := `SELECT ` + quarantinedRateColumns + ` FROM quarantined_rates WHERE code = ?1 ORDER BY created_at`
+	return r.queryQuarantinedRates(ctx, query, code)
+}
+
+func (r *SQLiteCurrencyRepository) queryQuarantinedRates(ctx context.Context, query string, args ...interface{}) ([]model.QuarantinedRate, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quarantined rates: %w", err)
+	}
+	defer rows.Close()
+
+	rates := []model.QuarantinedRate{}
+	for rows.Next() {
+		rate, err := scanSQLiteQuarantinedRate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan quarantined rate: %w", err)
+		}
+		rates = append(rates, *rate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate quarantined rates: %w", err)
+	}
+	return rates, nil
+}
+
+func scanSQLiteQuarantinedRate(row rowScanner) (*model.QuarantinedRate, error) {
+	var rate model.QuarantinedRate
+	err := row.Scan(
+		&rate.ID, &rate.Code, &rate.PreviousRate, &rate.Rate, &rate.Change,
+		(*sqliteStrings)(&rate.Providers), &rate.RecordedAt, &rate.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func (r *SQLiteCurrencyRepository) ApproveQuarantinedRate(ctx context.Context, id, approvedBy uuid.UUID) (*model.QuarantinedRate, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `SELECT ` + quarantinedRateColumns + ` FROM quarantined_rates WHERE id = ?1`
+	rate, err := scanSQLiteQuarantinedRate(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, model.ErrQuarantinedRateNotFound
+		}
+		return nil, fmt.Errorf("failed to remove quarantined rate: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM quarantined_rates WHERE id = ?1`, id); err != nil {
+		return nil, fmt.Errorf("failed to remove quarantined rate: %w", err)
+	}
+
+	var locked bool
+	if err := tx.QueryRowContext(ctx, `SELECT locked FROM currencies WHERE code = ?1`, rate.Code).Scan(&locked); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, model.ErrCurrencyNotFound
+		}
+		return nil, fmt.Errorf("failed to get currency: %w", err)
+	}
+	if locked {
+		return nil, fmt.Errorf("%w: %s", model.ErrCurrencyLocked, rate.Code)
+	}
+
+	currency := &model.Currency{
+		Code:      rate.Code,
+		Rate:      rate.Rate,
+		UpdatedAt: rate.RecordedAt,
+		UpdatedBy: approvedBy,
+		Providers: rate.Providers,
+	}
+	query = `UPDATE currencies SET rate = ?2, updated_at = ?3, updated_by = ?4, source = ?5, providers = ?6 WHERE code = ?1`
+	result, err := tx.ExecContext(ctx, query,
+		currency.Code, currency.Rate, sqliteTime(currency.UpdatedAt), currency.UpdatedBy,
+		model.CurrencySourceProvider, sqliteStrings(currency.Providers),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update currency: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, model.ErrCurrencyNotFound
+	}
+
+	if err := insertSQLiteRateHistory(ctx, tx, currency); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return rate, nil
+}
+
+func (r *SQLiteCurrencyRepository) RejectQuarantinedRate(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM quarantined_rates WHERE id = ?1`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to reject quarantined rate: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return model.ErrQuarantinedRateNotFound
+	}
+	return nil
+}
+
+func sqliteCurrencyArgs(currency *model.Currency) []interface{} {
+	return []interface{}{
+		currency.Code, currency.Rate, sqliteTime(currency.UpdatedAt),
+		currency.CreatedBy, currency.UpdatedBy, sqliteTime(currency.CreatedAt),
+		currency.Name, currency.Symbol, currency.MinorUnits,
+		sqliteStrings(currency.Countries), kindOrNull(currency.Kind),
+		sourceOrDefault(currency.Source), currency.Locked, sqliteStrings(currency.Providers),
+	}
+}
+
+func insertSQLiteRateHistory(ctx context.Context, tx *sql.Tx, currency *model.Currency) error {
+	query := `INSERT INTO currency_rate_history (code, rate, recorded_at, updated_by, providers) VALUES (?1, ?2, ?3, ?4, ?5)`
+	_, err := tx.ExecContext(ctx, query,
+		currency.Code, currency.Rate, sqliteTime(currency.UpdatedAt), currency.UpdatedBy, sqliteStrings(currency.Providers),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record rate history: %w", err)
+	}
+	return nil
+}
+
+func updateSQLiteCurrencyRate(ctx context.Context, tx *sql.Tx, currency *model.Currency) error {
+	query := `UPDATE currencies SET rate = ?2, updated_at = ?3, updated_by = ?4, source = ?5, locked = locked OR ?6, providers = ?7 WHERE code = ?1`
+	result, err := tx.ExecContext(ctx, query,
+		currency.Code, currency.Rate, sqliteTime(currency.UpdatedAt), currency.UpdatedBy,
+		sourceOrDefault(currency.Source), currency.Locked, sqliteStrings(currency.Providers),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update currency: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return model.ErrCurrencyNotFound
+	}
+
+	return insertSQLiteRateHistory(ctx, tx, currency)
+}
+
+func updateSQLiteCurrencyMetadata(ctx context.Context, db execer, code string, metadata model.CurrencyMetadata) error {
+	query := `UPDATE currencies SET name = ?2, symbol = ?3, minor_units = ?4, countries = ?5, kind = ?6 WHERE code = ?1`
+	result, err := db.ExecContext(ctx, query,
+		code, metadata.Name, metadata.Symbol, metadata.MinorUnits,
+		sqliteStrings(metadata.Countries), kindOrNull(metadata.Kind),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update currency metadata: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return model.ErrCurrencyNotFound
+	}
+
+	return nil
+}
+
+func upsertSQLitePeg(ctx context.Context, tx *sql.Tx, code string, peg *model.CurrencyPeg) error {
+	query := `INSERT INTO currency_pegs (code, anchor, ratio) VALUES (?1, ?2, ?3)
+              ON CONFLICT (code) DO UPDATE SET anchor = excluded.anchor, ratio = excluded.ratio`
+	_, err := tx.ExecContext(ctx, query, code, peg.Anchor, peg.Ratio)
+	if err != nil {
+		return fmt.Errorf("failed to save peg: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteCurrencyRepository) Close() error {
+	if !r.ownsDB {
+		return nil
+	}
+	return r.db.Close()
+}
@@ -0,0 +1,350 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Lutefd/challenge-bravo/internal/model"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSQLiteCurrencyRepository(t *testing.T) *SQLiteCurrencyRepository {
+	repo, err := NewSQLiteCurrencyRepository(filepath.Join(t.TempDir(), "test.db"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+func sqliteCurrency(code, rate string, updatedAt time.Time) *model.Currency {
+	return &model.Currency{
+		Code:      code,
+		Rate:      decimal.RequireFromString(rate),
+		UpdatedAt: updatedAt,
+		CreatedBy: uuid.New(),
+		UpdatedBy: uuid.New(),
+		CreatedAt: updatedAt,
+		Providers: []string{"ecb"},
+		CurrencyMetadata: model.CurrencyMetadata{
+			Name:       code,
+			MinorUnits: model.MinorUnits(2),
+			Countries:  []string{"XX"},
+			Kind:       model.CurrencyKindFiat,
+		},
+	}
+}
+
+func TestSQLiteCurrencyRepository_CreateAndGet(t *testing.T) {
+	repo := newSQLiteCurrencyRepository(t)
+	ctx := context.Background()
+	now := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+
+	usd := sqliteCurrency("USD", "1", now)
+	require.NoError(t, repo.Create(ctx, usd))
+	brl := sqliteCurrency("BRL", "5.123456789012345678", now)
+	brl.Peg = &model.CurrencyPeg{Anchor: "USD", Ratio: decimal.RequireFromString("5")}
+	require.NoError(t, repo.Create(ctx, brl))
+
+	got, err := repo.GetByCode(ctx, "BRL")
+	require.NoError(t, err)
+	assert.Equal(t, "5.123456789012345678", got.Rate.String())
+	assert.True(t, now.Equal(got.UpdatedAt))
+	assert.Equal(t, brl.CreatedBy, got.CreatedBy)
+	assert.Equal(t, []string{"XX"}, got.Countries)
+	assert.Equal(t, []string{"ecb"}, got.Providers)
+	assert.Equal(t, model.CurrencySourceProvider, got.Source)
+	assert.Nil(t, got.JumpThreshold)
+
+	peg, err := repo.GetPeg(ctx, "BRL")
+	require.NoError(t, err)
+	assert.Equal(t, "USD", peg.Anchor)
+	assert.Equal(t, "5", peg.Ratio.String())
+
+	_, err = repo.GetByCode(ctx, "EUR")
+	assert.ErrorIs(t, err, model.ErrCurrencyNotFound)
+
+	currencies, err := repo.GetByCodes(ctx, []string{"USD", "BRL", "EUR"})
+	require.NoError(t, err)
+	assert.Len(t, currencies, 2)
+
+	require.NoError(t, repo.Delete(ctx, "BRL"))
+	_, err = repo.GetPeg(ctx, "BRL")
+	assert.ErrorIs(t, err, model.ErrPegNotFound)
+}
+
+func TestSQLiteCurrencyRepository_Replace(t *testing.T) {
+	repo := newSQLiteCurrencyRepository(t)
+	ctx := context.Background()
+	now := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, repo.Create(ctx, sqliteCurrency("USD", "1", now)))
+	hurb := sqliteCurrency("HURB", "2", now)
+	hurb.Peg = &model.CurrencyPeg{Anchor: "USD", Ratio: decimal.RequireFromString("0.5")}
+	require.NoError(t, repo.Create(ctx, hurb))
+
+	hurb.Rate = decimal.RequireFromString("3")
+	hurb.UpdatedAt = now.Add(time.Hour)
+	hurb.Source = model.CurrencySourceManual
+	hurb.Locked = true
+	hurb.Peg = nil
+	hurb.Name = "Hurb Coin"
+	hurb.Kind = model.CurrencyKindFictional
+	require.NoError(t, repo.Replace(ctx, hurb))
+
+	got, err := repo.GetByCode(ctx, "HURB")
+	require.NoError(t, err)
+	assert.Equal(t, "3", got.Rate.String())
+	assert.Equal(t, "Hurb Coin", got.Name)
+	assert.Equal(t, model.CurrencyKindFictional, got.Kind)
+	assert.True(t, got.Locked)
+	_, err = repo.GetPeg(ctx, "HURB")
+	assert.ErrorIs(t, err, model.ErrPegNotFound)
+
+	hurb.Code = "GOLD"
+	assert.ErrorIs(t, repo.Replace(ctx, hurb), model.ErrCurrencyNotFound)
+}
+
+func TestSQLiteCurrencyRepository_List(t *testing.T) {
+	repo := newSQLiteCurrencyRepository(t)
+	ctx := context.Background()
+	now := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+
+	_, err := repo.UpsertRates(ctx, []model.Currency{
+		*sqliteCurrency("USD", "1", now),
+		*sqliteCurrency("EUR", "0.9", now),
+		*sqliteCurrency("JPY", "150", now.Add(time.Hour)),
+	})
+	require.NoError(t, err)
+
+	currencies, total, err := repo.List(ctx, model.CurrencyListOptions{SortBy: "rate", Descending: true, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, currencies, 2)
+	assert.Equal(t, "JPY", currencies[0].Code)
+	assert.Equal(t, "USD", currencies[1].Code)
+
+	currencies, total, err = repo.List(ctx, model.CurrencyListOptions{UpdatedSince: now.Add(time.Minute), Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, "JPY", currencies[0].Code)
+}
+
+func TestSQLiteCurrencyRepository_UpsertRatesKeepsLock(t *testing.T) {
+	repo := newSQLiteCurrencyRepository(t)
+	ctx := context.Background()
+	now := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+
+	written, err := repo.UpsertRates(ctx, []model.Currency{*sqliteCurrency("EUR", "0.9", now)})
+	require.NoError(t, err)
+	assert.Equal(t, 1, written)
+	require.NoError(t, repo.SetLocked(ctx, "EUR", true))
+	written, err = repo.UpsertRates(ctx, []model.Currency{
+		*sqliteCurrency("EUR", "0.91", now.Add(time.Hour)),
+		*sqliteCurrency("GBP", "0.8", now.Add(time.Hour)),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, written)
+
+	got, err := repo.GetByCode(ctx, "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, "0.9", got.Rate.String())
+	assert.True(t, got.Locked)
+
+	history, err := repo.GetRateHistory(ctx, "EUR", now, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, "0.9", history[0].Rate.String())
+
+	history, err = repo.GetRateHistory(ctx, "GBP", now, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, "0.8", history[0].Rate.String())
+
+	assert.ErrorIs(t, repo.SetLocked(ctx, "CHF", true), model.ErrCurrencyNotFound)
+}
+
+func TestSQLiteCurrencyRepository_UpdatePeggedRates(t *testing.T) {
+	repo := newSQLiteCurrencyRepository(t)
+	ctx := context.Background()
+	now := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+
+	_, err := repo.UpsertRates(ctx, []model.Currency{*sqliteCurrency("EUR", "0.9", now)})
+	require.NoError(t, err)
+	hurb := sqliteCurrency("HURB", "1.8", now)
+	hurb.Source = model.CurrencySourceManual
+	hurb.Locked = true
+	hurb.Peg = &model.CurrencyPeg{Anchor: "EUR", Ratio: decimal.RequireFromString("0.5")}
+	require.NoError(t, repo.Create(ctx, hurb))
+	gold := sqliteCurrency("GOLD", "0.0005", now)
+	gold.Locked = true
+	require.NoError(t, repo.Create(ctx, gold))
+
+	later := now.Add(time.Hour)
+	_, err = repo.UpsertRates(ctx, []model.Currency{*sqliteCurrency("EUR", "0.95", later)})
+	require.NoError(t, err)
+	require.NoError(t, repo.UpdatePeggedRates(ctx, []model.Currency{
+		{Code: "HURB", Rate: decimal.RequireFromString("1.9"), UpdatedAt: later},
+		{Code: "GOLD", Rate: decimal.RequireFromString("0.0006"), UpdatedAt: later},
+	}))
+
+	got, err := repo.GetByCode(ctx, "HURB")
+	require.NoError(t, err)
+	assert.Equal(t, "1.9", got.Rate.String())
+	assert.True(t, later.Equal(got.UpdatedAt))
+	assert.True(t, got.Locked)
+	assert.Equal(t, model.CurrencySourceManual, got.Source)
+
+	history, err := repo.GetRateHistory(ctx, "HURB", later, later.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, "1.9", history[0].Rate.String())
+
+	got, err = repo.GetByCode(ctx, "GOLD")
+	require.NoError(t, err)
+	assert.Equal(t, "0.0005", got.Rate.String())
+}
+
+func TestSQLiteCurrencyRepository_RateHistory(t *testing.T) {
+	repo := newSQLiteCurrencyRepository(t)
+	ctx := context.Background()
+	day := time.Date(2024, 8, 9, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, repo.Create(ctx, sqliteCurrency("EUR", "0.9", day.AddDate(0, 0, 2))))
+	entries := []model.RateHistory{
+		{Code: "EUR", Rate: decimal.RequireFromString("0.91"), RecordedAt: day},
+		{Code: "EUR", Rate: decimal.RequireFromString("0.92"), RecordedAt: day.AddDate(0, 0, 1)},
+	}
+	inserted, err := repo.InsertRateHistory(ctx, entries)
+	require.NoError(t, err)
+	assert.Equal(t, 2, inserted)
+
+	inserted, err = repo.InsertRateHistory(ctx, entries)
+	require.NoError(t, err)
+	assert.Equal(t, 0, inserted)
+
+	require.NoError(t, repo.Create(ctx, sqliteCurrency("GBP", "0.78", day.AddDate(0, 0, 2))))
+	_, err = repo.InsertRateHistory(ctx, []model.RateHistory{
+		{Code: "GBP", Rate: decimal.RequireFromString("0.77"), RecordedAt: day.Add(time.Hour)},
+	})
+	require.NoError(t, err)
+
+	before, err := repo.GetRatesBefore(ctx, []string{"EUR", "GBP", "JPY"}, day.Add(36*time.Hour).In(time.FixedZone("BRT", -3*3600)))
+	require.NoError(t, err)
+	require.Len(t, before, 2)
+	assert.Equal(t, "EUR", before[0].Code)
+	assert.Equal(t, "0.92", before[0].Rate.String())
+	assert.Equal(t, "GBP", before[1].Code)
+	assert.Equal(t, "0.77", before[1].Rate.String())
+
+	before, err = repo.GetRatesBefore(ctx, []string{"EUR"}, day)
+	require.NoError(t, err)
+	assert.Empty(t, before)
+
+	lastSync, err := repo.GetLastRateSync(ctx)
+	require.NoError(t, err)
+	assert.True(t, lastSync.IsZero())
+
+	require.NoError(t, repo.SetLastRateSync(ctx, day.AddDate(0, 0, 1)))
+	require.NoError(t, repo.SetLastRateSync(ctx, day))
+	lastSync, err = repo.GetLastRateSync(ctx)
+	require.NoError(t, err)
+	assert.True(t, day.AddDate(0, 0, 1).Equal(lastSync))
+}
+
+func TestSQLiteCurrencyRepository_ScheduledRateChanges(t *testing.T) {
+	repo := newSQLiteCurrencyRepository(t)
+	ctx := context.Background()
+	now := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, repo.Create(ctx, sqliteCurrency("USD", "1", now)))
+	eur := sqliteCurrency("EUR", "0.9", now)
+	eur.Peg = &model.CurrencyPeg{Anchor: "USD", Ratio: decimal.RequireFromString("1.1")}
+	require.NoError(t, repo.Create(ctx, eur))
+
+	change := model.ScheduledRateChange{
+		ID:          uuid.New(),
+		Code:        "EUR",
+		Rate:        decimal.RequireFromString("0.95"),
+		EffectiveAt: now.Add(time.Hour),
+		CreatedBy:   uuid.New(),
+		CreatedAt:   now,
+	}
+	require.NoError(t, repo.ScheduleRateChange(ctx, &change))
+
+	due, err := repo.ListDueRateChanges(ctx, now)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	due, err = repo.ListDueRateChanges(ctx, now.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, change.ID, due[0].ID)
+
+	require.NoError(t, repo.ApplyScheduledRateChange(ctx, due[0]))
+	assert.ErrorIs(t, repo.ApplyScheduledRateChange(ctx, due[0]), model.ErrScheduledChangeNotFound)
+
+	got, err := repo.GetByCode(ctx, "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, "0.95", got.Rate.String())
+	assert.Equal(t, model.CurrencySourceManual, got.Source)
+	assert.True(t, got.Locked)
+	assert.Empty(t, got.Providers)
+
+	_, err = repo.GetPeg(ctx, "EUR")
+	assert.ErrorIs(t, err, model.ErrPegNotFound)
+}
+
+func TestSQLiteCurrencyRepository_QuarantinedRates(t *testing.T) {
+	repo := newSQLiteCurrencyRepository(t)
+	ctx := context.Background()
+	now := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, repo.Create(ctx, sqliteCurrency("EUR", "0.9", now)))
+	quarantined := model.QuarantinedRate{
+		ID:           uuid.New(),
+		Code:         "EUR",
+		PreviousRate: decimal.RequireFromString("0.9"),
+		Rate:         decimal.RequireFromString("1.8"),
+		Change:       decimal.RequireFromString("1"),
+		Providers:    []string{"ecb", "frankfurter"},
+		RecordedAt:   now.Add(time.Hour),
+		CreatedAt:    now.Add(time.Hour),
+	}
+	require.NoError(t, repo.QuarantineRate(ctx, &quarantined))
+
+	rates, err := repo.ListQuarantinedRates(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, rates, 1)
+	assert.Equal(t, []string{"ecb", "frankfurter"}, rates[0].Providers)
+
+	approvedBy := uuid.New()
+	approved, err := repo.ApproveQuarantinedRate(ctx, quarantined.ID, approvedBy)
+	require.NoError(t, err)
+	assert.Equal(t, "1.8", approved.Rate.String())
+
+	got, err := repo.GetByCode(ctx, "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, "1.8", got.Rate.String())
+	assert.Equal(t, approvedBy, got.UpdatedBy)
+
+	_, err = repo.ApproveQuarantinedRate(ctx, quarantined.ID, approvedBy)
+	assert.ErrorIs(t, err, model.ErrQuarantinedRateNotFound)
+	assert.ErrorIs(t, repo.RejectQuarantinedRate(ctx, quarantined.ID), model.ErrQuarantinedRateNotFound)
+
+	quarantined.ID = uuid.New()
+	require.NoError(t, repo.QuarantineRate(ctx, &quarantined))
+	require.NoError(t, repo.SetLocked(ctx, "EUR", true))
+	_, err = repo.ApproveQuarantinedRate(ctx, quarantined.ID, approvedBy)
+	assert.ErrorIs(t, err, model.ErrCurrencyLocked)
+
+	got, err = repo.GetByCode(ctx, "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, "1.8", got.Rate.String())
+	rates, err = repo.ListQuarantinedRates(ctx, "EUR")
+	require.NoError(t, err)
+	assert.Len(t, rates, 1)
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SQLiteLeaderLockRepository struct {
+	db     *sql.DB
+	ownsDB bool
+	holder string
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+func NewSQLiteLeaderLockRepository(path string, db *sql.DB, ttl time.Duration) (*SQLiteLeaderLockRepository, error) {
+	ownsDB := db == nil
+	if ownsDB {
+		var err error
+		db, err = openSQLite(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &SQLiteLeaderLockRepository{db: db, ownsDB: ownsDB, holder: uuid.NewString(), ttl: ttl, now: time.Now}, nil
+}
+
+func (r *SQLiteLeaderLockRepository) TryAcquire(ctx context.Context) (bool, error) {
+	now := r.now().UTC()
+	query := `INSERT INTO leader_lock (id, holder, expires_at) VALUES (TRUE, ?1, ?2)
+              ON CONFLICT (id) DO UPDATE SET holder = excluded.holder, expires_at = excluded.expires_at
+              WHERE leader_lock.holder = excluded.holder OR leader_lock.expires_at < ?3`
+	result, err := r.db.ExecContext(ctx, query, r.holder, now.Add(r.ttl), now)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire leader lock: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected == 1, nil
+}
+
+func (r *SQLiteLeaderLockRepository) Release(ctx context.Context) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM leader_lock WHERE holder = ?1`, r.holder); err != nil {
+		return fmt.Errorf("failed to release leader lock: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteLeaderLockRepository) Close() error {
+	err := r.Release(context.Background())
+	if r.ownsDB {
+		if closeErr := r.db.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
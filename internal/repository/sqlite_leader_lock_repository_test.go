@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteLeaderLockRepository(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	ctx := context.Background()
+
+	first, err := NewSQLiteLeaderLockRepository(path, nil, time.Minute)
+	require.NoError(t, err)
+	defer first.Close()
+	second, err := NewSQLiteLeaderLockRepository(path, nil, time.Minute)
+	require.NoError(t, err)
+	defer second.Close()
+
+	acquired, err := first.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	acquired, err = first.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, acquired, "holder renews its own lease")
+
+	acquired, err = second.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	second.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	acquired, err = second.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, acquired, "expired lease is taken over")
+
+	acquired, err = first.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	require.NoError(t, second.Release(ctx))
+	acquired, err = first.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}
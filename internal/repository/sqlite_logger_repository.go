@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Lutefd/challenge-bravo/internal/model"
+)
+
+type SQLiteLogRepository struct {
+	db     *sql.DB
+	ownsDB bool
+}
+
+func NewSQLiteLogRepository(path string, db *sql.DB) (*SQLiteLogRepository, error) {
+	ownsDB := db == nil
+	if ownsDB {
+		var err error
+		db, err = openSQLite(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &SQLiteLogRepository{db: db, ownsDB: ownsDB}, nil
+}
+
+func (r *SQLiteLogRepository) SaveLog(ctx context.Context, log model.Log) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO logs (id, level, message, timestamp, source)
+		VALUES (?1, ?2, ?3, ?4, ?5)
+	`, log.ID, log.Level, log.Message, sqliteTime(log.Timestamp), log.Source)
+	if err != nil {
+		return fmt.Errorf("failed to save log: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteLogRepository) CreatePartition(ctx context.Context, month time.Time) error {
+	return nil
+}
+
+func (r *SQLiteLogRepository) Close() error {
+	if !r.ownsDB {
+		return nil
+	}
+	return r.db.Close()
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Lutefd/challenge-bravo/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteProviderStatusRepository(t *testing.T) {
+	repo, err := NewSQLiteProviderStatusRepository(filepath.Join(t.TempDir(), "test.db"), nil)
+	require.NoError(t, err)
+	defer repo.Close()
+	ctx := context.Background()
+
+	openedAt := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.SaveStatus(ctx, model.ProviderStatus{
+		Provider: "frankfurter", State: model.CircuitStateClosed, UpdatedAt: openedAt,
+	}))
+	require.NoError(t, repo.SaveStatus(ctx, model.ProviderStatus{
+		Provider: "ecb", State: model.CircuitStateHalfOpen, ConsecutiveFailures: 1, UpdatedAt: openedAt,
+	}))
+	require.NoError(t, repo.SaveStatus(ctx, model.ProviderStatus{
+		Provider: "ecb", State: model.CircuitStateOpen, ConsecutiveFailures: 3, OpenedAt: &openedAt, UpdatedAt: openedAt,
+	}))
+
+	statuses, err := repo.ListStatuses(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.Equal(t, "ecb", statuses[0].Provider)
+	assert.Equal(t, model.CircuitStateOpen, statuses[0].State)
+	assert.Equal(t, 3, statuses[0].ConsecutiveFailures)
+	require.NotNil(t, statuses[0].OpenedAt)
+	assert.True(t, openedAt.Equal(*statuses[0].OpenedAt))
+	assert.Nil(t, statuses[1].OpenedAt)
+}
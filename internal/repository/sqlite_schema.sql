@@ -0,0 +1,97 @@
+CREATE TABLE IF NOT EXISTS currencies (
+    code TEXT PRIMARY KEY,
+    rate TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    created_by TEXT,
+    updated_by TEXT,
+    created_at TIMESTAMP NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    symbol TEXT NOT NULL DEFAULT '',
+    minor_units INTEGER,
+    countries TEXT NOT NULL DEFAULT '[]',
+    kind TEXT CHECK (kind IN ('fiat', 'crypto', 'fictional', 'commodity')),
+    source TEXT NOT NULL DEFAULT 'provider' CHECK (source IN ('provider', 'manual')),
+    locked BOOLEAN NOT NULL DEFAULT FALSE,
+    providers TEXT NOT NULL DEFAULT '[]',
+    jump_threshold TEXT
+);
+
+CREATE TABLE IF NOT EXISTS currency_rate_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    code TEXT NOT NULL,
+    rate TEXT NOT NULL,
+    recorded_at TIMESTAMP NOT NULL,
+    updated_by TEXT,
+    providers TEXT NOT NULL DEFAULT '[]'
+);
+
+CREATE INDEX IF NOT EXISTS idx_currency_rate_history_code_recorded_at ON currency_rate_history (code, recorded_at);
+
+CREATE TABLE IF NOT EXISTS currency_pegs (
+    code TEXT PRIMARY KEY REFERENCES currencies (code) ON DELETE CASCADE,
+    anchor TEXT NOT NULL REFERENCES currencies (code),
+    ratio TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS users (
+    id TEXT PRIMARY KEY,
+    username TEXT NOT NULL UNIQUE,
+    password TEXT NOT NULL,
+    role TEXT NOT NULL,
+    api_key TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS logs (
+    id TEXT NOT NULL,
+    level TEXT NOT NULL,
+    message TEXT NOT NULL,
+    timestamp TIMESTAMP NOT NULL,
+    source TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_logs_timestamp ON logs (timestamp);
+
+CREATE TABLE IF NOT EXISTS scheduled_rate_changes (
+    id TEXT PRIMARY KEY,
+    code TEXT NOT NULL REFERENCES currencies (code) ON DELETE CASCADE,
+    rate TEXT NOT NULL,
+    effective_at TIMESTAMP NOT NULL,
+    created_by TEXT,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_rate_changes_effective_at ON scheduled_rate_changes (effective_at);
+
+CREATE TABLE IF NOT EXISTS provider_status (
+    provider TEXT PRIMARY KEY,
+    state TEXT NOT NULL,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    opened_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS rate_sync_state (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    last_synced_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS quarantined_rates (
+    id TEXT PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE REFERENCES currencies (code) ON DELETE CASCADE,
+    previous_rate TEXT NOT NULL,
+    rate TEXT NOT NULL,
+    change TEXT NOT NULL,
+    providers TEXT NOT NULL DEFAULT '[]',
+    recorded_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS leader_lock (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    holder TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Lutefd/challenge-bravo/internal/model"
+)
+
+type SQLiteUserRepository struct {
+	db     *sql.DB
+	ownsDB bool
+}
+
+func NewSQLiteUserRepository(path string, db *sql.DB) (*SQLiteUserRepository, error) {
+	ownsDB := db == nil
+	if ownsDB {
+		var err error
+		db, err = openSQLite(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &SQLiteUserRepository{db: db, ownsDB: ownsDB}, nil
+}
+
+func (r *SQLiteUserRepository) Create(ctx context.Context, user *model.UserDB) error {
+	query := `INSERT INTO users (id, username, password, role, api_key, created_at, updated_at)
+              VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7)`
+	_, err := r.db.ExecContext(ctx, query, user.ID, user.Username, user.Password, user.Role, user.APIKey, sqliteTime(user.CreatedAt), sqliteTime(user.UpdatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteUserRepository) GetByUsername(ctx context.Context, username string) (*model.UserDB, error) {
+	query := `SELECT id, username, password, role, api_key, created_at, updated_at FROM users WHERE username = ?1`
+	return r.getUser(ctx, query, username)
+}
+
+func (r *SQLiteUserRepository) GetByAPIKey(ctx context.Context, apiKey string) (*model.UserDB, error) {
+	query := `SELECT id, username, password, role, api_key, created_at, updated_at FROM users WHERE api_key = ?1`
+	return r.getUser(ctx, query, apiKey)
+}
+
+func (r *SQLiteUserRepository) getUser(ctx context.Context, query string, arg string) (*model.UserDB, error) {
+	var user model.UserDB
+	err := r.db.QueryRowContext(ctx, query, arg).Scan(
+		&user.ID, &user.Username, &user.Password, &user.Role, &user.APIKey, &user.CreatedAt, &user.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return &user, nil
+}
+
+func (r *SQLiteUserRepository) Update(ctx context.Context, user *model.UserDB) error {
+	query := `UPDATE users
+              SET password = ?1, role = ?2, api_key = ?3, updated_at = ?4
+              WHERE username = ?5`
+	result, err := r.db.ExecContext(ctx, query, user.Password, user.Role, user.APIKey, sqliteTime(user.UpdatedAt), user.Username)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+func (r *SQLiteUserRepository) Delete(ctx context.Context, username string) error {
+	query := `DELETE FROM users WHERE username = ?1`
+	result, err := r.db.ExecContext(ctx, query, username)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+func (r *SQLiteUserRepository) Close() error {
+	if !r.ownsDB {
+		return nil
+	}
+	return r.db.Close()
+}
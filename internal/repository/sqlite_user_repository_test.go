@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Lutefd/challenge-bravo/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteUserRepository(t *testing.T) {
+	repo, err := NewSQLiteUserRepository(filepath.Join(t.TempDir(), "test.db"), nil)
+	require.NoError(t, err)
+	defer repo.Close()
+	ctx := context.Background()
+
+	now := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+	user := &model.UserDB{
+		ID:        uuid.New(),
+		Username:  "testuser",
+		Password:  "hashedpassword",
+		Role:      model.RoleUser,
+		APIKey:    uuid.New().String(),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	require.NoError(t, repo.Create(ctx, user))
+	assert.ErrorContains(t, repo.Create(ctx, user), "failed to create user")
+
+	got, err := repo.GetByUsername(ctx, "testuser")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, got.ID)
+	assert.True(t, now.Equal(got.CreatedAt))
+
+	got, err = repo.GetByAPIKey(ctx, user.APIKey)
+	require.NoError(t, err)
+	assert.Equal(t, "testuser", got.Username)
+
+	user.Role = model.RoleAdmin
+	user.UpdatedAt = now.Add(time.Hour)
+	require.NoError(t, repo.Update(ctx, user))
+	got, err = repo.GetByUsername(ctx, "testuser")
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleAdmin, got.Role)
+
+	require.NoError(t, repo.Delete(ctx, "testuser"))
+	_, err = repo.GetByUsername(ctx, "testuser")
+	assert.EqualError(t, err, "user not found")
+	assert.EqualError(t, repo.Delete(ctx, "testuser"), "user not found")
+	assert.EqualError(t, repo.Update(ctx, user), "user not found")
+}
@@ -13,30 +13,48 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 )
 
-func (s *Server) registerRoutes(currencyService *service.CurrencyService, userService *service.UserService) {
+func (s *Server) registerRoutes(currencyService *service.CurrencyService, userService *service.UserService, statusService *service.ProviderStatusService) {
 	router := chi.NewRouter()
 	router.Use(middleware.Logger)
 	authMiddleware := api_middleware.NewAuthMiddleware(s.userRepo)
 
 	router.Get("/healthz", handler.HandlerReadiness)
 
-	currencyHandler := handler.NewCurrencyHandler(currencyService)
+	currencyHandler := handler.NewCurrencyHandler(currencyService, handler.WithStaleRatePolicy(model.StaleRatePolicy(s.config.StaleRatePolicy)))
 	userHandler := handler.NewUserHandler(userService)
+	statusHandler := handler.NewProviderStatusHandler(statusService)
+	cacheStatsHandler := handler.NewCacheStatsHandler(s.localCache)
 	router.Route("/api/v1", func(r chi.Router) {
 		r.Route("/auth", func(r chi.Router) {
 			r.With(api_middleware.RateLimitMiddleware).Post("/register", userHandler.Register)
 			r.With(api_middleware.RateLimitMiddleware).Post("/login", userHandler.Login)
 		})
 		r.Route("/currency", func(r chi.Router) {
+			r.Get("/", currencyHandler.ListCurrencies)
 			r.Get("/convert", currencyHandler.ConvertCurrency)
+			r.Post("/convert/batch", currencyHandler.ConvertBatch)
+			r.Get("/matrix", currencyHandler.GetRateMatrix)
+			r.Get("/{code}", currencyHandler.GetCurrency)
+			r.Get("/{code}/history", currencyHandler.GetCurrencyHistory)
 			r.Group(func(r chi.Router) {
 				r.Use(authMiddleware.Authenticate)
 				r.Use(api_middleware.RequireRole(model.RoleAdmin))
 				r.Post("/", currencyHandler.AddCurrency)
 				r.Put("/{code}", currencyHandler.UpdateCurrency)
 				r.Delete("/{code}", currencyHandler.RemoveCurrency)
+				r.Put("/{code}/lock", currencyHandler.LockCurrency)
+				r.Delete("/{code}/lock", currencyHandler.UnlockCurrency)
+				r.Get("/scheduled", currencyHandler.ListScheduledRateChanges)
+				r.Delete("/scheduled/{id}", currencyHandler.CancelScheduledRateChange)
+				r.Put("/{code}/jump-threshold", currencyHandler.SetJumpThreshold)
+				r.Delete("/{code}/jump-threshold", currencyHandler.ResetJumpThreshold)
+				r.Get("/quarantine", currencyHandler.ListQuarantinedRates)
+				r.Post("/quarantine/{id}/approve", currencyHandler.ApproveQuarantinedRate)
+				r.Delete("/quarantine/{id}", currencyHandler.RejectQuarantinedRate)
 			})
 		})
+		r.Get("/providers/status", statusHandler.ListProviderStatuses)
+		r.With(authMiddleware.Authenticate, api_middleware.RequireRole(model.RoleAdmin)).Get("/cache/stats", cacheStatsHandler.GetCacheStats)
 		r.Get("/reference", func(w http.ResponseWriter, r *http.Request) {
 			htmlContent, err := scalar.ApiReferenceHTML(&scalar.Options{
 				SpecURL: "./docs/swagger/v1/swagger.yaml",
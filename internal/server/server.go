@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/Lutefd/challenge-bravo/internal/cache"
@@ -11,51 +12,87 @@ import (
 	"github.com/Lutefd/challenge-bravo/internal/logger"
 	"github.com/Lutefd/challenge-bravo/internal/repository"
 	"github.com/Lutefd/challenge-bravo/internal/service"
+	"github.com/Lutefd/challenge-bravo/internal/storage"
+	"github.com/Lutefd/challenge-bravo/internal/worker"
 )
 
 type Server struct {
 	config        commons.Config
+	storage       repository.Config
 	httpServer    *http.Server
 	Router        http.Handler
 	currencyRepo  repository.CurrencyRepository
 	currencyCache cache.Cache
+	localCache    *cache.LocalCache
+	rateChanges   cache.RateChangeSubscriber
 	userRepo      repository.UserRepository
 	logRepo       repository.LogRepository
+	statusRepo    repository.ProviderStatusRepository
+	leaderLock    repository.LeaderLockRepository
+	rateUpdater   *worker.RateUpdater
+	partitionMgr  *logger.PartitionManager
+	elector       *worker.LeaderElector
 }
 
 func NewServer(config commons.Config) (*Server, error) {
-	repo, err := repository.NewPostgresCurrencyRepository(config.PostgresConn, nil)
+	storageConfig, err := storage.Open(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	repo, err := repository.NewCurrencyRepository(storageConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize repository: %w", err)
 	}
-	userRepo, err := repository.NewPostgresUserRepository(config.PostgresConn, nil)
+	userRepo, err := repository.NewUserRepository(storageConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize user repository: %w", err)
 	}
-	logRepo, err := repository.NewPostgresLogRepository(config.PostgresConn, nil)
+	logRepo, err := repository.NewLogRepository(storageConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize log repository: %w", err)
 	}
-	redisCache, err := cache.NewRedisCache(config.RedisAddr, config.RedisPass)
+	statusRepo, err := repository.NewProviderStatusRepository(storageConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize provider status repository: %w", err)
+	}
+	rateCache, err := cache.NewRateCache(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize cache: %w", err)
 	}
-	currencyService := service.NewCurrencyService(repo, redisCache)
+	localCache := cache.NewLocalCache(rateCache, commons.LocalCacheSize, commons.LocalCacheExpiration)
+	currencyService := service.NewCurrencyService(repo, localCache, service.WithRateChangePublisher(rateCache), service.WithMaxRateAge(config.RateMaxAge))
 	userService := service.NewUserService(userRepo)
+	statusService := service.NewProviderStatusService(statusRepo)
 	logger.InitLogger(logRepo)
-	partManager := logger.NewPartitionManager(logRepo)
-	if err := partManager.Start(context.Background()); err != nil {
-		return nil, fmt.Errorf("failed to start partition manager: %w", err)
-	}
 	server := &Server{
 		config:        config,
+		storage:       storageConfig,
 		currencyRepo:  repo,
-		currencyCache: redisCache,
+		currencyCache: localCache,
+		localCache:    localCache,
+		rateChanges:   rateCache,
 		userRepo:      userRepo,
 		logRepo:       logRepo,
+		statusRepo:    statusRepo,
+	}
+
+	if config.Standalone {
+		providers, err := worker.NewProviders(config, statusRepo)
+		if err != nil {
+			return nil, err
+		}
+		leaderLock, err := repository.NewLeaderLockRepository(storageConfig, commons.WorkerLeaderLockKey, commons.SQLiteLeaderLockTTL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize leader lock repository: %w", err)
+		}
+		server.rateUpdater = worker.NewRateUpdater(repo, rateCache, worker.NewRateAggregator(providers, config.RateTolerance), commons.RateUpdaterInterval,
+			worker.WithJumpThreshold(config.RateJumpThreshold), worker.WithRateChangePublisher(rateCache))
+		server.partitionMgr = logger.NewPartitionManager(logRepo)
+		server.leaderLock = leaderLock
+		server.elector = worker.NewLeaderElector(leaderLock, commons.LeaderElectionInterval)
 	}
 
-	server.registerRoutes(currencyService, userService)
+	server.registerRoutes(currencyService, userService, statusService)
 
 	server.httpServer = &http.Server{
 		Addr:         fmt.Sprintf(":%d", config.ServerPort),
@@ -69,6 +106,12 @@ func NewServer(config commons.Config) (*Server, error) {
 }
 
 func (s *Server) Start(ctx context.Context) error {
+	go func() {
+		if err := s.rateChanges.SubscribeRateChanges(ctx, s.localCache); err != nil {
+			logger.Errorf("rate change subscription error: %v", err)
+		}
+	}()
+
 	go func() {
 		logger.Infof("Server started on port %d", s.config.ServerPort)
 		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -76,7 +119,26 @@ func (s *Server) Start(ctx context.Context) error {
 		}
 	}()
 
+	var wg sync.WaitGroup
+	if s.elector != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger.Info("Running the rate updater in-process")
+			if err := s.elector.Run(ctx, func(ctx context.Context) error {
+				if err := s.partitionMgr.Start(ctx); err != nil {
+					return fmt.Errorf("failed to start partition manager: %w", err)
+				}
+				s.rateUpdater.Start(ctx)
+				return nil
+			}); err != nil {
+				logger.Errorf("in-process rate updater error: %v", err)
+			}
+		}()
+	}
+
 	<-ctx.Done()
+	wg.Wait()
 	return s.Shutdown()
 }
 
@@ -88,17 +150,35 @@ func (s *Server) Shutdown() error {
 		logger.Errorf("HTTP server shutdown error: %v", err)
 		return err
 	}
+	if err := logger.Shutdown(ctx); err != nil {
+		logger.ErrorLogger.Printf("error shutting down logger: %v", err)
+	}
+
 	if err := s.currencyRepo.Close(); err != nil {
-		logger.Errorf("database connection close error: %v", err)
+		logger.ErrorLogger.Printf("database connection close error: %v", err)
+		return err
+	}
+
+	if err := s.statusRepo.Close(); err != nil {
+		logger.ErrorLogger.Printf("provider status database connection close error: %v", err)
 		return err
 	}
 
+	if s.leaderLock != nil {
+		if err := s.leaderLock.Close(); err != nil {
+			logger.ErrorLogger.Printf("leader lock database connection close error: %v", err)
+			return err
+		}
+	}
+
 	if err := s.currencyCache.Close(); err != nil {
-		logger.Errorf("cache connection close error: %v", err)
+		logger.ErrorLogger.Printf("cache connection close error: %v", err)
 		return err
 	}
-	if err := logger.Shutdown(ctx); err != nil {
-		logger.Errorf("error shutting down logger: %v", err)
+
+	if err := s.storage.Close(); err != nil {
+		logger.ErrorLogger.Printf("storage close error: %v", err)
+		return err
 	}
 
 	fmt.Println("server shutdown complete")
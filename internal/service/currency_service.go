@@ -2,56 +2,332 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/Lutefd/challenge-bravo/internal/cache"
+	"github.com/Lutefd/challenge-bravo/internal/commons"
 	"github.com/Lutefd/challenge-bravo/internal/model"
 	"github.com/Lutefd/challenge-bravo/internal/repository"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 type CurrencyService struct {
-	repo  repository.CurrencyRepository
-	cache cache.Cache
+	repo       repository.CurrencyRepository
+	cache      cache.Cache
+	publisher  cache.RateChangePublisher
+	maxRateAge time.Duration
 }
 
-func NewCurrencyService(repo repository.CurrencyRepository, cache cache.Cache) *CurrencyService {
-	return &CurrencyService{
+type CurrencyServiceOption func(*CurrencyService)
+
+func WithRateChangePublisher(publisher cache.RateChangePublisher) CurrencyServiceOption {
+	return func(s *CurrencyService) {
+		s.publisher = publisher
+	}
+}
+
+func WithMaxRateAge(maxAge time.Duration) CurrencyServiceOption {
+	return func(s *CurrencyService) {
+		s.maxRateAge = maxAge
+	}
+}
+
+func NewCurrencyService(repo repository.CurrencyRepository, cache cache.Cache, options ...CurrencyServiceOption) *CurrencyService {
+	s := &CurrencyService{
 		repo:  repo,
 		cache: cache,
 	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+func (s *CurrencyService) Convert(ctx context.Context, from, to string, amount decimal.Decimal) (*model.Conversion, error) {
+	conversions, err := s.ConvertBatch(ctx, []model.ConversionRequest{{From: from, To: to, Amount: amount}})
+	if err != nil {
+		return nil, err
+	}
+	return &conversions[0], nil
 }
 
-func (s *CurrencyService) Convert(ctx context.Context, from, to string, amount float64) (float64, error) {
-	fromRate, err := s.getRate(ctx, from)
+func (s *CurrencyService) ConvertBatch(ctx context.Context, requests []model.ConversionRequest) ([]model.Conversion, error) {
+	legs, infos, version, err := s.resolve(ctx, requestCodes(requests))
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	return convertRequests(requests, legs, infos, version)
+}
+
+func requestCodes(requests []model.ConversionRequest) []string {
+	seen := make(map[string]bool)
+	codes := make([]string, 0, len(requests)*2)
+	for _, request := range requests {
+		for _, code := range []string{request.From, request.To} {
+			if !seen[code] {
+				seen[code] = true
+				codes = append(codes, code)
+			}
+		}
+	}
+	return codes
+}
+
+func convertRequests(requests []model.ConversionRequest, legs map[string]model.RateLeg, infos map[string]model.CurrencyInfo, version uint64) ([]model.Conversion, error) {
+	conversions := make([]model.Conversion, 0, len(requests))
+	for _, request := range requests {
+		fromLeg, toLeg := legs[request.From], legs[request.To]
+		result, err := convertAmount(request.Amount, fromLeg, toLeg)
+		if err != nil {
+			return nil, err
+		}
+		conversions = append(conversions, model.Conversion{
+			From:     infos[request.From],
+			To:       infos[request.To],
+			Amount:   request.Amount,
+			Result:   result,
+			FromRate: fromLeg,
+			ToRate:   toLeg,
+			Stale:    fromLeg.Stale || toLeg.Stale,
+			Version:  version,
+		})
 	}
-	toRate, err := s.getRate(ctx, to)
+	return conversions, nil
+}
+
+func (s *CurrencyService) GetRateMatrix(ctx context.Context, codes []string) (*model.RateMatrix, error) {
+	legs, _, version, err := s.resolve(ctx, codes)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	usdAmount := amount / fromRate
-	result := usdAmount * toRate
 
-	return result, nil
+	matrix := &model.RateMatrix{
+		Codes:       codes,
+		Rates:       make(map[string]map[string]decimal.Decimal, len(codes)),
+		Version:     version,
+		GeneratedAt: time.Now().UTC(),
+	}
+	for _, from := range codes {
+		row := make(map[string]decimal.Decimal, len(codes))
+		for _, to := range codes {
+			rate, err := convertAmount(decimal.NewFromInt(1), legs[from], legs[to])
+			if err != nil {
+				return nil, err
+			}
+			row[to] = rate
+		}
+		matrix.Rates[from] = row
+	}
+	return matrix, nil
 }
 
-func (s *CurrencyService) getRate(ctx context.Context, code string) (float64, error) {
-	rate, err := s.cache.Get(ctx, code)
-	if err == nil {
-		return rate, nil
+func (s *CurrencyService) resolve(ctx context.Context, codes []string) (map[string]model.RateLeg, map[string]model.CurrencyInfo, uint64, error) {
+	snapshot := s.rateSnapshot(ctx, codes)
+	if missing := missingCodes(snapshot, codes); len(missing) > 0 {
+		snapshot = s.backfillSnapshot(ctx, snapshot, codes, missing)
 	}
 
+	legs := make(map[string]model.RateLeg, len(codes))
+	infos := make(map[string]model.CurrencyInfo, len(codes))
+	for _, code := range codes {
+		currency, ok := snapshot.Currencies[code]
+		if !ok {
+			return nil, nil, 0, fmt.Errorf("%w: %s", model.ErrCurrencyNotFound, code)
+		}
+		infos[code] = model.CurrencyInfo{Code: code, CurrencyMetadata: currency.CurrencyMetadata}
+		legs[code] = s.rateLeg(currency)
+	}
+	return legs, infos, snapshot.Version, nil
+}
+
+func (s *CurrencyService) rateSnapshot(ctx context.Context, codes []string) model.RateSnapshot {
+	snapshot, err := s.cache.GetSnapshot(ctx, codes)
+	if err != nil {
+		fmt.Printf("failed to get rates from cache: %v\n", err)
+		return model.RateSnapshot{Currencies: make(map[string]model.Currency)}
+	}
+	return snapshot
+}
+
+func (s *CurrencyService) backfillSnapshot(ctx context.Context, snapshot model.RateSnapshot, codes, missing []string) model.RateSnapshot {
+	currencies, err := s.repo.GetByCodes(ctx, missing)
+	if err != nil {
+		fmt.Printf("failed to get currencies %v: %v\n", missing, err)
+		return snapshot
+	}
+	if len(currencies) == 0 {
+		return snapshot
+	}
+
+	filled, err := s.cache.FillSnapshot(ctx, snapshot.Version, currencies)
+	if err != nil {
+		fmt.Printf("failed to cache currencies %v: %v\n", missing, err)
+	}
+	if filled || snapshot.Version == 0 {
+		merged := model.RateSnapshot{Version: snapshot.Version, Currencies: make(map[string]model.Currency, len(codes))}
+		for code, currency := range snapshot.Currencies {
+			merged.Currencies[code] = currency
+		}
+		for _, currency := range currencies {
+			merged.Currencies[currency.Code] = currency
+		}
+		return merged
+	}
+
+	currencies, err = s.repo.GetByCodes(ctx, codes)
+	if err != nil {
+		fmt.Printf("failed to get currencies %v: %v\n", codes, err)
+		return snapshot
+	}
+	fallback := model.RateSnapshot{Currencies: make(map[string]model.Currency, len(currencies))}
+	for _, currency := range currencies {
+		fallback.Currencies[currency.Code] = currency
+	}
+	return fallback
+}
+
+func missingCodes(snapshot model.RateSnapshot, codes []string) []string {
+	var missing []string
+	for _, code := range codes {
+		if _, ok := snapshot.Currencies[code]; !ok {
+			missing = append(missing, code)
+		}
+	}
+	return missing
+}
+
+func (s *CurrencyService) rateLeg(currency model.Currency) model.RateLeg {
+	leg := model.RateLeg{Code: currency.Code, Rate: currency.Rate, Source: currency.Source}
+	if currency.UpdatedAt.IsZero() {
+		return leg
+	}
+	updatedAt := currency.UpdatedAt.UTC()
+	leg.UpdatedAt = &updatedAt
+	if s.maxRateAge > 0 && currency.Source == model.CurrencySourceProvider && !currency.Locked {
+		leg.Stale = time.Since(updatedAt) > s.maxRateAge
+	}
+	return leg
+}
+
+func (s *CurrencyService) ConvertAt(ctx context.Context, from, to string, amount decimal.Decimal, at time.Time) (*model.Conversion, error) {
+	conversions, err := s.ConvertBatchAt(ctx, []model.ConversionRequest{{From: from, To: to, Amount: amount}}, at)
+	if err != nil {
+		return nil, err
+	}
+	return &conversions[0], nil
+}
+
+func (s *CurrencyService) ConvertBatchAt(ctx context.Context, requests []model.ConversionRequest, at time.Time) ([]model.Conversion, error) {
+	codes := requestCodes(requests)
+	legs, err := s.getRatesBefore(ctx, codes, at)
+	if err != nil {
+		return nil, err
+	}
+	return convertRequests(requests, legs, s.describe(ctx, codes), 0)
+}
+
+func (s *CurrencyService) describe(ctx context.Context, codes []string) map[string]model.CurrencyInfo {
+	infos := make(map[string]model.CurrencyInfo, len(codes))
+	for _, code := range codes {
+		infos[code] = unknownCurrencyInfo(code)
+	}
+
+	currencies, err := s.repo.GetByCodes(ctx, codes)
+	if err != nil {
+		fmt.Printf("failed to get metadata for currencies %v: %v\n", codes, err)
+		return infos
+	}
+	for _, currency := range currencies {
+		infos[currency.Code] = model.CurrencyInfo{Code: currency.Code, CurrencyMetadata: currency.CurrencyMetadata}
+	}
+	return infos
+}
+
+func unknownCurrencyInfo(code string) model.CurrencyInfo {
+	return model.CurrencyInfo{Code: code, CurrencyMetadata: model.KnownCurrencyMetadata(code)}
+}
+
+func (s *CurrencyService) GetRateHistory(ctx context.Context, code string, from, to time.Time) ([]model.RateHistory, error) {
+	if _, err := s.repo.GetByCode(ctx, code); err != nil {
+		if errors.Is(err, model.ErrCurrencyNotFound) {
+			return nil, fmt.Errorf("%w: %s", model.ErrCurrencyNotFound, code)
+		}
+		return nil, fmt.Errorf("failed to get currency: %w", err)
+	}
+
+	history, err := s.repo.GetRateHistory(ctx, code, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rate history: %w", err)
+	}
+	return history, nil
+}
+
+func convertAmount(amount decimal.Decimal, from, to model.RateLeg) (decimal.Decimal, error) {
+	if !from.Rate.IsPositive() {
+		return decimal.Zero, fmt.Errorf("%w: %s has rate %s", model.ErrInvalidRate, from.Code, from.Rate)
+	}
+	return amount.Mul(to.Rate).DivRound(from.Rate, commons.DivisionPrecision), nil
+}
+
+func (s *CurrencyService) getRatesBefore(ctx context.Context, codes []string, before time.Time) (map[string]model.RateLeg, error) {
+	entries, err := s.repo.GetRatesBefore(ctx, codes, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get historical rates: %w", err)
+	}
+
+	legs := make(map[string]model.RateLeg, len(entries))
+	for _, entry := range entries {
+		recordedAt := entry.RecordedAt.UTC()
+		legs[entry.Code] = model.RateLeg{Code: entry.Code, Rate: entry.Rate, UpdatedAt: &recordedAt}
+	}
+	for _, code := range codes {
+		if _, ok := legs[code]; !ok {
+			return nil, fmt.Errorf("%w: %s", model.ErrCurrencyNotFound, code)
+		}
+	}
+	return legs, nil
+}
+
+func (s *CurrencyService) GetCurrency(ctx context.Context, code string) (*model.Currency, error) {
 	currency, err := s.repo.GetByCode(ctx, code)
 	if err != nil {
-		return 0, fmt.Errorf("%w: %s", model.ErrCurrencyNotFound, code)
+		if errors.Is(err, model.ErrCurrencyNotFound) {
+			return nil, fmt.Errorf("%w: %s", model.ErrCurrencyNotFound, code)
+		}
+		return nil, fmt.Errorf("failed to get currency: %w", err)
+	}
+
+	peg, err := s.repo.GetPeg(ctx, code)
+	if err != nil && !errors.Is(err, model.ErrPegNotFound) {
+		return nil, fmt.Errorf("failed to get currency peg: %w", err)
 	}
+	currency.Peg = peg
+	return currency, nil
+}
 
-	s.cache.Set(ctx, code, currency.Rate, 1*time.Hour)
+func (s *CurrencyService) ListCurrencies(ctx context.Context, opts model.CurrencyListOptions) ([]model.Currency, int, error) {
+	currencies, total, err := s.repo.List(ctx, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list currencies: %w", err)
+	}
 
-	return currency.Rate, nil
+	pegs, err := s.repo.ListPegs(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list currency pegs: %w", err)
+	}
+	pegsByCode := make(map[string]model.CurrencyPeg, len(pegs))
+	for _, peg := range pegs {
+		pegsByCode[peg.Code] = peg
+	}
+	for i := range currencies {
+		if peg, ok := pegsByCode[currencies[i].Code]; ok {
+			currencies[i].Peg = &peg
+		}
+	}
+	return currencies, total, nil
 }
 
 func (s *CurrencyService) AddCurrency(ctx context.Context, currency *model.Currency) error {
@@ -60,18 +336,26 @@ func (s *CurrencyService) AddCurrency(ctx context.Context, currency *model.Curre
 		return fmt.Errorf("currency %s already exists", currency.Code)
 	}
 
+	if currency.Peg != nil {
+		rate, err := s.pegRate(ctx, currency.Code, currency.Peg)
+		if err != nil {
+			return err
+		}
+		currency.Rate = rate
+	}
+	currency.Source = model.CurrencySourceManual
+	currency.Locked = currency.Peg == nil
+
 	if err := s.repo.Create(ctx, currency); err != nil {
 		return fmt.Errorf("failed to add currency to repository: %w", err)
 	}
 
-	if err := s.cache.Set(ctx, currency.Code, currency.Rate, 1*time.Hour); err != nil {
-		fmt.Printf("failed to update cache for new currency %s: %v\n", currency.Code, err)
-	}
+	s.applyRateChanges(ctx, model.RateChangeEvent{Code: currency.Code, Rate: currency.Rate})
 
 	return nil
 }
 
-func (s *CurrencyService) UpdateCurrency(ctx context.Context, code string, rate float64, updatedBy uuid.UUID) error {
+func (s *CurrencyService) UpdateCurrency(ctx context.Context, code string, update model.CurrencyUpdate, updatedBy uuid.UUID) error {
 	currency, err := s.repo.GetByCode(ctx, code)
 	if err != nil {
 		if err == model.ErrCurrencyNotFound {
@@ -80,33 +364,265 @@ func (s *CurrencyService) UpdateCurrency(ctx context.Context, code string, rate
 		return fmt.Errorf("failed to get currency: %w", err)
 	}
 
+	metadata := currency.CurrencyMetadata
+	if update.Name != nil {
+		metadata.Name = *update.Name
+	}
+	if update.Symbol != nil {
+		metadata.Symbol = *update.Symbol
+	}
+	if update.MinorUnits != nil {
+		metadata.MinorUnits = update.MinorUnits
+	}
+	if update.Countries != nil {
+		metadata.Countries = update.Countries
+	}
+	if update.Kind != nil {
+		metadata.Kind = *update.Kind
+	}
+	if update.Rate == nil && update.Peg == nil {
+		if err := s.repo.UpdateMetadata(ctx, code, metadata); err != nil {
+			return fmt.Errorf("failed to update currency metadata in repository: %w", err)
+		}
+		s.applyRateChanges(ctx, model.RateChangeEvent{Code: code, Rate: currency.Rate})
+		return nil
+	}
+
+	var rate decimal.Decimal
+	if update.Peg != nil {
+		rate, err = s.pegRate(ctx, code, update.Peg)
+		if err != nil {
+			return err
+		}
+	} else {
+		rate = *update.Rate
+	}
+
 	currency.Rate = rate
 	currency.UpdatedAt = time.Now()
 	currency.UpdatedBy = updatedBy
+	currency.Source = model.CurrencySourceManual
+	currency.Locked = update.Peg == nil
+	currency.Providers = nil
+	currency.Peg = update.Peg
+	currency.CurrencyMetadata = metadata
 
-	if err := s.repo.Update(ctx, currency); err != nil {
+	if err := s.repo.Replace(ctx, currency); err != nil {
 		return fmt.Errorf("failed to update currency in repository: %w", err)
 	}
 
-	if err := s.cache.Set(ctx, code, rate, 1*time.Hour); err != nil {
-		fmt.Printf("failed to update cache for currency %s: %v\n", code, err)
+	s.applyRateChanges(ctx, model.RateChangeEvent{Code: code, Rate: rate})
+
+	s.updatePeggedRates(ctx, code, rate, updatedBy)
+
+	return nil
+}
+
+func (s *CurrencyService) SetCurrencyLock(ctx context.Context, code string, locked bool) error {
+	if err := s.repo.SetLocked(ctx, code, locked); err != nil {
+		if errors.Is(err, model.ErrCurrencyNotFound) {
+			return fmt.Errorf("%w: %s", model.ErrCurrencyNotFound, code)
+		}
+		return fmt.Errorf("failed to update currency lock: %w", err)
+	}
+	return nil
+}
+
+func (s *CurrencyService) ScheduleRateChange(ctx context.Context, change *model.ScheduledRateChange) error {
+	if _, err := s.repo.GetByCode(ctx, change.Code); err != nil {
+		if errors.Is(err, model.ErrCurrencyNotFound) {
+			return fmt.Errorf("%w: %s", model.ErrCurrencyNotFound, change.Code)
+		}
+		return fmt.Errorf("failed to get currency: %w", err)
+	}
+
+	change.ID = uuid.New()
+	change.CreatedAt = time.Now()
+	if err := s.repo.ScheduleRateChange(ctx, change); err != nil {
+		return fmt.Errorf("failed to schedule rate change: %w", err)
+	}
+	return nil
+}
+
+func (s *CurrencyService) ListScheduledRateChanges(ctx context.Context, code string) ([]model.ScheduledRateChange, error) {
+	changes, err := s.repo.ListScheduledRateChanges(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled rate changes: %w", err)
+	}
+	return changes, nil
+}
+
+func (s *CurrencyService) CancelScheduledRateChange(ctx context.Context, id uuid.UUID) error {
+	if err := s.repo.CancelScheduledRateChange(ctx, id); err != nil {
+		if errors.Is(err, model.ErrScheduledChangeNotFound) {
+			return fmt.Errorf("%w: %s", model.ErrScheduledChangeNotFound, id)
+		}
+		return fmt.Errorf("failed to cancel scheduled rate change: %w", err)
+	}
+	return nil
+}
+
+func (s *CurrencyService) SetJumpThreshold(ctx context.Context, code string, threshold *decimal.Decimal) error {
+	if err := s.repo.SetJumpThreshold(ctx, code, threshold); err != nil {
+		if errors.Is(err, model.ErrCurrencyNotFound) {
+			return fmt.Errorf("%w: %s", model.ErrCurrencyNotFound, code)
+		}
+		return fmt.Errorf("failed to update jump threshold: %w", err)
+	}
+	return nil
+}
+
+func (s *CurrencyService) ListQuarantinedRates(ctx context.Context, code string) ([]model.QuarantinedRate, error) {
+	rates, err := s.repo.ListQuarantinedRates(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quarantined rates: %w", err)
+	}
+	return rates, nil
+}
+
+func (s *CurrencyService) ApproveQuarantinedRate(ctx context.Context, id, approvedBy uuid.UUID) (*model.QuarantinedRate, error) {
+	rate, err := s.repo.ApproveQuarantinedRate(ctx, id, approvedBy)
+	if err != nil {
+		if errors.Is(err, model.ErrQuarantinedRateNotFound) {
+			return nil, fmt.Errorf("%w: %s", model.ErrQuarantinedRateNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to approve quarantined rate: %w", err)
 	}
 
+	s.applyRateChanges(ctx, model.RateChangeEvent{Code: rate.Code, Rate: rate.Rate})
+
+	s.updatePeggedRates(ctx, rate.Code, rate.Rate, approvedBy)
+
+	return rate, nil
+}
+
+func (s *CurrencyService) RejectQuarantinedRate(ctx context.Context, id uuid.UUID) error {
+	if err := s.repo.RejectQuarantinedRate(ctx, id); err != nil {
+		if errors.Is(err, model.ErrQuarantinedRateNotFound) {
+			return fmt.Errorf("%w: %s", model.ErrQuarantinedRateNotFound, id)
+		}
+		return fmt.Errorf("failed to reject quarantined rate: %w", err)
+	}
 	return nil
 }
 
+func (s *CurrencyService) pegRate(ctx context.Context, code string, peg *model.CurrencyPeg) (decimal.Decimal, error) {
+	if peg.Anchor == code {
+		return decimal.Zero, fmt.Errorf("%w: %s cannot be pegged to itself", model.ErrInvalidPeg, code)
+	}
+
+	anchor, err := s.repo.GetByCode(ctx, peg.Anchor)
+	if err != nil {
+		if errors.Is(err, model.ErrCurrencyNotFound) {
+			return decimal.Zero, fmt.Errorf("%w: anchor currency %s not found", model.ErrInvalidPeg, peg.Anchor)
+		}
+		return decimal.Zero, fmt.Errorf("failed to get anchor currency: %w", err)
+	}
+
+	pegs, err := s.repo.ListPegs(ctx)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to list currency pegs: %w", err)
+	}
+	for _, existing := range pegs {
+		if existing.Code == peg.Anchor {
+			return decimal.Zero, fmt.Errorf("%w: anchor currency %s is itself pegged to %s", model.ErrInvalidPeg, peg.Anchor, existing.Anchor)
+		}
+		if existing.Anchor == code {
+			return decimal.Zero, fmt.Errorf("%w: %s is the anchor of %s", model.ErrInvalidPeg, code, existing.Code)
+		}
+	}
+
+	return peg.RateFrom(anchor.Rate, commons.DivisionPrecision), nil
+}
+
+func (s *CurrencyService) updatePeggedRates(ctx context.Context, anchor string, anchorRate decimal.Decimal, updatedBy uuid.UUID) {
+	pegs, err := s.repo.ListPegs(ctx)
+	if err != nil {
+		fmt.Printf("failed to list currencies pegged to %s: %v\n", anchor, err)
+		return
+	}
+
+	var events []model.RateChangeEvent
+	for _, peg := range pegs {
+		if peg.Anchor != anchor {
+			continue
+		}
+		currency := &model.Currency{
+			Code:      peg.Code,
+			Rate:      peg.RateFrom(anchorRate, commons.DivisionPrecision),
+			UpdatedAt: time.Now(),
+			UpdatedBy: updatedBy,
+			Source:    model.CurrencySourceManual,
+		}
+		if err := s.repo.Update(ctx, currency); err != nil {
+			fmt.Printf("failed to update pegged currency %s: %v\n", peg.Code, err)
+			continue
+		}
+		events = append(events, model.RateChangeEvent{Code: peg.Code, Rate: currency.Rate})
+	}
+	s.applyRateChanges(ctx, events...)
+}
+
 func (s *CurrencyService) RemoveCurrency(ctx context.Context, code string) error {
 	_, err := s.repo.GetByCode(ctx, code)
 	if err != nil {
 		return fmt.Errorf("currency %s not found", code)
 	}
 
+	pegs, err := s.repo.ListPegs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list currency pegs: %w", err)
+	}
+	for _, peg := range pegs {
+		if peg.Anchor == code {
+			return fmt.Errorf("%w: %s is the anchor of %s", model.ErrCurrencyInUse, code, peg.Code)
+		}
+	}
+
 	if err := s.repo.Delete(ctx, code); err != nil {
 		return fmt.Errorf("failed to remove currency from repository: %w", err)
 	}
 
-	if err := s.cache.Delete(ctx, code); err != nil {
-		fmt.Printf("failed to remove currency %s from cache: %v\n", code, err)
-	}
+	s.applyRateChanges(ctx, model.RateChangeEvent{Code: code, Deleted: true})
 	return nil
 }
+
+func (s *CurrencyService) applyRateChanges(ctx context.Context, events ...model.RateChangeEvent) {
+	if len(events) == 0 {
+		return
+	}
+	var changed, deleted []string
+	for _, event := range events {
+		if event.Deleted {
+			deleted = append(deleted, event.Code)
+			continue
+		}
+		changed = append(changed, event.Code)
+	}
+
+	var currencies []model.Currency
+	if len(changed) > 0 {
+		stored, err := s.repo.GetByCodes(ctx, changed)
+		if err != nil {
+			fmt.Printf("failed to reload currencies %v: %v\n", changed, err)
+			deleted = append(deleted, changed...)
+		}
+		currencies = stored
+	}
+	version, err := s.cache.UpdateSnapshot(ctx, currencies, deleted, commons.CacheExpiration)
+	if err != nil {
+		fmt.Printf("failed to update cached rates: %v\n", err)
+	}
+
+	if s.publisher == nil {
+		return
+	}
+	changedAt := time.Now().UTC()
+	for i := range events {
+		events[i].Version = version
+		events[i].ChangedAt = changedAt
+	}
+	if err := s.publisher.PublishRateChanges(ctx, events); err != nil {
+		fmt.Printf("failed to publish rate changes: %v\n", err)
+	}
+}
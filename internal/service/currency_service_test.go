@@ -3,29 +3,65 @@ package service_test
 import (
 	"context"
 	"errors"
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/Lutefd/challenge-bravo/internal/model"
 	"github.com/Lutefd/challenge-bravo/internal/service"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type mockRepository struct {
 	currencies map[string]*model.Currency
+	history    map[string][]model.RateHistory
+	pegs       map[string]model.CurrencyPeg
+	scheduled  []model.ScheduledRateChange
+	quarantine []model.QuarantinedRate
+
+	ratesBeforeCalls int
 }
 
 func (m *mockRepository) GetByCode(ctx context.Context, code string) (*model.Currency, error) {
 	currency, ok := m.currencies[code]
 	if !ok {
-		return nil, errors.New("currency not found")
+		return nil, model.ErrCurrencyNotFound
 	}
 	return currency, nil
 }
 
+func (m *mockRepository) GetByCodes(ctx context.Context, codes []string) ([]model.Currency, error) {
+	currencies := []model.Currency{}
+	for _, code := range codes {
+		if currency, ok := m.currencies[code]; ok {
+			currencies = append(currencies, *currency)
+		}
+	}
+	return currencies, nil
+}
+
+func (m *mockRepository) List(ctx context.Context, opts model.CurrencyListOptions) ([]model.Currency, int, error) {
+	codes := make([]string, 0, len(m.currencies))
+	for code := range m.currencies {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	currencies := []model.Currency{}
+	for i, code := range codes {
+		if i >= opts.Offset && len(currencies) < opts.Limit {
+			currencies = append(currencies, *m.currencies[code])
+		}
+	}
+	return currencies, len(codes), nil
+}
+
 func (m *mockRepository) Create(ctx context.Context, currency *model.Currency) error {
 	m.currencies[currency.Code] = currency
+	m.savePeg(currency)
 	return nil
 }
 
@@ -34,6 +70,73 @@ func (m *mockRepository) Update(ctx context.Context, currency *model.Currency) e
 		return errors.New("currency not found")
 	}
 	m.currencies[currency.Code] = currency
+	m.savePeg(currency)
+	return nil
+}
+
+func (m *mockRepository) Replace(ctx context.Context, currency *model.Currency) error {
+	if err := m.Update(ctx, currency); err != nil {
+		return err
+	}
+	if currency.Peg == nil {
+		delete(m.pegs, currency.Code)
+	}
+	return nil
+}
+
+func (m *mockRepository) UpsertRates(ctx context.Context, currencies []model.Currency) (int, error) {
+	for i := range currencies {
+		m.currencies[currencies[i].Code] = &currencies[i]
+	}
+	return len(currencies), nil
+}
+
+func (m *mockRepository) UpdatePeggedRates(ctx context.Context, currencies []model.Currency) error {
+	for _, currency := range currencies {
+		if existing, ok := m.currencies[currency.Code]; ok {
+			existing.Rate = currency.Rate
+			existing.UpdatedAt = currency.UpdatedAt
+		}
+	}
+	return nil
+}
+
+func (m *mockRepository) savePeg(currency *model.Currency) {
+	if currency.Peg == nil {
+		return
+	}
+	if m.pegs == nil {
+		m.pegs = make(map[string]model.CurrencyPeg)
+	}
+	peg := *currency.Peg
+	peg.Code = currency.Code
+	m.pegs[currency.Code] = peg
+}
+
+func (m *mockRepository) UpdateMetadata(ctx context.Context, code string, metadata model.CurrencyMetadata) error {
+	currency, ok := m.currencies[code]
+	if !ok {
+		return model.ErrCurrencyNotFound
+	}
+	currency.CurrencyMetadata = metadata
+	return nil
+}
+
+func (m *mockRepository) SetLocked(ctx context.Context, code string, locked bool) error {
+	currency, ok := m.currencies[code]
+	if !ok {
+		return model.ErrCurrencyNotFound
+	}
+	currency.Locked = locked
+	return nil
+}
+
+func (m *mockRepository) SetJumpThreshold(ctx context.Context, code string, threshold *decimal.Decimal) error {
+	currency, ok := m.currencies[code]
+	if !ok {
+		return model.ErrCurrencyNotFound
+	}
+	currency.JumpThreshold = threshold
 	return nil
 }
 
@@ -42,47 +145,252 @@ func (m *mockRepository) Delete(ctx context.Context, code string) error {
 	return nil
 }
 
+func (m *mockRepository) GetPeg(ctx context.Context, code string) (*model.CurrencyPeg, error) {
+	peg, ok := m.pegs[code]
+	if !ok {
+		return nil, model.ErrPegNotFound
+	}
+	return &peg, nil
+}
+
+func (m *mockRepository) ListPegs(ctx context.Context) ([]model.CurrencyPeg, error) {
+	pegs := []model.CurrencyPeg{}
+	for _, peg := range m.pegs {
+		pegs = append(pegs, peg)
+	}
+	return pegs, nil
+}
+
+func (m *mockRepository) GetRateHistory(ctx context.Context, code string, from, to time.Time) ([]model.RateHistory, error) {
+	history := []model.RateHistory{}
+	for _, entry := range m.history[code] {
+		if !entry.RecordedAt.Before(from) && entry.RecordedAt.Before(to) {
+			history = append(history, entry)
+		}
+	}
+	return history, nil
+}
+
+func (m *mockRepository) GetRatesBefore(ctx context.Context, codes []string, before time.Time) ([]model.RateHistory, error) {
+	m.ratesBeforeCalls++
+	entries := []model.RateHistory{}
+	for _, code := range codes {
+		var latest *model.RateHistory
+		for i, entry := range m.history[code] {
+			if entry.RecordedAt.Before(before) && (latest == nil || entry.RecordedAt.After(latest.RecordedAt)) {
+				latest = &m.history[code][i]
+			}
+		}
+		if latest != nil {
+			entries = append(entries, *latest)
+		}
+	}
+	return entries, nil
+}
+
+func (m *mockRepository) InsertRateHistory(ctx context.Context, entries []model.RateHistory) (int, error) {
+	for _, entry := range entries {
+		m.history[entry.Code] = append(m.history[entry.Code], entry)
+	}
+	return len(entries), nil
+}
+
+func (m *mockRepository) GetLastRateSync(ctx context.Context) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+func (m *mockRepository) SetLastRateSync(ctx context.Context, at time.Time) error {
+	return nil
+}
+
+func (m *mockRepository) ScheduleRateChange(ctx context.Context, change *model.ScheduledRateChange) error {
+	m.scheduled = append(m.scheduled, *change)
+	return nil
+}
+
+func (m *mockRepository) ListScheduledRateChanges(ctx context.Context, code string) ([]model.ScheduledRateChange, error) {
+	changes := []model.ScheduledRateChange{}
+	for _, change := range m.scheduled {
+		if code == "" || change.Code == code {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+func (m *mockRepository) ListDueRateChanges(ctx context.Context, at time.Time) ([]model.ScheduledRateChange, error) {
+	changes := []model.ScheduledRateChange{}
+	for _, change := range m.scheduled {
+		if !change.EffectiveAt.After(at) {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+func (m *mockRepository) CancelScheduledRateChange(ctx context.Context, id uuid.UUID) error {
+	for i, change := range m.scheduled {
+		if change.ID == id {
+			m.scheduled = append(m.scheduled[:i], m.scheduled[i+1:]...)
+			return nil
+		}
+	}
+	return model.ErrScheduledChangeNotFound
+}
+
+func (m *mockRepository) ApplyScheduledRateChange(ctx context.Context, change model.ScheduledRateChange) error {
+	if err := m.CancelScheduledRateChange(ctx, change.ID); err != nil {
+		return err
+	}
+	currency, ok := m.currencies[change.Code]
+	if !ok {
+		return model.ErrCurrencyNotFound
+	}
+	currency.Rate = change.Rate
+	currency.UpdatedAt = change.EffectiveAt
+	return nil
+}
+
+func (m *mockRepository) QuarantineRate(ctx context.Context, rate *model.QuarantinedRate) error {
+	m.quarantine = append(m.quarantine, *rate)
+	return nil
+}
+
+func (m *mockRepository) ListQuarantinedRates(ctx context.Context, code string) ([]model.QuarantinedRate, error) {
+	rates := []model.QuarantinedRate{}
+	for _, rate := range m.quarantine {
+		if code == "" || rate.Code == code {
+			rates = append(rates, rate)
+		}
+	}
+	return rates, nil
+}
+
+func (m *mockRepository) ApproveQuarantinedRate(ctx context.Context, id, approvedBy uuid.UUID) (*model.QuarantinedRate, error) {
+	for i, rate := range m.quarantine {
+		if rate.ID != id {
+			continue
+		}
+		m.quarantine = append(m.quarantine[:i], m.quarantine[i+1:]...)
+		currency, ok := m.currencies[rate.Code]
+		if !ok {
+			return nil, model.ErrCurrencyNotFound
+		}
+		currency.Rate = rate.Rate
+		currency.UpdatedAt = rate.RecordedAt
+		currency.UpdatedBy = approvedBy
+		return &rate, nil
+	}
+	return nil, model.ErrQuarantinedRateNotFound
+}
+
+func (m *mockRepository) RejectQuarantinedRate(ctx context.Context, id uuid.UUID) error {
+	for i, rate := range m.quarantine {
+		if rate.ID == id {
+			m.quarantine = append(m.quarantine[:i], m.quarantine[i+1:]...)
+			return nil
+		}
+	}
+	return model.ErrQuarantinedRateNotFound
+}
+
 func (m *mockRepository) Close() error {
 	return nil
 }
 
 type mockCache struct {
-	data map[string]float64
+	data    map[string]model.Currency
+	version uint64
+}
+
+func (m *mockCache) Get(ctx context.Context, code string) (model.Currency, error) {
+	if currency, ok := m.data[code]; ok {
+		return currency, nil
+	}
+	return model.Currency{}, errors.New("key not found")
 }
 
-func (m *mockCache) Get(ctx context.Context, key string) (float64, error) {
-	if rate, ok := m.data[key]; ok {
-		return rate, nil
+func (m *mockCache) GetMany(ctx context.Context, codes []string) (map[string]model.Currency, error) {
+	currencies := make(map[string]model.Currency)
+	for _, code := range codes {
+		if currency, ok := m.data[code]; ok {
+			currencies[code] = currency
+		}
 	}
-	return 0, errors.New("key not found")
+	return currencies, nil
+}
+
+func (m *mockCache) GetSnapshot(ctx context.Context, codes []string) (model.RateSnapshot, error) {
+	currencies, err := m.GetMany(ctx, codes)
+	return model.RateSnapshot{Version: m.version, Currencies: currencies}, err
+}
+
+func (m *mockCache) Set(ctx context.Context, currency model.Currency, expiration time.Duration) error {
+	m.data[currency.Code] = currency
+	return nil
 }
 
-func (m *mockCache) Set(ctx context.Context, key string, value float64, expiration time.Duration) error {
-	m.data[key] = value
+func (m *mockCache) SetMany(ctx context.Context, currencies []model.Currency, expiration time.Duration) error {
+	for _, currency := range currencies {
+		m.data[currency.Code] = currency
+	}
 	return nil
 }
 
-func (m *mockCache) Delete(ctx context.Context, key string) error {
-	delete(m.data, key)
+func (m *mockCache) Delete(ctx context.Context, code string) error {
+	delete(m.data, code)
 	return nil
 }
 
+func (m *mockCache) UpdateSnapshot(ctx context.Context, currencies []model.Currency, deleted []string, expiration time.Duration) (uint64, error) {
+	for _, currency := range currencies {
+		m.data[currency.Code] = currency
+	}
+	for _, code := range deleted {
+		delete(m.data, code)
+	}
+	m.version++
+	return m.version, nil
+}
+
+func (m *mockCache) FillSnapshot(ctx context.Context, version uint64, currencies []model.Currency) (bool, error) {
+	if version == 0 || version != m.version {
+		return false, nil
+	}
+	for _, currency := range currencies {
+		if _, ok := m.data[currency.Code]; !ok {
+			m.data[currency.Code] = currency
+		}
+	}
+	return true, nil
+}
+
 func (m *mockCache) Close() error {
 	return nil
 }
+
+type mockPublisher struct {
+	events []model.RateChangeEvent
+}
+
+func (m *mockPublisher) PublishRateChanges(ctx context.Context, events []model.RateChangeEvent) error {
+	m.events = append(m.events, events...)
+	return nil
+}
+
 func TestCurrencyService_Convert(t *testing.T) {
 	repo := &mockRepository{
 		currencies: map[string]*model.Currency{
-			"USD": {Code: "USD", Rate: 1.0},
-			"EUR": {Code: "EUR", Rate: 0.85},
-			"GBP": {Code: "GBP", Rate: 0.75},
+			"USD": {Code: "USD", Rate: decimal.NewFromFloat(1.0)},
+			"EUR": {Code: "EUR", Rate: decimal.NewFromFloat(0.85)},
+			"GBP": {Code: "GBP", Rate: decimal.NewFromFloat(0.75)},
 		},
 	}
 	cache := &mockCache{
-		data: map[string]float64{
-			"USD": 1.0,
-			"EUR": 0.85,
-			"GBP": 0.75,
+		data: map[string]model.Currency{
+			"USD": {Code: "USD", Rate: decimal.NewFromFloat(1.0)},
+			"EUR": {Code: "EUR", Rate: decimal.NewFromFloat(0.85)},
+			"GBP": {Code: "GBP", Rate: decimal.NewFromFloat(0.75)},
 		},
 	}
 
@@ -105,24 +413,453 @@ func TestCurrencyService_Convert(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := currencyService.Convert(context.Background(), tt.from, tt.to, tt.amount)
+			result, err := currencyService.Convert(context.Background(), tt.from, tt.to, decimal.NewFromFloat(tt.amount))
+
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.InDelta(t, tt.expected, result.Result.InexactFloat64(), 0.01)
+			}
+		})
+	}
+}
+
+func TestCurrencyService_Convert_ZeroRate(t *testing.T) {
+	repo := &mockRepository{currencies: map[string]*model.Currency{}}
+	cache := &mockCache{
+		data: map[string]model.Currency{
+			"USD": {Code: "USD", Rate: decimal.NewFromInt(1)},
+			"ZWL": {Code: "ZWL", Rate: decimal.Zero},
+		},
+	}
+
+	currencyService := service.NewCurrencyService(repo, cache)
+
+	_, err := currencyService.Convert(context.Background(), "ZWL", "USD", decimal.NewFromInt(100))
+	assert.ErrorIs(t, err, model.ErrInvalidRate)
+
+	result, err := currencyService.Convert(context.Background(), "USD", "ZWL", decimal.NewFromInt(100))
+	assert.NoError(t, err)
+	assert.True(t, result.Result.IsZero())
+
+	_, err = currencyService.GetRateMatrix(context.Background(), []string{"USD", "ZWL"})
+	assert.ErrorIs(t, err, model.ErrInvalidRate)
+}
+
+type failingCache struct {
+	mockCache
+}
+
+func (m *failingCache) GetSnapshot(ctx context.Context, keys []string) (model.RateSnapshot, error) {
+	return model.RateSnapshot{}, errors.New("cache down")
+}
+
+type supersededCache struct {
+	mockCache
+}
+
+func (m *supersededCache) FillSnapshot(ctx context.Context, version uint64, currencies []model.Currency) (bool, error) {
+	return false, nil
+}
+
+func TestCurrencyService_Convert_RateVersion(t *testing.T) {
+	repo := &mockRepository{
+		currencies: map[string]*model.Currency{
+			"USD": {Code: "USD", Rate: decimal.NewFromInt(1)},
+			"EUR": {Code: "EUR", Rate: decimal.RequireFromString("0.8")},
+		},
+	}
+	ctx := context.Background()
+
+	t.Run("Both legs come from the current snapshot", func(t *testing.T) {
+		cache := &mockCache{data: map[string]model.Currency{"USD": {Code: "USD", Rate: decimal.NewFromInt(1)}, "EUR": {Code: "EUR", Rate: decimal.RequireFromString("0.9")}}, version: 7}
+		currencyService := service.NewCurrencyService(repo, cache)
+
+		result, err := currencyService.Convert(ctx, "USD", "EUR", decimal.NewFromInt(10))
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(7), result.Version)
+		assert.Equal(t, "9", result.Result.String())
+	})
+
+	t.Run("Missing rates fill the current snapshot without a new version", func(t *testing.T) {
+		cache := &mockCache{data: map[string]model.Currency{"USD": {Code: "USD", Rate: decimal.NewFromInt(1)}}, version: 7}
+		currencyService := service.NewCurrencyService(repo, cache)
+
+		result, err := currencyService.Convert(ctx, "USD", "EUR", decimal.NewFromInt(10))
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(7), result.Version)
+		assert.Equal(t, "8", result.Result.String())
+		assert.Equal(t, "0.8", cache.data["EUR"].Rate.String())
+		assert.Equal(t, uint64(7), cache.version)
+
+		matrix, err := currencyService.GetRateMatrix(ctx, []string{"USD", "EUR"})
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(7), matrix.Version)
+	})
+
+	t.Run("Database rates are used when the snapshot moved on", func(t *testing.T) {
+		cache := &supersededCache{mockCache{data: map[string]model.Currency{"USD": {Code: "USD", Rate: decimal.RequireFromString("2")}}, version: 7}}
+		currencyService := service.NewCurrencyService(repo, cache)
+
+		result, err := currencyService.Convert(ctx, "USD", "EUR", decimal.NewFromInt(10))
+		assert.NoError(t, err)
+		assert.Zero(t, result.Version)
+		assert.Equal(t, "8", result.Result.String())
+		assert.NotContains(t, cache.data, "EUR")
+	})
+
+	t.Run("Database rates are used when the cache is unavailable", func(t *testing.T) {
+		cache := &failingCache{mockCache{data: make(map[string]model.Currency)}}
+		currencyService := service.NewCurrencyService(repo, cache)
+
+		result, err := currencyService.Convert(ctx, "USD", "EUR", decimal.NewFromInt(10))
+		assert.NoError(t, err)
+		assert.Zero(t, result.Version)
+		assert.Equal(t, "8", result.Result.String())
+	})
+}
+
+func TestCurrencyService_Convert_Metadata(t *testing.T) {
+	repo := &mockRepository{
+		currencies: map[string]*model.Currency{
+			"USD": {Code: "USD", Rate: decimal.NewFromFloat(1.0), CurrencyMetadata: model.CurrencyMetadata{Name: "US Dollar", Symbol: "$", MinorUnits: model.MinorUnits(2), Kind: model.CurrencyKindFiat}},
+		},
+	}
+	cache := &mockCache{
+		data: map[string]model.Currency{
+			"USD":  *repo.currencies["USD"],
+			"HURB": {Code: "HURB", Rate: decimal.NewFromFloat(0.5), CurrencyMetadata: model.CurrencyMetadata{Name: "Hurb Coin", MinorUnits: model.MinorUnits(2)}},
+		},
+	}
+
+	currencyService := service.NewCurrencyService(repo, cache)
+
+	conversion, err := currencyService.Convert(context.Background(), "USD", "HURB", decimal.NewFromInt(10))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "US Dollar", conversion.From.Name)
+	assert.Equal(t, "$", conversion.From.Symbol)
+	assert.Equal(t, "HURB", conversion.To.Code)
+	assert.Equal(t, "Hurb Coin", conversion.To.Name)
+	assert.Equal(t, model.MinorUnits(2), conversion.To.MinorUnits)
+	assert.True(t, decimal.NewFromInt(5).Equal(conversion.Result))
+}
+
+func TestCurrencyService_Convert_Precision(t *testing.T) {
+	repo := &mockRepository{currencies: map[string]*model.Currency{}}
+	cache := &mockCache{
+		data: map[string]model.Currency{
+			"USD": {Code: "USD", Rate: decimal.NewFromInt(1)},
+			"BTC": {Code: "BTC", Rate: decimal.RequireFromString("0.000016")},
+			"EUR": {Code: "EUR", Rate: decimal.RequireFromString("0.1")},
+			"GBP": {Code: "GBP", Rate: decimal.RequireFromString("0.3")},
+		},
+	}
+
+	currencyService := service.NewCurrencyService(repo, cache)
+
+	conversion, err := currencyService.Convert(context.Background(), "BTC", "USD", decimal.NewFromInt(1))
+	assert.NoError(t, err)
+	assert.Equal(t, "62500", conversion.Result.String())
+
+	conversion, err = currencyService.Convert(context.Background(), "USD", "EUR", decimal.RequireFromString("0.2"))
+	assert.NoError(t, err)
+	assert.Equal(t, "0.02", conversion.Result.String())
+
+	conversion, err = currencyService.Convert(context.Background(), "GBP", "EUR", decimal.NewFromInt(3))
+	assert.NoError(t, err)
+	assert.Equal(t, "1", conversion.Result.String())
+}
+
+func TestCurrencyService_ConvertBatch(t *testing.T) {
+	repo := &mockRepository{
+		currencies: map[string]*model.Currency{
+			"USD": {Code: "USD", Rate: decimal.NewFromInt(1), CurrencyMetadata: model.CurrencyMetadata{Name: "US Dollar", MinorUnits: model.MinorUnits(2)}},
+			"BRL": {Code: "BRL", Rate: decimal.RequireFromString("5.5"), CurrencyMetadata: model.CurrencyMetadata{Name: "Brazilian Real", MinorUnits: model.MinorUnits(2)}},
+			"BTC": {Code: "BTC", Rate: decimal.RequireFromString("0.000016"), CurrencyMetadata: model.CurrencyMetadata{Name: "Bitcoin", MinorUnits: model.MinorUnits(8)}},
+		},
+	}
+	cache := &mockCache{
+		data: map[string]model.Currency{
+			"USD": *repo.currencies["USD"],
+			"BRL": *repo.currencies["BRL"],
+		},
+		version: 1,
+	}
+
+	currencyService := service.NewCurrencyService(repo, cache)
+
+	t.Run("Resolves every item", func(t *testing.T) {
+		conversions, err := currencyService.ConvertBatch(context.Background(), []model.ConversionRequest{
+			{From: "USD", To: "BRL", Amount: decimal.NewFromInt(10)},
+			{From: "USD", To: "BTC", Amount: decimal.NewFromInt(10)},
+			{From: "BRL", To: "USD", Amount: decimal.NewFromInt(11)},
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, conversions, 3)
+		assert.Equal(t, "55", conversions[0].Result.String())
+		assert.Equal(t, "Brazilian Real", conversions[0].To.Name)
+		assert.Equal(t, "0.00016", conversions[1].Result.String())
+		assert.Equal(t, model.MinorUnits(8), conversions[1].To.MinorUnits)
+		assert.Equal(t, "2", conversions[2].Result.String())
+		assert.Equal(t, "0.000016", cache.data["BTC"].Rate.String())
+	})
+
+	t.Run("Unknown currency", func(t *testing.T) {
+		_, err := currencyService.ConvertBatch(context.Background(), []model.ConversionRequest{
+			{From: "USD", To: "BRL", Amount: decimal.NewFromInt(10)},
+			{From: "USD", To: "XYZ", Amount: decimal.NewFromInt(10)},
+		})
+
+		assert.ErrorIs(t, err, model.ErrCurrencyNotFound)
+		assert.Contains(t, err.Error(), "XYZ")
+	})
+}
+
+func TestCurrencyService_Convert_Freshness(t *testing.T) {
+	now := time.Now().UTC()
+	repo := &mockRepository{
+		currencies: map[string]*model.Currency{
+			"USD":  {Code: "USD", Rate: decimal.NewFromInt(1), UpdatedAt: now.Add(-time.Hour), Source: model.CurrencySourceProvider},
+			"EUR":  {Code: "EUR", Rate: decimal.RequireFromString("0.85"), UpdatedAt: now.Add(-48 * time.Hour), Source: model.CurrencySourceProvider},
+			"GBP":  {Code: "GBP", Rate: decimal.RequireFromString("0.75"), UpdatedAt: now.Add(-48 * time.Hour), Source: model.CurrencySourceProvider, Locked: true},
+			"HURB": {Code: "HURB", Rate: decimal.RequireFromString("0.5"), UpdatedAt: now.Add(-48 * time.Hour), Source: model.CurrencySourceManual},
+		},
+	}
+	cache := &mockCache{
+		data: map[string]model.Currency{
+			"USD": *repo.currencies["USD"],
+			"EUR": {Code: "EUR", Rate: decimal.RequireFromString("0.86"), UpdatedAt: now.Add(-48 * time.Hour), Source: model.CurrencySourceProvider},
+			"XAU": {Code: "XAU", Rate: decimal.RequireFromString("0.0004")},
+		},
+	}
+
+	currencyService := service.NewCurrencyService(repo, cache, service.WithMaxRateAge(24*time.Hour))
+
+	t.Run("Legs carry rate metadata", func(t *testing.T) {
+		conversion, err := currencyService.Convert(context.Background(), "USD", "EUR", decimal.NewFromInt(100))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "USD", conversion.FromRate.Code)
+		assert.Equal(t, "1", conversion.FromRate.Rate.String())
+		assert.Equal(t, model.CurrencySourceProvider, conversion.FromRate.Source)
+		assert.True(t, now.Add(-time.Hour).Equal(*conversion.FromRate.UpdatedAt))
+		assert.False(t, conversion.FromRate.Stale)
+		assert.Equal(t, "0.86", conversion.ToRate.Rate.String())
+		assert.True(t, conversion.ToRate.Stale)
+		assert.True(t, conversion.Stale)
+		assert.Equal(t, []string{"EUR"}, conversion.StaleCodes())
+	})
+
+	t.Run("Locked and manual rates never go stale", func(t *testing.T) {
+		conversion, err := currencyService.Convert(context.Background(), "GBP", "HURB", decimal.NewFromInt(1))
+
+		assert.NoError(t, err)
+		assert.False(t, conversion.Stale)
+		assert.Equal(t, model.CurrencySourceManual, conversion.ToRate.Source)
+	})
+
+	t.Run("Cached rate without a stored currency", func(t *testing.T) {
+		conversion, err := currencyService.Convert(context.Background(), "USD", "XAU", decimal.NewFromInt(1))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "0.0004", conversion.ToRate.Rate.String())
+		assert.Nil(t, conversion.ToRate.UpdatedAt)
+		assert.False(t, conversion.Stale)
+	})
+
+	t.Run("Disabled without a max age", func(t *testing.T) {
+		conversion, err := service.NewCurrencyService(repo, cache).Convert(context.Background(), "USD", "EUR", decimal.NewFromInt(100))
+
+		assert.NoError(t, err)
+		assert.NotNil(t, conversion.ToRate.UpdatedAt)
+		assert.False(t, conversion.Stale)
+	})
+}
+
+func TestCurrencyService_GetRateMatrix(t *testing.T) {
+	repo := &mockRepository{
+		currencies: map[string]*model.Currency{
+			"BRL": {Code: "BRL", Rate: decimal.RequireFromString("5")},
+		},
+	}
+	cache := &mockCache{
+		data: map[string]model.Currency{
+			"USD": {Code: "USD", Rate: decimal.NewFromInt(1)},
+			"EUR": {Code: "EUR", Rate: decimal.RequireFromString("0.8")},
+		},
+	}
+
+	currencyService := service.NewCurrencyService(repo, cache)
+
+	matrix, err := currencyService.GetRateMatrix(context.Background(), []string{"USD", "EUR", "BRL"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"USD", "EUR", "BRL"}, matrix.Codes)
+	assert.Equal(t, "1", matrix.Rates["USD"]["USD"].String())
+	assert.Equal(t, "0.8", matrix.Rates["USD"]["EUR"].String())
+	assert.Equal(t, "1.25", matrix.Rates["EUR"]["USD"].String())
+	assert.Equal(t, "6.25", matrix.Rates["EUR"]["BRL"].String())
+	assert.Equal(t, "0.16", matrix.Rates["BRL"]["EUR"].String())
+	assert.False(t, matrix.GeneratedAt.IsZero())
+
+	_, err = currencyService.GetRateMatrix(context.Background(), []string{"USD", "XYZ"})
+	assert.ErrorIs(t, err, model.ErrCurrencyNotFound)
+}
+
+func TestCurrencyService_ConvertAt(t *testing.T) {
+	day := time.Date(2024, 8, 10, 0, 0, 0, 0, time.UTC)
+	repo := &mockRepository{
+		currencies: map[string]*model.Currency{},
+		history: map[string][]model.RateHistory{
+			"USD": {
+				{Code: "USD", Rate: decimal.NewFromFloat(1.0), RecordedAt: day.Add(-48 * time.Hour)},
+			},
+			"EUR": {
+				{Code: "EUR", Rate: decimal.NewFromFloat(0.80), RecordedAt: day.Add(-48 * time.Hour)},
+				{Code: "EUR", Rate: decimal.NewFromFloat(0.85), RecordedAt: day.Add(12 * time.Hour)},
+				{Code: "EUR", Rate: decimal.NewFromFloat(0.90), RecordedAt: day.Add(36 * time.Hour)},
+			},
+		},
+	}
+	cache := &mockCache{data: map[string]model.Currency{}}
+
+	currencyService := service.NewCurrencyService(repo, cache)
+
+	tests := []struct {
+		name          string
+		from          string
+		to            string
+		amount        float64
+		at            time.Time
+		expected      float64
+		expectedError bool
+	}{
+		{"Rate from previous day", "USD", "EUR", 100, day, 80, false},
+		{"Rate recorded during the day", "USD", "EUR", 100, day.AddDate(0, 0, 1), 85, false},
+		{"Latest rate", "EUR", "USD", 90, day.AddDate(0, 0, 2), 100, false},
+		{"No rate recorded yet", "USD", "EUR", 100, day.AddDate(0, 0, -3), 0, true},
+		{"Unknown currency", "USD", "GBP", 100, day, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := currencyService.ConvertAt(context.Background(), tt.from, tt.to, decimal.NewFromFloat(tt.amount), tt.at)
 
 			if tt.expectedError {
 				assert.Error(t, err)
+				assert.ErrorIs(t, err, model.ErrCurrencyNotFound)
 			} else {
 				assert.NoError(t, err)
-				assert.InDelta(t, tt.expected, result, 0.01)
+				assert.InDelta(t, tt.expected, result.Result.InexactFloat64(), 0.01)
+				assert.True(t, result.ToRate.UpdatedAt.Before(tt.at))
+				assert.False(t, result.Stale)
 			}
 		})
 	}
 }
 
+func TestCurrencyService_ConvertBatchAt(t *testing.T) {
+	day := time.Date(2024, 8, 10, 0, 0, 0, 0, time.UTC)
+	repo := &mockRepository{
+		currencies: map[string]*model.Currency{
+			"EUR": {Code: "EUR", CurrencyMetadata: model.CurrencyMetadata{Name: "Euro"}},
+		},
+		history: map[string][]model.RateHistory{
+			"USD": {{Code: "USD", Rate: decimal.NewFromInt(1), RecordedAt: day.Add(-time.Hour)}},
+			"EUR": {{Code: "EUR", Rate: decimal.RequireFromString("0.8"), RecordedAt: day.Add(-time.Hour)}},
+			"GBP": {{Code: "GBP", Rate: decimal.RequireFromString("0.75"), RecordedAt: day.Add(-time.Hour)}},
+		},
+	}
+	currencyService := service.NewCurrencyService(repo, &mockCache{data: map[string]model.Currency{}})
+
+	conversions, err := currencyService.ConvertBatchAt(context.Background(), []model.ConversionRequest{
+		{From: "USD", To: "EUR", Amount: decimal.NewFromInt(100)},
+		{From: "USD", To: "GBP", Amount: decimal.NewFromInt(100)},
+	}, day)
+
+	require.NoError(t, err)
+	require.Len(t, conversions, 2)
+	assert.Equal(t, "80", conversions[0].Result.String())
+	assert.Equal(t, "Euro", conversions[0].To.Name)
+	assert.Equal(t, "75", conversions[1].Result.String())
+	assert.Equal(t, "USD", conversions[1].FromRate.Code)
+	assert.Equal(t, 1, repo.ratesBeforeCalls)
+
+	_, err = currencyService.ConvertBatchAt(context.Background(), []model.ConversionRequest{
+		{From: "USD", To: "JPY", Amount: decimal.NewFromInt(100)},
+	}, day)
+	assert.ErrorIs(t, err, model.ErrCurrencyNotFound)
+}
+
+func TestCurrencyService_GetRateHistory(t *testing.T) {
+	day := time.Date(2024, 8, 10, 0, 0, 0, 0, time.UTC)
+	repo := &mockRepository{
+		currencies: map[string]*model.Currency{
+			"EUR": {Code: "EUR", Rate: decimal.NewFromFloat(0.85)},
+		},
+		history: map[string][]model.RateHistory{
+			"EUR": {
+				{Code: "EUR", Rate: decimal.NewFromFloat(0.80), RecordedAt: day.Add(-48 * time.Hour)},
+				{Code: "EUR", Rate: decimal.NewFromFloat(0.85), RecordedAt: day.Add(12 * time.Hour)},
+			},
+		},
+	}
+	currencyService := service.NewCurrencyService(repo, &mockCache{data: map[string]model.Currency{}})
+
+	history, err := currencyService.GetRateHistory(context.Background(), "EUR", day, day.AddDate(0, 0, 1))
+
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	assert.True(t, decimal.NewFromFloat(0.85).Equal(history[0].Rate))
+
+	_, err = currencyService.GetRateHistory(context.Background(), "XYZ", day, day.AddDate(0, 0, 1))
+	assert.ErrorIs(t, err, model.ErrCurrencyNotFound)
+}
+
+func TestCurrencyService_GetCurrency(t *testing.T) {
+	repo := &mockRepository{
+		currencies: map[string]*model.Currency{
+			"EUR": {Code: "EUR", Rate: decimal.NewFromFloat(0.85)},
+		},
+	}
+	currencyService := service.NewCurrencyService(repo, &mockCache{data: map[string]model.Currency{}})
+
+	currency, err := currencyService.GetCurrency(context.Background(), "EUR")
+	assert.NoError(t, err)
+	assert.True(t, decimal.NewFromFloat(0.85).Equal(currency.Rate))
+
+	_, err = currencyService.GetCurrency(context.Background(), "GBP")
+	assert.Error(t, err)
+}
+
+func TestCurrencyService_ListCurrencies(t *testing.T) {
+	repo := &mockRepository{
+		currencies: map[string]*model.Currency{
+			"USD": {Code: "USD", Rate: decimal.NewFromFloat(1.0)},
+			"EUR": {Code: "EUR", Rate: decimal.NewFromFloat(0.85)},
+			"GBP": {Code: "GBP", Rate: decimal.NewFromFloat(0.75)},
+		},
+	}
+	currencyService := service.NewCurrencyService(repo, &mockCache{data: map[string]model.Currency{}})
+
+	currencies, total, err := currencyService.ListCurrencies(context.Background(), model.CurrencyListOptions{Limit: 2, Offset: 1})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Len(t, currencies, 2)
+	assert.Equal(t, "GBP", currencies[0].Code)
+	assert.Equal(t, "USD", currencies[1].Code)
+}
+
 func TestCurrencyService_AddCurrency(t *testing.T) {
 	repo := &mockRepository{
 		currencies: make(map[string]*model.Currency),
 	}
 	cache := &mockCache{
-		data: make(map[string]float64),
+		data: make(map[string]model.Currency),
 	}
 
 	currencyService := service.NewCurrencyService(repo, cache)
@@ -133,7 +870,7 @@ func TestCurrencyService_AddCurrency(t *testing.T) {
 	t.Run("Add new currency", func(t *testing.T) {
 		newCurrency := &model.Currency{
 			Code:      "JPY",
-			Rate:      110.0,
+			Rate:      decimal.NewFromFloat(110.0),
 			CreatedBy: userID,
 			UpdatedBy: userID,
 			CreatedAt: time.Now(),
@@ -144,13 +881,15 @@ func TestCurrencyService_AddCurrency(t *testing.T) {
 
 		assert.NoError(t, err)
 		assert.Equal(t, newCurrency, repo.currencies["JPY"])
-		assert.Equal(t, 110.0, cache.data["JPY"])
+		assert.Equal(t, model.CurrencySourceManual, repo.currencies["JPY"].Source)
+		assert.True(t, repo.currencies["JPY"].Locked)
+		assert.True(t, decimal.NewFromFloat(110.0).Equal(cache.data["JPY"].Rate))
 	})
 
 	t.Run("Add existing currency", func(t *testing.T) {
 		existingCurrency := &model.Currency{
 			Code:      "USD",
-			Rate:      1.0,
+			Rate:      decimal.NewFromFloat(1.0),
 			CreatedBy: userID,
 			UpdatedBy: userID,
 			CreatedAt: time.Now(),
@@ -160,7 +899,7 @@ func TestCurrencyService_AddCurrency(t *testing.T) {
 
 		newCurrency := &model.Currency{
 			Code:      "USD",
-			Rate:      1.1,
+			Rate:      decimal.NewFromFloat(1.1),
 			CreatedBy: userID,
 			UpdatedBy: userID,
 			CreatedAt: time.Now(),
@@ -179,7 +918,7 @@ func TestCurrencyService_UpdateCurrency(t *testing.T) {
 		currencies: make(map[string]*model.Currency),
 	}
 	cache := &mockCache{
-		data: make(map[string]float64),
+		data: make(map[string]model.Currency),
 	}
 
 	currencyService := service.NewCurrencyService(repo, cache)
@@ -190,7 +929,7 @@ func TestCurrencyService_UpdateCurrency(t *testing.T) {
 	t.Run("Update existing currency", func(t *testing.T) {
 		existingCurrency := &model.Currency{
 			Code:      "EUR",
-			Rate:      0.85,
+			Rate:      decimal.NewFromFloat(0.85),
 			CreatedBy: uuid.New(),
 			UpdatedBy: uuid.New(),
 			CreatedAt: time.Now().Add(-24 * time.Hour),
@@ -200,35 +939,220 @@ func TestCurrencyService_UpdateCurrency(t *testing.T) {
 
 		originalUpdatedAt := existingCurrency.UpdatedAt
 
-		err := currencyService.UpdateCurrency(ctx, "EUR", 0.82, userID)
+		err := currencyService.UpdateCurrency(ctx, "EUR", model.CurrencyUpdate{Rate: rateOf(decimal.NewFromFloat(0.82))}, userID)
 
 		assert.NoError(t, err)
 		updatedCurrency := repo.currencies["EUR"]
-		assert.Equal(t, 0.82, updatedCurrency.Rate)
+		assert.True(t, decimal.NewFromFloat(0.82).Equal(updatedCurrency.Rate))
 		assert.Equal(t, userID, updatedCurrency.UpdatedBy)
+		assert.Equal(t, model.CurrencySourceManual, updatedCurrency.Source)
+		assert.True(t, updatedCurrency.Locked)
 		assert.True(t, updatedCurrency.UpdatedAt.After(originalUpdatedAt), "UpdatedAt should be later than the original time")
-		assert.Equal(t, 0.82, cache.data["EUR"])
+		assert.True(t, decimal.NewFromFloat(0.82).Equal(cache.data["EUR"].Rate))
+	})
+
+	t.Run("Update metadata", func(t *testing.T) {
+		repo.currencies["BTC"] = &model.Currency{
+			Code: "BTC",
+			Rate: decimal.NewFromFloat(0.00002),
+			CurrencyMetadata: model.CurrencyMetadata{
+				Name:       "Bitcoin",
+				MinorUnits: model.MinorUnits(2),
+				Countries:  []string{},
+				Kind:       model.CurrencyKindFiat,
+			},
+		}
+
+		symbol := "₿"
+		minorUnits := 8
+		kind := model.CurrencyKindCrypto
+		err := currencyService.UpdateCurrency(ctx, "BTC", model.CurrencyUpdate{
+			Rate:       rateOf(decimal.NewFromFloat(0.000016)),
+			Symbol:     &symbol,
+			MinorUnits: &minorUnits,
+			Kind:       &kind,
+		}, userID)
+
+		assert.NoError(t, err)
+		updatedCurrency := repo.currencies["BTC"]
+		assert.True(t, decimal.NewFromFloat(0.000016).Equal(updatedCurrency.Rate))
+		assert.Equal(t, "Bitcoin", updatedCurrency.Name)
+		assert.Equal(t, "₿", updatedCurrency.Symbol)
+		assert.Equal(t, model.MinorUnits(8), updatedCurrency.MinorUnits)
+		assert.Equal(t, model.CurrencyKindCrypto, updatedCurrency.Kind)
+	})
+
+	t.Run("Update metadata only", func(t *testing.T) {
+		updatedAt := time.Now().Add(-time.Hour)
+		repo.currencies["JPY"] = &model.Currency{
+			Code:      "JPY",
+			Rate:      decimal.RequireFromString("150"),
+			UpdatedAt: updatedAt,
+			Source:    model.CurrencySourceProvider,
+			Providers: []string{"ecb"},
+		}
+
+		name := "Japanese Yen"
+		err := currencyService.UpdateCurrency(ctx, "JPY", model.CurrencyUpdate{Name: &name}, userID)
+
+		assert.NoError(t, err)
+		updatedCurrency := repo.currencies["JPY"]
+		assert.Equal(t, "150", updatedCurrency.Rate.String())
+		assert.Equal(t, "Japanese Yen", updatedCurrency.Name)
+		assert.Equal(t, model.CurrencySourceProvider, updatedCurrency.Source)
+		assert.False(t, updatedCurrency.Locked)
+		assert.Equal(t, updatedAt, updatedCurrency.UpdatedAt)
+		assert.Equal(t, "Japanese Yen", cache.data["JPY"].Name)
 	})
 
 	t.Run("Update non-existing currency", func(t *testing.T) {
-		err := currencyService.UpdateCurrency(ctx, "GBP", 0.75, userID)
+		err := currencyService.UpdateCurrency(ctx, "GBP", model.CurrencyUpdate{Rate: rateOf(decimal.NewFromFloat(0.75))}, userID)
 
 		assert.Error(t, err)
 		assert.NotContains(t, repo.currencies, "GBP")
 		assert.NotContains(t, cache.data, "GBP")
 	})
 }
+
+func rateOf(rate decimal.Decimal) *decimal.Decimal {
+	return &rate
+}
+
+func TestCurrencyService_SetCurrencyLock(t *testing.T) {
+	repo := &mockRepository{
+		currencies: map[string]*model.Currency{
+			"ARS": {Code: "ARS", Rate: decimal.NewFromInt(900), Source: model.CurrencySourceManual, Locked: true},
+		},
+	}
+	currencyService := service.NewCurrencyService(repo, &mockCache{data: make(map[string]model.Currency)})
+
+	ctx := context.Background()
+
+	err := currencyService.SetCurrencyLock(ctx, "ARS", false)
+	assert.NoError(t, err)
+	assert.False(t, repo.currencies["ARS"].Locked)
+
+	err = currencyService.SetCurrencyLock(ctx, "XYZ", true)
+	assert.ErrorIs(t, err, model.ErrCurrencyNotFound)
+}
+
+func TestCurrencyService_ScheduledRateChanges(t *testing.T) {
+	repo := &mockRepository{
+		currencies: map[string]*model.Currency{
+			"HURB": {Code: "HURB", Rate: decimal.NewFromInt(2)},
+		},
+	}
+	currencyService := service.NewCurrencyService(repo, &mockCache{data: make(map[string]model.Currency)})
+
+	ctx := context.Background()
+	effectiveAt := time.Now().Add(24 * time.Hour).UTC()
+
+	change := &model.ScheduledRateChange{Code: "HURB", Rate: decimal.NewFromInt(4), EffectiveAt: effectiveAt, CreatedBy: uuid.New()}
+	err := currencyService.ScheduleRateChange(ctx, change)
+	assert.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, change.ID)
+	assert.False(t, change.CreatedAt.IsZero())
+	assert.True(t, decimal.NewFromInt(2).Equal(repo.currencies["HURB"].Rate))
+
+	err = currencyService.ScheduleRateChange(ctx, &model.ScheduledRateChange{Code: "XYZ", Rate: decimal.NewFromInt(1), EffectiveAt: effectiveAt})
+	assert.ErrorIs(t, err, model.ErrCurrencyNotFound)
+
+	changes, err := currencyService.ListScheduledRateChanges(ctx, "HURB")
+	assert.NoError(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, change.ID, changes[0].ID)
+
+	err = currencyService.CancelScheduledRateChange(ctx, change.ID)
+	assert.NoError(t, err)
+	changes, err = currencyService.ListScheduledRateChanges(ctx, "")
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	err = currencyService.CancelScheduledRateChange(ctx, change.ID)
+	assert.ErrorIs(t, err, model.ErrScheduledChangeNotFound)
+}
+
+func TestCurrencyService_SetJumpThreshold(t *testing.T) {
+	repo := &mockRepository{
+		currencies: map[string]*model.Currency{
+			"ARS": {Code: "ARS", Rate: decimal.NewFromInt(900)},
+		},
+	}
+	currencyService := service.NewCurrencyService(repo, &mockCache{data: make(map[string]model.Currency)})
+
+	ctx := context.Background()
+	threshold := decimal.RequireFromString("0.5")
+
+	err := currencyService.SetJumpThreshold(ctx, "ARS", &threshold)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.5", repo.currencies["ARS"].JumpThreshold.String())
+
+	err = currencyService.SetJumpThreshold(ctx, "ARS", nil)
+	assert.NoError(t, err)
+	assert.Nil(t, repo.currencies["ARS"].JumpThreshold)
+
+	err = currencyService.SetJumpThreshold(ctx, "XYZ", nil)
+	assert.ErrorIs(t, err, model.ErrCurrencyNotFound)
+}
+
+func TestCurrencyService_QuarantinedRates(t *testing.T) {
+	approved := model.QuarantinedRate{ID: uuid.New(), Code: "ARS", PreviousRate: decimal.NewFromInt(900), Rate: decimal.NewFromInt(1350), RecordedAt: time.Now().UTC()}
+	rejected := model.QuarantinedRate{ID: uuid.New(), Code: "EUR", PreviousRate: decimal.RequireFromString("0.85"), Rate: decimal.RequireFromString("1.7")}
+	repo := &mockRepository{
+		currencies: map[string]*model.Currency{
+			"ARS":  {Code: "ARS", Rate: decimal.NewFromInt(900)},
+			"EUR":  {Code: "EUR", Rate: decimal.RequireFromString("0.85")},
+			"PESO": {Code: "PESO", Rate: decimal.NewFromInt(450)},
+		},
+		pegs: map[string]model.CurrencyPeg{
+			"PESO": {Code: "PESO", Anchor: "ARS", Ratio: decimal.NewFromInt(2)},
+		},
+		quarantine: []model.QuarantinedRate{approved, rejected},
+	}
+	cache := &mockCache{data: make(map[string]model.Currency)}
+	currencyService := service.NewCurrencyService(repo, cache)
+
+	ctx := context.Background()
+	approvedBy := uuid.New()
+
+	rates, err := currencyService.ListQuarantinedRates(ctx, "ARS")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.QuarantinedRate{approved}, rates)
+
+	rate, err := currencyService.ApproveQuarantinedRate(ctx, approved.ID, approvedBy)
+	assert.NoError(t, err)
+	assert.Equal(t, approved.ID, rate.ID)
+	assert.True(t, decimal.NewFromInt(1350).Equal(repo.currencies["ARS"].Rate))
+	assert.Equal(t, approvedBy, repo.currencies["ARS"].UpdatedBy)
+	assert.True(t, decimal.NewFromInt(1350).Equal(cache.data["ARS"].Rate))
+	assert.True(t, decimal.NewFromInt(675).Equal(repo.currencies["PESO"].Rate))
+
+	_, err = currencyService.ApproveQuarantinedRate(ctx, approved.ID, approvedBy)
+	assert.ErrorIs(t, err, model.ErrQuarantinedRateNotFound)
+
+	err = currencyService.RejectQuarantinedRate(ctx, rejected.ID)
+	assert.NoError(t, err)
+	assert.True(t, decimal.RequireFromString("0.85").Equal(repo.currencies["EUR"].Rate))
+
+	rates, err = currencyService.ListQuarantinedRates(ctx, "")
+	assert.NoError(t, err)
+	assert.Empty(t, rates)
+
+	err = currencyService.RejectQuarantinedRate(ctx, rejected.ID)
+	assert.ErrorIs(t, err, model.ErrQuarantinedRateNotFound)
+}
+
 func TestCurrencyService_RemoveCurrency(t *testing.T) {
 	repo := &mockRepository{
 		currencies: map[string]*model.Currency{
-			"USD": {Code: "USD", Rate: 1.0},
-			"EUR": {Code: "EUR", Rate: 0.85},
+			"USD": {Code: "USD", Rate: decimal.NewFromFloat(1.0)},
+			"EUR": {Code: "EUR", Rate: decimal.NewFromFloat(0.85)},
 		},
 	}
 	cache := &mockCache{
-		data: map[string]float64{
-			"USD": 1.0,
-			"EUR": 0.85,
+		data: map[string]model.Currency{
+			"USD": {Code: "USD", Rate: decimal.NewFromFloat(1.0)},
+			"EUR": {Code: "EUR", Rate: decimal.NewFromFloat(0.85)},
 		},
 	}
 
@@ -267,3 +1191,122 @@ func TestCurrencyService_RemoveCurrency(t *testing.T) {
 		})
 	}
 }
+
+func TestCurrencyService_Pegs(t *testing.T) {
+	repo := &mockRepository{
+		currencies: map[string]*model.Currency{
+			"USD": {Code: "USD", Rate: decimal.NewFromInt(1)},
+			"EUR": {Code: "EUR", Rate: decimal.RequireFromString("0.8")},
+		},
+	}
+	cache := &mockCache{data: make(map[string]model.Currency)}
+
+	currencyService := service.NewCurrencyService(repo, cache)
+
+	ctx := context.Background()
+	userID := uuid.New()
+
+	t.Run("Add pegged currency", func(t *testing.T) {
+		err := currencyService.AddCurrency(ctx, &model.Currency{
+			Code: "HURB",
+			Peg:  &model.CurrencyPeg{Anchor: "EUR", Ratio: decimal.RequireFromString("0.5")},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "1.6", repo.currencies["HURB"].Rate.String())
+		assert.Equal(t, "1.6", cache.data["HURB"].Rate.String())
+		assert.Equal(t, model.CurrencySourceManual, repo.currencies["HURB"].Source)
+		assert.False(t, repo.currencies["HURB"].Locked)
+
+		currency, err := currencyService.GetCurrency(ctx, "HURB")
+		assert.NoError(t, err)
+		assert.Equal(t, "EUR", currency.Peg.Anchor)
+	})
+
+	t.Run("Anchor update recomputes pegged rate", func(t *testing.T) {
+		err := currencyService.UpdateCurrency(ctx, "EUR", model.CurrencyUpdate{Rate: rateOf(decimal.RequireFromString("0.9"))}, userID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "1.8", repo.currencies["HURB"].Rate.String())
+		assert.Equal(t, "1.8", cache.data["HURB"].Rate.String())
+		assert.Equal(t, userID, repo.currencies["HURB"].UpdatedBy)
+	})
+
+	t.Run("Invalid pegs", func(t *testing.T) {
+		err := currencyService.AddCurrency(ctx, &model.Currency{
+			Code: "GOLD",
+			Peg:  &model.CurrencyPeg{Anchor: "HURB", Ratio: decimal.NewFromInt(2)},
+		})
+		assert.ErrorIs(t, err, model.ErrInvalidPeg)
+
+		err = currencyService.AddCurrency(ctx, &model.Currency{
+			Code: "GOLD",
+			Peg:  &model.CurrencyPeg{Anchor: "XYZ", Ratio: decimal.NewFromInt(2)},
+		})
+		assert.ErrorIs(t, err, model.ErrInvalidPeg)
+
+		err = currencyService.UpdateCurrency(ctx, "EUR", model.CurrencyUpdate{
+			Peg: &model.CurrencyPeg{Anchor: "USD", Ratio: decimal.NewFromInt(1)},
+		}, userID)
+		assert.ErrorIs(t, err, model.ErrInvalidPeg)
+	})
+
+	t.Run("Anchor cannot be removed", func(t *testing.T) {
+		err := currencyService.RemoveCurrency(ctx, "EUR")
+
+		assert.ErrorIs(t, err, model.ErrCurrencyInUse)
+		assert.Contains(t, repo.currencies, "EUR")
+	})
+
+	t.Run("Metadata update keeps the peg", func(t *testing.T) {
+		name := "Hurb Coin"
+		err := currencyService.UpdateCurrency(ctx, "HURB", model.CurrencyUpdate{Name: &name}, userID)
+
+		assert.NoError(t, err)
+		assert.Contains(t, repo.pegs, "HURB")
+		assert.Equal(t, "1.8", repo.currencies["HURB"].Rate.String())
+		assert.Equal(t, "Hurb Coin", repo.currencies["HURB"].Name)
+	})
+
+	t.Run("Setting a rate removes the peg", func(t *testing.T) {
+		err := currencyService.UpdateCurrency(ctx, "HURB", model.CurrencyUpdate{Rate: rateOf(decimal.NewFromInt(3))}, userID)
+
+		assert.NoError(t, err)
+		assert.NotContains(t, repo.pegs, "HURB")
+		assert.True(t, repo.currencies["HURB"].Locked)
+		assert.NoError(t, currencyService.RemoveCurrency(ctx, "EUR"))
+	})
+}
+
+func TestCurrencyService_PublishesRateChanges(t *testing.T) {
+	repo := &mockRepository{
+		currencies: map[string]*model.Currency{
+			"USD": {Code: "USD", Rate: decimal.NewFromInt(1)},
+			"EUR": {Code: "EUR", Rate: decimal.RequireFromString("0.8")},
+		},
+		pegs: map[string]model.CurrencyPeg{
+			"HURB": {Code: "HURB", Anchor: "EUR", Ratio: decimal.RequireFromString("0.5")},
+		},
+	}
+	repo.currencies["HURB"] = &model.Currency{Code: "HURB", Rate: decimal.RequireFromString("1.6")}
+	publisher := &mockPublisher{}
+
+	currencyService := service.NewCurrencyService(repo, &mockCache{data: make(map[string]model.Currency)}, service.WithRateChangePublisher(publisher))
+	ctx := context.Background()
+
+	err := currencyService.UpdateCurrency(ctx, "EUR", model.CurrencyUpdate{Rate: rateOf(decimal.RequireFromString("0.9"))}, uuid.New())
+	assert.NoError(t, err)
+
+	assert.Len(t, publisher.events, 2)
+	assert.Equal(t, "EUR", publisher.events[0].Code)
+	assert.Equal(t, "0.9", publisher.events[0].Rate.String())
+	assert.Equal(t, uint64(1), publisher.events[0].Version)
+	assert.Equal(t, "HURB", publisher.events[1].Code)
+	assert.Equal(t, "1.8", publisher.events[1].Rate.String())
+	assert.Equal(t, uint64(2), publisher.events[1].Version)
+	assert.False(t, publisher.events[1].ChangedAt.IsZero())
+
+	publisher.events = nil
+	assert.NoError(t, currencyService.RemoveCurrency(ctx, "USD"))
+	assert.Equal(t, []model.RateChangeEvent{{Code: "USD", Deleted: true, Version: 3, ChangedAt: publisher.events[0].ChangedAt}}, publisher.events)
+}
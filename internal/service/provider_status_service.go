@@ -0,0 +1,25 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Lutefd/challenge-bravo/internal/model"
+	"github.com/Lutefd/challenge-bravo/internal/repository"
+)
+
+type ProviderStatusService struct {
+	statusRepo repository.ProviderStatusRepository
+}
+
+func NewProviderStatusService(statusRepo repository.ProviderStatusRepository) *ProviderStatusService {
+	return &ProviderStatusService{statusRepo: statusRepo}
+}
+
+func (s *ProviderStatusService) ListProviderStatuses(ctx context.Context) ([]model.ProviderStatus, error) {
+	statuses, err := s.statusRepo.ListStatuses(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list provider statuses: %w", err)
+	}
+	return statuses, nil
+}
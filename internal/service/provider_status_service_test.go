@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Lutefd/challenge-bravo/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockProviderStatusRepository struct {
+	mock.Mock
+}
+
+func (m *MockProviderStatusRepository) SaveStatus(ctx context.Context, status model.ProviderStatus) error {
+	args := m.Called(ctx, status)
+	return args.Error(0)
+}
+
+func (m *MockProviderStatusRepository) ListStatuses(ctx context.Context) ([]model.ProviderStatus, error) {
+	args := m.Called(ctx)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.ProviderStatus), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockProviderStatusRepository) Close() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func TestProviderStatusService_ListProviderStatuses(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := new(MockProviderStatusRepository)
+		service := NewProviderStatusService(mockRepo)
+		expected := []model.ProviderStatus{{Provider: "ecb", State: model.CircuitStateOpen, ConsecutiveFailures: 3}}
+		mockRepo.On("ListStatuses", ctx).Return(expected, nil)
+
+		statuses, err := service.ListProviderStatuses(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, statuses)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Repository error", func(t *testing.T) {
+		mockRepo := new(MockProviderStatusRepository)
+		service := NewProviderStatusService(mockRepo)
+		mockRepo.On("ListStatuses", ctx).Return(nil, errors.New("database error"))
+
+		statuses, err := service.ListProviderStatuses(ctx)
+
+		assert.Nil(t, statuses)
+		assert.ErrorContains(t, err, "failed to list provider statuses")
+	})
+}
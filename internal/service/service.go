@@ -2,15 +2,32 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/Lutefd/challenge-bravo/internal/model"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 type CurrencyServiceInterface interface {
-	Convert(ctx context.Context, from, to string, amount float64) (float64, error)
+	Convert(ctx context.Context, from, to string, amount decimal.Decimal) (*model.Conversion, error)
+	ConvertBatch(ctx context.Context, requests []model.ConversionRequest) ([]model.Conversion, error)
+	ConvertAt(ctx context.Context, from, to string, amount decimal.Decimal, at time.Time) (*model.Conversion, error)
+	ConvertBatchAt(ctx context.Context, requests []model.ConversionRequest, at time.Time) ([]model.Conversion, error)
+	GetRateMatrix(ctx context.Context, codes []string) (*model.RateMatrix, error)
+	GetRateHistory(ctx context.Context, code string, from, to time.Time) ([]model.RateHistory, error)
+	GetCurrency(ctx context.Context, code string) (*model.Currency, error)
+	ListCurrencies(ctx context.Context, opts model.CurrencyListOptions) ([]model.Currency, int, error)
 	AddCurrency(ctx context.Context, currency *model.Currency) error
-	UpdateCurrency(ctx context.Context, code string, rate float64, updatedBy uuid.UUID) error
+	UpdateCurrency(ctx context.Context, code string, update model.CurrencyUpdate, updatedBy uuid.UUID) error
+	SetCurrencyLock(ctx context.Context, code string, locked bool) error
+	ScheduleRateChange(ctx context.Context, change *model.ScheduledRateChange) error
+	ListScheduledRateChanges(ctx context.Context, code string) ([]model.ScheduledRateChange, error)
+	CancelScheduledRateChange(ctx context.Context, id uuid.UUID) error
+	SetJumpThreshold(ctx context.Context, code string, threshold *decimal.Decimal) error
+	ListQuarantinedRates(ctx context.Context, code string) ([]model.QuarantinedRate, error)
+	ApproveQuarantinedRate(ctx context.Context, id, approvedBy uuid.UUID) (*model.QuarantinedRate, error)
+	RejectQuarantinedRate(ctx context.Context, id uuid.UUID) error
 	RemoveCurrency(ctx context.Context, code string) error
 }
 
@@ -21,3 +38,7 @@ type UserServiceInterface interface {
 	Create(ctx context.Context, username, password string) (model.User, error)
 	Delete(ctx context.Context, username string) error
 }
+
+type ProviderStatusServiceInterface interface {
+	ListProviderStatuses(ctx context.Context) ([]model.ProviderStatus, error)
+}
@@ -0,0 +1,14 @@
+package storage
+
+import (
+	"github.com/Lutefd/challenge-bravo/internal/commons"
+	"github.com/Lutefd/challenge-bravo/internal/repository"
+)
+
+func Open(config commons.Config) (repository.Config, error) {
+	return repository.OpenStorage(repository.Config{
+		Driver:       config.StorageDriver,
+		PostgresConn: config.PostgresConn,
+		SQLitePath:   config.SQLitePath,
+	})
+}
@@ -0,0 +1,153 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Lutefd/challenge-bravo/internal/logger"
+	"github.com/Lutefd/challenge-bravo/internal/model"
+	"github.com/Lutefd/challenge-bravo/internal/repository"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type CircuitBreaker struct {
+	name             string
+	client           ExternalAPIClient
+	statusRepo       repository.ProviderStatusRepository
+	failureThreshold int
+	coolDown         time.Duration
+	now              func() time.Time
+
+	mu       sync.Mutex
+	state    model.CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func NewCircuitBreaker(name string, client ExternalAPIClient, statusRepo repository.ProviderStatusRepository, failureThreshold int, coolDown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		name:             name,
+		client:           client,
+		statusRepo:       statusRepo,
+		failureThreshold: failureThreshold,
+		coolDown:         coolDown,
+		now:              time.Now,
+		state:            model.CircuitStateClosed,
+	}
+}
+
+func (cb *CircuitBreaker) FetchRates(ctx context.Context) (*model.ExchangeRates, error) {
+	if !cb.allow(ctx) {
+		return nil, fmt.Errorf("rate provider %s: %w", cb.name, ErrCircuitOpen)
+	}
+
+	rates, err := cb.client.FetchRates(ctx)
+	cb.record(ctx, err)
+	return rates, err
+}
+
+func (cb *CircuitBreaker) FetchHistoricalRates(ctx context.Context, date time.Time) (*model.ExchangeRates, error) {
+	historical, ok := cb.client.(HistoricalRatesClient)
+	if !ok {
+		return nil, ErrHistoricalRatesUnsupported
+	}
+	if !cb.allow(ctx) {
+		return nil, fmt.Errorf("rate provider %s: %w", cb.name, ErrCircuitOpen)
+	}
+
+	rates, err := historical.FetchHistoricalRates(ctx, date)
+	cb.record(ctx, err)
+	return rates, err
+}
+
+func (cb *CircuitBreaker) Status() model.ProviderStatus {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.status()
+}
+
+func (cb *CircuitBreaker) allow(ctx context.Context) bool {
+	cb.mu.Lock()
+	if cb.state == model.CircuitStateClosed {
+		cb.mu.Unlock()
+		return true
+	}
+	if cb.probing {
+		cb.mu.Unlock()
+		logger.Infof("skipping rate provider %s: circuit half-open with a probe in flight", cb.name)
+		return false
+	}
+	if cb.state == model.CircuitStateOpen && cb.now().Sub(cb.openedAt) < cb.coolDown {
+		openUntil := cb.openedAt.Add(cb.coolDown)
+		cb.mu.Unlock()
+		logger.Infof("skipping rate provider %s: circuit open until %s", cb.name, openUntil.UTC().Format(time.RFC3339))
+		return false
+	}
+	cb.probing = true
+	cb.transition(model.CircuitStateHalfOpen)
+	status := cb.status()
+	cb.mu.Unlock()
+
+	cb.save(ctx, status)
+	return true
+}
+
+func (cb *CircuitBreaker) record(ctx context.Context, err error) {
+	cb.mu.Lock()
+	cb.probing = false
+	if err == nil {
+		cb.failures = 0
+		cb.transition(model.CircuitStateClosed)
+	} else {
+		cb.failures++
+		if cb.state == model.CircuitStateHalfOpen || cb.failures >= cb.failureThreshold {
+			cb.openedAt = cb.now()
+			cb.transition(model.CircuitStateOpen)
+		}
+	}
+	status := cb.status()
+	cb.mu.Unlock()
+
+	cb.save(ctx, status)
+}
+
+func (cb *CircuitBreaker) transition(state model.CircuitState) {
+	previous := cb.state
+	if previous == state {
+		return
+	}
+	cb.state = state
+	if state == model.CircuitStateOpen {
+		logger.Errorf("rate provider %s circuit %s -> %s after %d consecutive failures, cooling down for %s", cb.name, previous, state, cb.failures, cb.coolDown)
+	} else {
+		logger.Infof("rate provider %s circuit %s -> %s", cb.name, previous, state)
+	}
+}
+
+func (cb *CircuitBreaker) save(ctx context.Context, status model.ProviderStatus) {
+	if cb.statusRepo == nil {
+		return
+	}
+	if err := cb.statusRepo.SaveStatus(ctx, status); err != nil {
+		logger.Errorf("failed to save status of rate provider %s: %v", cb.name, err)
+	}
+}
+
+func (cb *CircuitBreaker) status() model.ProviderStatus {
+	status := model.ProviderStatus{
+		Provider:            cb.name,
+		State:               cb.state,
+		ConsecutiveFailures: cb.failures,
+		UpdatedAt:           cb.now().UTC(),
+	}
+	if cb.state != model.CircuitStateClosed {
+		openedAt := cb.openedAt.UTC()
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
@@ -0,0 +1,159 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Lutefd/challenge-bravo/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockProviderStatusRepository struct {
+	mock.Mock
+}
+
+func (m *MockProviderStatusRepository) SaveStatus(ctx context.Context, status model.ProviderStatus) error {
+	args := m.Called(ctx, status)
+	return args.Error(0)
+}
+
+func (m *MockProviderStatusRepository) ListStatuses(ctx context.Context) ([]model.ProviderStatus, error) {
+	args := m.Called(ctx)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.ProviderStatus), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockProviderStatusRepository) Close() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func newTestCircuitBreaker(client ExternalAPIClient) (*CircuitBreaker, *MockProviderStatusRepository, *time.Time) {
+	statusRepo := &MockProviderStatusRepository{}
+	statusRepo.On("SaveStatus", mock.Anything, mock.Anything).Return(nil)
+	now := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+	breaker := NewCircuitBreaker("ecb", client, statusRepo, 2, time.Hour)
+	breaker.now = func() time.Time { return now }
+	return breaker, statusRepo, &now
+}
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	client := &MockExternalAPIClient{}
+	client.On("FetchRates", mock.Anything).Return(nil, errors.New("API error")).Twice()
+	breaker, statusRepo, _ := newTestCircuitBreaker(client)
+	ctx := context.Background()
+
+	_, err := breaker.FetchRates(ctx)
+	assert.EqualError(t, err, "API error")
+	assert.Equal(t, model.CircuitStateClosed, breaker.Status().State)
+
+	_, err = breaker.FetchRates(ctx)
+	assert.EqualError(t, err, "API error")
+	assert.Equal(t, model.CircuitStateOpen, breaker.Status().State)
+	assert.Equal(t, 2, breaker.Status().ConsecutiveFailures)
+
+	_, err = breaker.FetchRates(ctx)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	client.AssertNumberOfCalls(t, "FetchRates", 2)
+	statusRepo.AssertCalled(t, "SaveStatus", ctx, mock.MatchedBy(func(status model.ProviderStatus) bool {
+		return status.Provider == "ecb" && status.State == model.CircuitStateOpen && status.OpenedAt != nil
+	}))
+}
+
+func TestCircuitBreaker_HalfOpenRecovers(t *testing.T) {
+	client := &MockExternalAPIClient{}
+	client.On("FetchRates", mock.Anything).Return(nil, errors.New("API error")).Twice()
+	client.On("FetchRates", mock.Anything).Return(&model.ExchangeRates{Base: "USD"}, nil).Once()
+	breaker, _, now := newTestCircuitBreaker(client)
+	ctx := context.Background()
+
+	breaker.FetchRates(ctx)
+	breaker.FetchRates(ctx)
+	assert.Equal(t, model.CircuitStateOpen, breaker.Status().State)
+
+	*now = now.Add(time.Hour)
+	rates, err := breaker.FetchRates(ctx)
+	assert.NoError(t, err)
+	assert.NotNil(t, rates)
+
+	status := breaker.Status()
+	assert.Equal(t, model.CircuitStateClosed, status.State)
+	assert.Equal(t, 0, status.ConsecutiveFailures)
+	assert.Nil(t, status.OpenedAt)
+}
+
+func TestCircuitBreaker_HalfOpenFailureReopens(t *testing.T) {
+	client := &MockExternalAPIClient{}
+	client.On("FetchRates", mock.Anything).Return(nil, errors.New("API error"))
+	breaker, _, now := newTestCircuitBreaker(client)
+	ctx := context.Background()
+
+	breaker.FetchRates(ctx)
+	breaker.FetchRates(ctx)
+
+	*now = now.Add(time.Hour)
+	_, err := breaker.FetchRates(ctx)
+	assert.EqualError(t, err, "API error")
+
+	status := breaker.Status()
+	assert.Equal(t, model.CircuitStateOpen, status.State)
+	assert.Equal(t, *now, *status.OpenedAt)
+
+	_, err = breaker.FetchRates(ctx)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	client.AssertNumberOfCalls(t, "FetchRates", 3)
+}
+
+func TestCircuitBreaker_HalfOpenAllowsSingleProbe(t *testing.T) {
+	probing := make(chan struct{})
+	release := make(chan struct{})
+	client := &MockExternalAPIClient{}
+	client.On("FetchRates", mock.Anything).Return(nil, errors.New("API error")).Twice()
+	client.On("FetchRates", mock.Anything).Run(func(mock.Arguments) {
+		close(probing)
+		<-release
+	}).Return(&model.ExchangeRates{Base: "USD"}, nil).Once()
+	breaker, _, now := newTestCircuitBreaker(client)
+	ctx := context.Background()
+
+	breaker.FetchRates(ctx)
+	breaker.FetchRates(ctx)
+	*now = now.Add(time.Hour)
+
+	done := make(chan error)
+	go func() {
+		_, err := breaker.FetchRates(ctx)
+		done <- err
+	}()
+	<-probing
+
+	assert.Equal(t, model.CircuitStateHalfOpen, breaker.Status().State)
+	_, err := breaker.FetchRates(ctx)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	close(release)
+	assert.NoError(t, <-done)
+	assert.Equal(t, model.CircuitStateClosed, breaker.Status().State)
+	client.AssertNumberOfCalls(t, "FetchRates", 3)
+}
+
+func TestCircuitBreaker_SavesStatusOutsideLock(t *testing.T) {
+	client := &MockExternalAPIClient{}
+	client.On("FetchRates", mock.Anything).Return(nil, errors.New("API error"))
+	statusRepo := &MockProviderStatusRepository{}
+	breaker := NewCircuitBreaker("ecb", client, statusRepo, 2, time.Hour)
+	statusRepo.On("SaveStatus", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+		assert.True(t, breaker.mu.TryLock())
+		breaker.mu.Unlock()
+	}).Return(nil)
+
+	breaker.FetchRates(context.Background())
+
+	statusRepo.AssertNumberOfCalls(t, "SaveStatus", 1)
+}
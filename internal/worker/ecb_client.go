@@ -0,0 +1,140 @@
+package worker
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Lutefd/challenge-bravo/internal/commons"
+	"github.com/Lutefd/challenge-bravo/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+type ECBClient struct {
+	httpRateClient
+	now func() time.Time
+
+	mu               sync.Mutex
+	history          []ecbDay
+	historyFetchedAt time.Time
+}
+
+func NewECBClient(baseURL string) *ECBClient {
+	if baseURL == "" {
+		baseURL = "https://www.ecb.europa.eu/stats/eurofxref"
+	}
+	return &ECBClient{httpRateClient: newHTTPRateClient(baseURL), now: time.Now}
+}
+
+func (c *ECBClient) FetchRates(ctx context.Context) (*model.ExchangeRates, error) {
+	return c.fetch(ctx, c.baseURL+"/eurofxref-daily.xml", decodeECBRates)
+}
+
+func (c *ECBClient) FetchHistoricalRates(ctx context.Context, date time.Time) (*model.ExchangeRates, error) {
+	if c.now().Sub(date) > ecbRecentHistory {
+		return c.fetchFullHistory(ctx, date)
+	}
+	return c.fetch(ctx, c.baseURL+"/eurofxref-hist-90d.xml", func(body io.Reader) (*model.ExchangeRates, error) {
+		days, err := decodeECBDays(body)
+		if err != nil {
+			return nil, err
+		}
+		return ecbRatesOn(days, date)
+	})
+}
+
+func (c *ECBClient) fetchFullHistory(ctx context.Context, date time.Time) (*model.ExchangeRates, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.history != nil && c.now().Sub(c.historyFetchedAt) < commons.ECBHistoryCacheExpiration {
+		return ecbRatesOn(c.history, date)
+	}
+
+	var history []ecbDay
+	rates, err := c.fetch(ctx, c.baseURL+"/eurofxref-hist.xml", func(body io.Reader) (*model.ExchangeRates, error) {
+		days, err := decodeECBDays(body)
+		if err != nil {
+			return nil, err
+		}
+		history = days
+		return ecbRatesOn(days, date)
+	})
+	if history != nil {
+		c.history, c.historyFetchedAt = history, c.now()
+	}
+	return rates, err
+}
+
+const ecbRecentHistory = 90 * 24 * time.Hour
+
+type ecbEnvelope struct {
+	Cube struct {
+		Days []ecbDay `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+type ecbDay struct {
+	Time  string `xml:"time,attr"`
+	Rates []struct {
+		Currency string `xml:"currency,attr"`
+		Rate     string `xml:"rate,attr"`
+	} `xml:"Cube"`
+}
+
+func decodeECBRates(body io.Reader) (*model.ExchangeRates, error) {
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(body).Decode(&envelope); err != nil {
+		return nil, err
+	}
+	if len(envelope.Cube.Days) == 0 {
+		return nil, errors.New("feed has no rates")
+	}
+
+	return envelope.Cube.Days[0].exchangeRates()
+}
+
+func decodeECBDays(body io.Reader) ([]ecbDay, error) {
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(body).Decode(&envelope); err != nil {
+		return nil, err
+	}
+	return envelope.Cube.Days, nil
+}
+
+func ecbRatesOn(days []ecbDay, date time.Time) (*model.ExchangeRates, error) {
+	target := date.Format(time.DateOnly)
+	var closest *ecbDay
+	for i, day := range days {
+		if day.Time <= target && (closest == nil || day.Time > closest.Time) {
+			closest = &days[i]
+		}
+	}
+	if closest == nil {
+		return nil, fmt.Errorf("feed has no rates on or before %s", target)
+	}
+
+	return closest.exchangeRates()
+}
+
+func (day ecbDay) exchangeRates() (*model.ExchangeRates, error) {
+	date, err := time.Parse(time.DateOnly, day.Time)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := make(map[string]decimal.Decimal, len(day.Rates)+1)
+	for _, entry := range day.Rates {
+		rate, err := decimal.NewFromString(entry.Rate)
+		if err != nil {
+			return nil, err
+		}
+		rates[entry.Currency] = rate
+	}
+
+	return rebaseToUSD("EUR", date.Unix(), rates)
+}
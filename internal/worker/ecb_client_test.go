@@ -0,0 +1,165 @@
+package worker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ecbDailyFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<gesmes:Sender>
+		<gesmes:name>European Central Bank</gesmes:name>
+	</gesmes:Sender>
+	<Cube>
+		<Cube time="2024-08-09">
+			<Cube currency="USD" rate="1.0916"/>
+			<Cube currency="JPY" rate="160.69"/>
+			<Cube currency="BRL" rate="6.0553"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func TestECBClient_FetchRates(t *testing.T) {
+	server := newMockServer([]mockResponse{{statusCode: http.StatusOK, body: ecbDailyFeed}})
+	defer server.Close()
+
+	client := NewECBClient(server.URL)
+	client.client = server.Client()
+
+	rates, err := client.FetchRates(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, "USD", rates.Base)
+	assert.Equal(t, time.Date(2024, 8, 9, 0, 0, 0, 0, time.UTC).Unix(), rates.Timestamp)
+	assert.Equal(t, "1", rates.Rates["USD"].String())
+	assert.Equal(t, "0.91608647856357640161", rates.Rates["EUR"].String())
+	assert.Equal(t, "147.20593624038109197508", rates.Rates["JPY"].String())
+	assert.Equal(t, "5.54717845364602418468", rates.Rates["BRL"].String())
+	assert.Len(t, rates.Rates, 4)
+}
+
+func TestECBClient_FetchRates_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			name:     "Malformed XML",
+			body:     `<Envelope><Cube>`,
+			expected: "failed to decode response",
+		},
+		{
+			name:     "Empty feed",
+			body:     `<Envelope><Cube></Cube></Envelope>`,
+			expected: "feed has no rates",
+		},
+		{
+			name:     "Missing USD",
+			body:     `<Envelope><Cube><Cube time="2024-08-09"><Cube currency="JPY" rate="160.69"/></Cube></Cube></Envelope>`,
+			expected: "no USD rate against EUR",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newMockServer([]mockResponse{{statusCode: http.StatusOK, body: tt.body}})
+			defer server.Close()
+
+			client := NewECBClient(server.URL)
+			client.client = server.Client()
+
+			rates, err := client.FetchRates(context.Background())
+
+			assert.Nil(t, rates)
+			assert.ErrorContains(t, err, tt.expected)
+			assert.Equal(t, 1, server.requestCount)
+		})
+	}
+}
+
+const ecbHistoricalFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<Cube>
+		<Cube time="2024-08-12">
+			<Cube currency="USD" rate="1.25"/>
+		</Cube>
+		<Cube time="2024-08-09">
+			<Cube currency="USD" rate="1.0916"/>
+			<Cube currency="BRL" rate="6.0553"/>
+		</Cube>
+		<Cube time="2024-08-08">
+			<Cube currency="USD" rate="1.0900"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func TestECBClient_FetchHistoricalRates(t *testing.T) {
+	var requestedPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedPath = r.URL.Path
+		w.Write([]byte(ecbHistoricalFeed))
+	}))
+	defer server.Close()
+
+	client := NewECBClient(server.URL)
+	client.client = server.Client()
+
+	t.Run("Weekend uses the previous business day", func(t *testing.T) {
+		rates, err := client.FetchHistoricalRates(context.Background(), time.Date(2024, 8, 10, 0, 0, 0, 0, time.UTC))
+
+		require.NoError(t, err)
+		assert.Equal(t, "/eurofxref-hist.xml", requestedPath)
+		assert.Equal(t, time.Date(2024, 8, 9, 0, 0, 0, 0, time.UTC).Unix(), rates.Timestamp)
+		assert.Equal(t, "5.54717845364602418468", rates.Rates["BRL"].String())
+	})
+
+	t.Run("Recent dates use the 90 day feed", func(t *testing.T) {
+		_, err := client.FetchHistoricalRates(context.Background(), time.Now().AddDate(0, 0, -1))
+
+		require.NoError(t, err)
+		assert.Equal(t, "/eurofxref-hist-90d.xml", requestedPath)
+	})
+
+	t.Run("No rates before date", func(t *testing.T) {
+		rates, err := client.FetchHistoricalRates(context.Background(), time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC))
+
+		assert.Nil(t, rates)
+		assert.ErrorContains(t, err, "feed has no rates on or before 2024-08-01")
+	})
+}
+
+func TestECBClient_FetchHistoricalRates_ReusesFullHistory(t *testing.T) {
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		w.Write([]byte(ecbHistoricalFeed))
+	}))
+	defer server.Close()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	client := NewECBClient(server.URL)
+	client.client = server.Client()
+	client.now = func() time.Time { return now }
+
+	for _, day := range []int{8, 9, 10, 11, 12} {
+		_, err := client.FetchHistoricalRates(context.Background(), time.Date(2024, 8, day, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+	}
+	_, err := client.FetchHistoricalRates(context.Background(), time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC))
+	assert.ErrorContains(t, err, "feed has no rates on or before 2024-08-01")
+	assert.Equal(t, 1, requests["/eurofxref-hist.xml"])
+
+	now = now.Add(time.Hour)
+	rates, err := client.FetchHistoricalRates(context.Background(), time.Date(2024, 8, 12, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 8, 12, 0, 0, 0, 0, time.UTC).Unix(), rates.Timestamp)
+	assert.Equal(t, 2, requests["/eurofxref-hist.xml"])
+}
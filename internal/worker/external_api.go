@@ -2,10 +2,18 @@ package worker
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/Lutefd/challenge-bravo/internal/model"
 )
 
+var ErrHistoricalRatesUnsupported = errors.New("rate provider does not support historical rates")
+
 type ExternalAPIClient interface {
 	FetchRates(ctx context.Context) (*model.ExchangeRates, error)
 }
+
+type HistoricalRatesClient interface {
+	FetchHistoricalRates(ctx context.Context, date time.Time) (*model.ExchangeRates, error)
+}
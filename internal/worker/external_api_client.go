@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math/rand"
 	"net"
 	"net/http"
@@ -15,12 +16,8 @@ import (
 )
 
 type OpenExchangeRatesClient struct {
-	apiKey     string
-	client     *http.Client
-	baseURL    string
-	maxRetries int
-	baseDelay  time.Duration
-	maxDelay   time.Duration
+	httpRateClient
+	apiKey string
 }
 
 type OpenExchangeRatesClientOption func(*OpenExchangeRatesClient)
@@ -45,12 +42,8 @@ func WithMaxDelay(maxDelay time.Duration) OpenExchangeRatesClientOption {
 
 func NewOpenExchangeRatesClient(apiKey string, options ...OpenExchangeRatesClientOption) *OpenExchangeRatesClient {
 	client := &OpenExchangeRatesClient{
-		apiKey:     apiKey,
-		client:     &http.Client{},
-		baseURL:    "https://openexchangerates.org/api",
-		maxRetries: commons.ExternalClientMaxRetries,
-		baseDelay:  commons.ExternalClientBaseDelay,
-		maxDelay:   commons.ExternalClientMaxDelay,
+		httpRateClient: newHTTPRateClient("https://openexchangerates.org/api"),
+		apiKey:         apiKey,
 	}
 
 	for _, option := range options {
@@ -62,13 +55,47 @@ func NewOpenExchangeRatesClient(apiKey string, options ...OpenExchangeRatesClien
 
 func (c *OpenExchangeRatesClient) FetchRates(ctx context.Context) (*model.ExchangeRates, error) {
 	url := fmt.Sprintf("%s/latest.json?app_id=%s&show_alternative=true", c.baseURL, c.apiKey)
+	return c.fetch(ctx, url, decodeOpenExchangeRates)
+}
+
+func (c *OpenExchangeRatesClient) FetchHistoricalRates(ctx context.Context, date time.Time) (*model.ExchangeRates, error) {
+	url := fmt.Sprintf("%s/historical/%s.json?app_id=%s&show_alternative=true", c.baseURL, date.Format(time.DateOnly), c.apiKey)
+	return c.fetch(ctx, url, decodeOpenExchangeRates)
+}
+
+func decodeOpenExchangeRates(body io.Reader) (*model.ExchangeRates, error) {
+	var rates model.ExchangeRates
+	if err := json.NewDecoder(body).Decode(&rates); err != nil {
+		return nil, err
+	}
+	return &rates, nil
+}
 
+type httpRateClient struct {
+	client     *http.Client
+	baseURL    string
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+}
+
+func newHTTPRateClient(baseURL string) httpRateClient {
+	return httpRateClient{
+		client:     &http.Client{},
+		baseURL:    baseURL,
+		maxRetries: commons.ExternalClientMaxRetries,
+		baseDelay:  commons.ExternalClientBaseDelay,
+		maxDelay:   commons.ExternalClientMaxDelay,
+	}
+}
+
+func (c *httpRateClient) fetch(ctx context.Context, url string, decode func(io.Reader) (*model.ExchangeRates, error)) (*model.ExchangeRates, error) {
 	var rates *model.ExchangeRates
 	var err error
 	var resp *http.Response
 
 	for attempt := 0; attempt < c.maxRetries; attempt++ {
-		rates, resp, err = c.doRequest(ctx, url)
+		rates, resp, err = c.doRequest(ctx, url, decode)
 		if err == nil {
 			return rates, nil
 		}
@@ -92,7 +119,7 @@ func (c *OpenExchangeRatesClient) FetchRates(ctx context.Context) (*model.Exchan
 	return nil, fmt.Errorf("max retries reached: %w", err)
 }
 
-func (c *OpenExchangeRatesClient) doRequest(ctx context.Context, url string) (*model.ExchangeRates, *http.Response, error) {
+func (c *httpRateClient) doRequest(ctx context.Context, url string, decode func(io.Reader) (*model.ExchangeRates, error)) (*model.ExchangeRates, *http.Response, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create request: %w", err)
@@ -108,16 +135,15 @@ func (c *OpenExchangeRatesClient) doRequest(ctx context.Context, url string) (*m
 		return nil, resp, fmt.Errorf("API request failed with status code: %d", resp.StatusCode)
 	}
 
-	var rates model.ExchangeRates
-	err = json.NewDecoder(resp.Body).Decode(&rates)
+	rates, err := decode(resp.Body)
 	if err != nil {
 		return nil, resp, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return &rates, resp, nil
+	return rates, resp, nil
 }
 
-func (c *OpenExchangeRatesClient) shouldRetry(err error, resp *http.Response) bool {
+func (c *httpRateClient) shouldRetry(err error, resp *http.Response) bool {
 	if err == nil {
 		return false
 	}
@@ -143,7 +169,7 @@ func (c *OpenExchangeRatesClient) shouldRetry(err error, resp *http.Response) bo
 	return false
 }
 
-func (c *OpenExchangeRatesClient) calculateBackoff(attempt int) time.Duration {
+func (c *httpRateClient) calculateBackoff(attempt int) time.Duration {
 	delay := c.baseDelay * time.Duration(1<<uint(attempt))
 	if delay > c.maxDelay {
 		delay = c.maxDelay
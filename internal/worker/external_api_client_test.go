@@ -103,6 +103,26 @@ func TestOpenExchangeRatesClient_FetchRates(t *testing.T) {
 	}
 }
 
+func TestOpenExchangeRatesClient_FetchHistoricalRates(t *testing.T) {
+	var requestedURL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedURL = r.URL.String()
+		w.Write([]byte(`{"timestamp":1723334399,"base":"USD","rates":{"USD":1,"EUR":0.91}}`))
+	}))
+	defer server.Close()
+
+	client := NewOpenExchangeRatesClient("test-api-key")
+	client.baseURL = server.URL
+	client.client = server.Client()
+
+	rates, err := client.FetchHistoricalRates(context.Background(), time.Date(2024, 8, 10, 0, 0, 0, 0, time.UTC))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/historical/2024-08-10.json?app_id=test-api-key&show_alternative=true", requestedURL)
+	assert.Equal(t, int64(1723334399), rates.Timestamp)
+	assert.Equal(t, "0.91", rates.Rates["EUR"].String())
+}
+
 func TestOpenExchangeRatesClient_shouldRetry(t *testing.T) {
 	client := NewOpenExchangeRatesClient("test-api-key")
 
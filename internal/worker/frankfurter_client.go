@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/Lutefd/challenge-bravo/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+type FrankfurterClient struct {
+	httpRateClient
+}
+
+func NewFrankfurterClient(baseURL string) *FrankfurterClient {
+	if baseURL == "" {
+		baseURL = "https://api.frankfurter.app"
+	}
+	return &FrankfurterClient{httpRateClient: newHTTPRateClient(baseURL)}
+}
+
+func (c *FrankfurterClient) FetchRates(ctx context.Context) (*model.ExchangeRates, error) {
+	return c.fetch(ctx, c.baseURL+"/latest?from=USD", decodeFrankfurterRates)
+}
+
+func (c *FrankfurterClient) FetchHistoricalRates(ctx context.Context, date time.Time) (*model.ExchangeRates, error) {
+	return c.fetch(ctx, c.baseURL+"/"+date.Format(time.DateOnly)+"?from=USD", decodeFrankfurterRates)
+}
+
+type frankfurterResponse struct {
+	Base  string                     `json:"base"`
+	Date  string                     `json:"date"`
+	Rates map[string]decimal.Decimal `json:"rates"`
+}
+
+func decodeFrankfurterRates(body io.Reader) (*model.ExchangeRates, error) {
+	var response frankfurterResponse
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		return nil, err
+	}
+
+	date, err := time.Parse(time.DateOnly, response.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	return rebaseToUSD(response.Base, date.Unix(), response.Rates)
+}
@@ -0,0 +1,103 @@
+package worker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrankfurterClient_FetchRates(t *testing.T) {
+	var requestedURL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedURL = r.URL.String()
+		w.Write([]byte(`{"amount":1.0,"base":"USD","date":"2024-08-09","rates":{"EUR":0.91608,"BRL":5.5472}}`))
+	}))
+	defer server.Close()
+
+	client := NewFrankfurterClient(server.URL)
+	client.client = server.Client()
+
+	rates, err := client.FetchRates(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, "/latest?from=USD", requestedURL)
+	assert.Equal(t, "USD", rates.Base)
+	assert.Equal(t, time.Date(2024, 8, 9, 0, 0, 0, 0, time.UTC).Unix(), rates.Timestamp)
+	assert.Equal(t, "1", rates.Rates["USD"].String())
+	assert.Equal(t, "0.91608", rates.Rates["EUR"].String())
+	assert.Equal(t, "5.5472", rates.Rates["BRL"].String())
+}
+
+func TestFrankfurterClient_FetchHistoricalRates(t *testing.T) {
+	var requestedURL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedURL = r.URL.String()
+		w.Write([]byte(`{"amount":1.0,"base":"USD","date":"2024-08-09","rates":{"EUR":0.91608}}`))
+	}))
+	defer server.Close()
+
+	client := NewFrankfurterClient(server.URL)
+	client.client = server.Client()
+
+	rates, err := client.FetchHistoricalRates(context.Background(), time.Date(2024, 8, 10, 0, 0, 0, 0, time.UTC))
+
+	require.NoError(t, err)
+	assert.Equal(t, "/2024-08-10?from=USD", requestedURL)
+	assert.Equal(t, time.Date(2024, 8, 9, 0, 0, 0, 0, time.UTC).Unix(), rates.Timestamp)
+	assert.Equal(t, "0.91608", rates.Rates["EUR"].String())
+}
+
+func TestFrankfurterClient_FetchRates_NonUSDBase(t *testing.T) {
+	server := newMockServer([]mockResponse{
+		{statusCode: http.StatusOK, body: `{"amount":1.0,"base":"EUR","date":"2024-08-09","rates":{"USD":1.25,"GBP":0.85}}`},
+	})
+	defer server.Close()
+
+	client := NewFrankfurterClient(server.URL)
+	client.client = server.Client()
+
+	rates, err := client.FetchRates(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, "1", rates.Rates["USD"].String())
+	assert.Equal(t, "0.8", rates.Rates["EUR"].String())
+	assert.Equal(t, "0.68", rates.Rates["GBP"].String())
+}
+
+func TestFrankfurterClient_FetchRates_Retry(t *testing.T) {
+	server := newMockServer([]mockResponse{
+		{statusCode: http.StatusServiceUnavailable},
+		{statusCode: http.StatusOK, body: `{"amount":1.0,"base":"USD","date":"2024-08-09","rates":{"EUR":0.91608}}`},
+	})
+	defer server.Close()
+
+	client := NewFrankfurterClient(server.URL)
+	client.client = server.Client()
+	client.baseDelay = time.Millisecond
+
+	rates, err := client.FetchRates(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, "0.91608", rates.Rates["EUR"].String())
+	assert.Equal(t, 2, server.requestCount)
+}
+
+func TestFrankfurterClient_FetchRates_InvalidDate(t *testing.T) {
+	server := newMockServer([]mockResponse{
+		{statusCode: http.StatusOK, body: `{"base":"USD","date":"yesterday","rates":{"EUR":0.91608}}`},
+	})
+	defer server.Close()
+
+	client := NewFrankfurterClient(server.URL)
+	client.client = server.Client()
+
+	rates, err := client.FetchRates(context.Background())
+
+	assert.Nil(t, rates)
+	assert.ErrorContains(t, err, "failed to decode response")
+}
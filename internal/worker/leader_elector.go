@@ -0,0 +1,96 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Lutefd/challenge-bravo/internal/logger"
+	"github.com/Lutefd/challenge-bravo/internal/repository"
+)
+
+type LeaderElector struct {
+	lock     repository.LeaderLockRepository
+	interval time.Duration
+
+	mu     sync.Mutex
+	leader bool
+}
+
+func NewLeaderElector(lock repository.LeaderLockRepository, interval time.Duration) *LeaderElector {
+	return &LeaderElector{
+		lock:     lock,
+		interval: interval,
+	}
+}
+
+func (le *LeaderElector) Run(ctx context.Context, lead func(ctx context.Context) error) error {
+	ticker := time.NewTicker(le.interval)
+	defer ticker.Stop()
+
+	var cancelLead context.CancelFunc
+	var done chan error
+	stepDown := func() {
+		if done == nil {
+			return
+		}
+		cancelLead()
+		<-done
+		done = nil
+		le.setLeader(false)
+	}
+	defer func() {
+		stepDown()
+		if err := le.lock.Release(context.Background()); err != nil {
+			logger.Errorf("failed to release leadership: %v", err)
+		}
+	}()
+
+	for {
+		acquired, err := le.lock.TryAcquire(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Errorf("leader election failed: %v", err)
+		}
+		switch {
+		case acquired && done == nil:
+			cancelLead, done = startTerm(ctx, lead)
+			le.setLeader(true)
+			logger.Infof("acquired leadership, running as the active worker")
+		case !acquired && done != nil && ctx.Err() == nil:
+			logger.Errorf("lost leadership, stopping the active worker")
+			stepDown()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-done:
+			cancelLead()
+			done = nil
+			le.setLeader(false)
+			return err
+		case <-ticker.C:
+		}
+	}
+}
+
+func startTerm(ctx context.Context, lead func(ctx context.Context) error) (context.CancelFunc, chan error) {
+	leadCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() {
+		done <- lead(leadCtx)
+	}()
+	return cancel, done
+}
+
+func (le *LeaderElector) IsLeader() bool {
+	le.mu.Lock()
+	defer le.mu.Unlock()
+	return le.leader
+}
+
+func (le *LeaderElector) setLeader(leader bool) {
+	le.mu.Lock()
+	defer le.mu.Unlock()
+	le.leader = leader
+}
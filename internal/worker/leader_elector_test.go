@@ -0,0 +1,96 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockLeaderLockRepository struct {
+	mock.Mock
+}
+
+func (m *MockLeaderLockRepository) TryAcquire(ctx context.Context) (bool, error) {
+	args := m.Called(ctx)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockLeaderLockRepository) Release(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockLeaderLockRepository) Close() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func TestLeaderElector_RunsLeadWhileLeader(t *testing.T) {
+	lock := &MockLeaderLockRepository{}
+	lock.On("TryAcquire", mock.Anything).Return(true, nil)
+	lock.On("Release", mock.Anything).Return(nil)
+	elector := NewLeaderElector(lock, 10*time.Millisecond)
+
+	err := elector.Run(context.Background(), func(ctx context.Context) error {
+		assert.True(t, elector.IsLeader())
+		return errors.New("partition manager error")
+	})
+
+	assert.EqualError(t, err, "partition manager error")
+	assert.False(t, elector.IsLeader())
+	lock.AssertCalled(t, "Release", mock.Anything)
+}
+
+func TestLeaderElector_Standby(t *testing.T) {
+	lock := &MockLeaderLockRepository{}
+	lock.On("TryAcquire", mock.Anything).Return(false, nil)
+	lock.On("Release", mock.Anything).Return(nil)
+	elector := NewLeaderElector(lock, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	led := false
+	err := elector.Run(ctx, func(ctx context.Context) error {
+		led = true
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.False(t, led)
+	assert.False(t, elector.IsLeader())
+	assert.Greater(t, len(lock.Calls), 2)
+}
+
+func TestLeaderElector_StepsDownWhenLockLost(t *testing.T) {
+	lock := &MockLeaderLockRepository{}
+	lock.On("TryAcquire", mock.Anything).Return(true, nil).Once()
+	lock.On("TryAcquire", mock.Anything).Return(false, errors.New("lost leader lock session: driver: bad connection")).Once()
+	lock.On("TryAcquire", mock.Anything).Return(false, nil)
+	lock.On("Release", mock.Anything).Return(nil)
+	elector := NewLeaderElector(lock, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	stopped := make(chan struct{})
+	err := elector.Run(ctx, func(leadCtx context.Context) error {
+		<-leadCtx.Done()
+		if ctx.Err() == nil {
+			close(stopped)
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	select {
+	case <-stopped:
+	default:
+		t.Fatal("expected lead to be stopped before the election ended")
+	}
+	assert.False(t, elector.IsLeader())
+}
@@ -0,0 +1,98 @@
+package worker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Lutefd/challenge-bravo/internal/commons"
+	"github.com/Lutefd/challenge-bravo/internal/model"
+	"github.com/Lutefd/challenge-bravo/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+type ProviderConfig struct {
+	APIKey  string
+	BaseURL string
+}
+
+type ProviderFactory func(config ProviderConfig) (ExternalAPIClient, error)
+
+var providers = map[string]ProviderFactory{
+	commons.RateProviderOpenExchangeRates: func(config ProviderConfig) (ExternalAPIClient, error) {
+		if config.APIKey == "" {
+			return nil, fmt.Errorf("provider %s requires an API key", commons.RateProviderOpenExchangeRates)
+		}
+		client := NewOpenExchangeRatesClient(config.APIKey)
+		if config.BaseURL != "" {
+			client.baseURL = config.BaseURL
+		}
+		return client, nil
+	},
+	commons.RateProviderECB: func(config ProviderConfig) (ExternalAPIClient, error) {
+		return NewECBClient(config.BaseURL), nil
+	},
+	commons.RateProviderFrankfurter: func(config ProviderConfig) (ExternalAPIClient, error) {
+		return NewFrankfurterClient(config.BaseURL), nil
+	},
+}
+
+func RegisterProvider(name string, factory ProviderFactory) {
+	providers[strings.ToLower(name)] = factory
+}
+
+func ProviderNames() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func NewProvider(name string, config ProviderConfig) (ExternalAPIClient, error) {
+	factory, ok := providers[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown rate provider %q, must be one of %s", name, strings.Join(ProviderNames(), ", "))
+	}
+	return factory(config)
+}
+
+func NewProviders(config commons.Config, statusRepo repository.ProviderStatusRepository) ([]NamedProvider, error) {
+	named := make([]NamedProvider, 0, len(config.RateProviders))
+	for _, name := range config.RateProviders {
+		client, err := NewProvider(name, ProviderConfig{
+			APIKey:  config.APIKey,
+			BaseURL: config.RateProviderURLs[name],
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize rate provider: %w", err)
+		}
+		breaker := NewCircuitBreaker(name, client, statusRepo,
+			config.CircuitBreakerFailureThreshold, config.CircuitBreakerCoolDown)
+		named = append(named, NamedProvider{Name: name, Client: breaker})
+	}
+	return named, nil
+}
+
+func rebaseToUSD(base string, timestamp int64, rates map[string]decimal.Decimal) (*model.ExchangeRates, error) {
+	rebased := make(map[string]decimal.Decimal, len(rates)+1)
+	for code, rate := range rates {
+		rebased[code] = rate
+	}
+	rebased[base] = decimal.NewFromInt(1)
+
+	usdRate, ok := rebased["USD"]
+	if !ok || !usdRate.IsPositive() {
+		return nil, fmt.Errorf("no USD rate against %s", base)
+	}
+	for code, rate := range rebased {
+		rebased[code] = rate.DivRound(usdRate, commons.DivisionPrecision)
+	}
+
+	return &model.ExchangeRates{
+		Timestamp: timestamp,
+		Base:      "USD",
+		Rates:     rebased,
+	}, nil
+}
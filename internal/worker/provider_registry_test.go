@@ -0,0 +1,98 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/Lutefd/challenge-bravo/internal/commons"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProvider(t *testing.T) {
+	t.Run("OpenExchangeRates", func(t *testing.T) {
+		provider, err := NewProvider(commons.RateProviderOpenExchangeRates, ProviderConfig{APIKey: "key", BaseURL: "http://localhost:9000"})
+		require.NoError(t, err)
+		client, ok := provider.(*OpenExchangeRatesClient)
+		require.True(t, ok)
+		assert.Equal(t, "key", client.apiKey)
+		assert.Equal(t, "http://localhost:9000", client.baseURL)
+	})
+
+	t.Run("OpenExchangeRates without API key", func(t *testing.T) {
+		_, err := NewProvider(commons.RateProviderOpenExchangeRates, ProviderConfig{})
+		assert.ErrorContains(t, err, "requires an API key")
+	})
+
+	t.Run("ECB", func(t *testing.T) {
+		provider, err := NewProvider("ECB", ProviderConfig{})
+		require.NoError(t, err)
+		client, ok := provider.(*ECBClient)
+		require.True(t, ok)
+		assert.Equal(t, "https://www.ecb.europa.eu/stats/eurofxref", client.baseURL)
+	})
+
+	t.Run("Frankfurter", func(t *testing.T) {
+		provider, err := NewProvider(commons.RateProviderFrankfurter, ProviderConfig{BaseURL: "http://localhost:8080"})
+		require.NoError(t, err)
+		client, ok := provider.(*FrankfurterClient)
+		require.True(t, ok)
+		assert.Equal(t, "http://localhost:8080", client.baseURL)
+	})
+
+	t.Run("Unknown provider", func(t *testing.T) {
+		_, err := NewProvider("fixer", ProviderConfig{})
+		assert.EqualError(t, err, `unknown rate provider "fixer", must be one of ecb, frankfurter, openexchangerates`)
+	})
+}
+
+func TestRegisterProvider(t *testing.T) {
+	static := &MockExternalAPIClient{}
+	RegisterProvider("Static", func(config ProviderConfig) (ExternalAPIClient, error) {
+		return static, nil
+	})
+	defer delete(providers, "static")
+
+	provider, err := NewProvider("static", ProviderConfig{})
+	require.NoError(t, err)
+	assert.Same(t, static, provider)
+	assert.Contains(t, ProviderNames(), "static")
+}
+
+func TestNewProviders(t *testing.T) {
+	t.Run("Providers wrapped in circuit breakers", func(t *testing.T) {
+		named, err := NewProviders(commons.Config{
+			RateProviders:    []string{commons.RateProviderECB, commons.RateProviderFrankfurter},
+			RateProviderURLs: map[string]string{commons.RateProviderECB: "http://localhost:9000"},
+		}, nil)
+		require.NoError(t, err)
+		require.Len(t, named, 2)
+		assert.Equal(t, commons.RateProviderECB, named[0].Name)
+		breaker, ok := named[0].Client.(*CircuitBreaker)
+		require.True(t, ok)
+		assert.Equal(t, "http://localhost:9000", breaker.client.(*ECBClient).baseURL)
+		assert.Equal(t, commons.RateProviderFrankfurter, named[1].Name)
+	})
+
+	t.Run("Invalid provider", func(t *testing.T) {
+		_, err := NewProviders(commons.Config{RateProviders: []string{"fixer"}}, nil)
+		assert.ErrorContains(t, err, "failed to initialize rate provider")
+	})
+}
+
+func TestRebaseToUSD(t *testing.T) {
+	rates, err := rebaseToUSD("GBP", 1723161600, map[string]decimal.Decimal{
+		"USD": decimal.RequireFromString("1.25"),
+		"EUR": decimal.RequireFromString("1.15"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "USD", rates.Base)
+	assert.Equal(t, int64(1723161600), rates.Timestamp)
+	assert.Equal(t, "1", rates.Rates["USD"].String())
+	assert.Equal(t, "0.8", rates.Rates["GBP"].String())
+	assert.Equal(t, "0.92", rates.Rates["EUR"].String())
+
+	rates, err = rebaseToUSD("GBP", 1723161600, map[string]decimal.Decimal{"EUR": decimal.RequireFromString("1.15")})
+	assert.Nil(t, rates)
+	assert.EqualError(t, err, "no USD rate against GBP")
+}
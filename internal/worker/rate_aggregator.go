@@ -0,0 +1,147 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Lutefd/challenge-bravo/internal/commons"
+	"github.com/Lutefd/challenge-bravo/internal/logger"
+	"github.com/Lutefd/challenge-bravo/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+type NamedProvider struct {
+	Name   string
+	Client ExternalAPIClient
+}
+
+type RateAggregator struct {
+	providers []NamedProvider
+	tolerance decimal.Decimal
+}
+
+type providerQuote struct {
+	provider string
+	rate     decimal.Decimal
+}
+
+func NewRateAggregator(providers []NamedProvider, tolerance decimal.Decimal) *RateAggregator {
+	return &RateAggregator{
+		providers: providers,
+		tolerance: tolerance,
+	}
+}
+
+func (a *RateAggregator) FetchRates(ctx context.Context) (*model.ExchangeRates, error) {
+	return a.collect(func(client ExternalAPIClient) (*model.ExchangeRates, error) {
+		return client.FetchRates(ctx)
+	})
+}
+
+func (a *RateAggregator) FetchHistoricalRates(ctx context.Context, date time.Time) (*model.ExchangeRates, error) {
+	return a.collect(func(client ExternalAPIClient) (*model.ExchangeRates, error) {
+		historical, ok := client.(HistoricalRatesClient)
+		if !ok {
+			return nil, ErrHistoricalRatesUnsupported
+		}
+		return historical.FetchHistoricalRates(ctx, date)
+	})
+}
+
+func (a *RateAggregator) collect(fetch func(client ExternalAPIClient) (*model.ExchangeRates, error)) (*model.ExchangeRates, error) {
+	results := make([]*model.ExchangeRates, len(a.providers))
+	var wg sync.WaitGroup
+	for i, provider := range a.providers {
+		wg.Add(1)
+		go func(i int, provider NamedProvider) {
+			defer wg.Done()
+			rates, err := fetch(provider.Client)
+			if err != nil {
+				logger.Errorf("rate provider %s failed: %v", provider.Name, err)
+				return
+			}
+			results[i] = rates
+		}(i, provider)
+	}
+	wg.Wait()
+
+	aggregated := &model.ExchangeRates{
+		Base:      "USD",
+		Rates:     make(map[string]decimal.Decimal),
+		Providers: make(map[string][]string),
+	}
+	quotes := make(map[string][]providerQuote)
+	succeeded := 0
+	for i, rates := range results {
+		if rates == nil {
+			continue
+		}
+		succeeded++
+		if rates.Timestamp > aggregated.Timestamp {
+			aggregated.Timestamp = rates.Timestamp
+		}
+		for code, rate := range rates.Rates {
+			if !rate.IsPositive() {
+				logger.Infof("discarding %s rate %s for %s: not positive", a.providers[i].Name, rate, code)
+				continue
+			}
+			quotes[code] = append(quotes[code], providerQuote{provider: a.providers[i].Name, rate: rate})
+		}
+	}
+	if succeeded == 0 {
+		return nil, errors.New("all rate providers failed")
+	}
+
+	for code, codeQuotes := range quotes {
+		rate, contributors := a.aggregate(codeQuotes)
+		if len(contributors) == 0 {
+			logger.Infof("skipping currency %s: no provider quote within tolerance", code)
+			continue
+		}
+		aggregated.Rates[code] = rate
+		aggregated.Providers[code] = contributors
+	}
+	return aggregated, nil
+}
+
+func (a *RateAggregator) aggregate(quotes []providerQuote) (decimal.Decimal, []string) {
+	rates := make([]decimal.Decimal, len(quotes))
+	for i, quote := range quotes {
+		rates[i] = quote.rate
+	}
+	center := median(rates)
+	maxDeviation := center.Abs().Mul(a.tolerance)
+
+	var kept []decimal.Decimal
+	var contributors []string
+	for _, quote := range quotes {
+		if quote.rate.Sub(center).Abs().GreaterThan(maxDeviation) {
+			logger.Infof("discarding %s rate %s: deviates from median %s beyond tolerance %s", quote.provider, quote.rate, center, a.tolerance)
+			continue
+		}
+		kept = append(kept, quote.rate)
+		contributors = append(contributors, quote.provider)
+	}
+	if len(kept) == 0 {
+		return decimal.Zero, nil
+	}
+	sort.Strings(contributors)
+	return median(kept), contributors
+}
+
+func median(values []decimal.Decimal) decimal.Decimal {
+	sorted := make([]decimal.Decimal, len(values))
+	copy(sorted, values)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].LessThan(sorted[j])
+	})
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[middle]
+	}
+	return sorted[middle-1].Add(sorted[middle]).DivRound(decimal.NewFromInt(2), commons.DivisionPrecision)
+}
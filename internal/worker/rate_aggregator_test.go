@@ -0,0 +1,140 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Lutefd/challenge-bravo/internal/model"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockProvider(name string, rates *model.ExchangeRates, err error) NamedProvider {
+	client := &MockExternalAPIClient{}
+	client.On("FetchRates", mock.Anything).Return(rates, err)
+	return NamedProvider{Name: name, Client: client}
+}
+
+func quoteRates(timestamp int64, rates map[string]string) *model.ExchangeRates {
+	parsed := make(map[string]decimal.Decimal, len(rates))
+	for code, rate := range rates {
+		parsed[code] = decimal.RequireFromString(rate)
+	}
+	return &model.ExchangeRates{Timestamp: timestamp, Base: "USD", Rates: parsed}
+}
+
+func TestRateAggregator_FetchRates(t *testing.T) {
+	tolerance := decimal.RequireFromString("0.05")
+
+	t.Run("Median of agreeing providers", func(t *testing.T) {
+		aggregator := NewRateAggregator([]NamedProvider{
+			newMockProvider("openexchangerates", quoteRates(100, map[string]string{"USD": "1", "EUR": "0.90"}), nil),
+			newMockProvider("ecb", quoteRates(300, map[string]string{"USD": "1", "EUR": "0.92"}), nil),
+			newMockProvider("frankfurter", quoteRates(200, map[string]string{"USD": "1", "EUR": "0.91"}), nil),
+		}, tolerance)
+
+		rates, err := aggregator.FetchRates(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int64(300), rates.Timestamp)
+		assert.Equal(t, "USD", rates.Base)
+		assert.Equal(t, "0.91", rates.Rates["EUR"].String())
+		assert.Equal(t, []string{"ecb", "frankfurter", "openexchangerates"}, rates.Providers["EUR"])
+	})
+
+	t.Run("Outlier discarded", func(t *testing.T) {
+		aggregator := NewRateAggregator([]NamedProvider{
+			newMockProvider("openexchangerates", quoteRates(100, map[string]string{"EUR": "0.90"}), nil),
+			newMockProvider("ecb", quoteRates(100, map[string]string{"EUR": "0.92"}), nil),
+			newMockProvider("frankfurter", quoteRates(100, map[string]string{"EUR": "1.50"}), nil),
+		}, tolerance)
+
+		rates, err := aggregator.FetchRates(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "0.91", rates.Rates["EUR"].String())
+		assert.Equal(t, []string{"ecb", "openexchangerates"}, rates.Providers["EUR"])
+	})
+
+	t.Run("Code skipped when no quote is within tolerance", func(t *testing.T) {
+		aggregator := NewRateAggregator([]NamedProvider{
+			newMockProvider("ecb", quoteRates(100, map[string]string{"USD": "1", "EUR": "0.80"}), nil),
+			newMockProvider("frankfurter", quoteRates(100, map[string]string{"USD": "1", "EUR": "1.00"}), nil),
+		}, tolerance)
+
+		rates, err := aggregator.FetchRates(context.Background())
+		require.NoError(t, err)
+		assert.NotContains(t, rates.Rates, "EUR")
+		assert.Equal(t, "1", rates.Rates["USD"].String())
+	})
+
+	t.Run("Non-positive quotes discarded", func(t *testing.T) {
+		aggregator := NewRateAggregator([]NamedProvider{
+			newMockProvider("ecb", quoteRates(100, map[string]string{"EUR": "0.92", "ZWL": "0"}), nil),
+			newMockProvider("frankfurter", quoteRates(100, map[string]string{"EUR": "0", "ZWL": "-1"}), nil),
+		}, tolerance)
+
+		rates, err := aggregator.FetchRates(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "0.92", rates.Rates["EUR"].String())
+		assert.Equal(t, []string{"ecb"}, rates.Providers["EUR"])
+		assert.NotContains(t, rates.Rates, "ZWL")
+	})
+
+	t.Run("Failed provider ignored", func(t *testing.T) {
+		aggregator := NewRateAggregator([]NamedProvider{
+			newMockProvider("openexchangerates", nil, errors.New("API error")),
+			newMockProvider("ecb", quoteRates(100, map[string]string{"EUR": "0.92"}), nil),
+		}, tolerance)
+
+		rates, err := aggregator.FetchRates(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "0.92", rates.Rates["EUR"].String())
+		assert.Equal(t, []string{"ecb"}, rates.Providers["EUR"])
+	})
+
+	t.Run("All providers failed", func(t *testing.T) {
+		aggregator := NewRateAggregator([]NamedProvider{
+			newMockProvider("openexchangerates", nil, errors.New("API error")),
+			newMockProvider("ecb", nil, errors.New("timeout")),
+		}, tolerance)
+
+		rates, err := aggregator.FetchRates(context.Background())
+		assert.Nil(t, rates)
+		assert.EqualError(t, err, "all rate providers failed")
+	})
+}
+
+func TestMedian(t *testing.T) {
+	values := func(rates ...string) []decimal.Decimal {
+		parsed := make([]decimal.Decimal, len(rates))
+		for i, rate := range rates {
+			parsed[i] = decimal.RequireFromString(rate)
+		}
+		return parsed
+	}
+
+	assert.Equal(t, "2", median(values("3", "1", "2")).String())
+	assert.Equal(t, "2.5", median(values("4", "1", "2", "3")).String())
+	assert.Equal(t, "7", median(values("7")).String())
+}
+
+func TestRateAggregator_FetchHistoricalRates(t *testing.T) {
+	date := time.Date(2024, 8, 9, 0, 0, 0, 0, time.UTC)
+	historical := &MockHistoricalAPIClient{}
+	historical.On("FetchHistoricalRates", mock.Anything, date).Return(quoteRates(date.Unix(), map[string]string{"EUR": "0.91"}), nil)
+
+	aggregator := NewRateAggregator([]NamedProvider{
+		{Name: "ecb", Client: historical},
+		newMockProvider("latest-only", quoteRates(100, map[string]string{"EUR": "0.5"}), nil),
+	}, decimal.RequireFromString("0.05"))
+
+	rates, err := aggregator.FetchHistoricalRates(context.Background(), date)
+
+	require.NoError(t, err)
+	assert.Equal(t, date.Unix(), rates.Timestamp)
+	assert.Equal(t, "0.91", rates.Rates["EUR"].String())
+	assert.Equal(t, []string{"ecb"}, rates.Providers["EUR"])
+}
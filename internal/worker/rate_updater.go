@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/Lutefd/challenge-bravo/internal/cache"
@@ -11,31 +12,65 @@ import (
 	"github.com/Lutefd/challenge-bravo/internal/logger"
 	"github.com/Lutefd/challenge-bravo/internal/model"
 	"github.com/Lutefd/challenge-bravo/internal/repository"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 type RateUpdater struct {
-	repo        repository.CurrencyRepository
-	cache       cache.Cache
-	externalAPI ExternalAPIClient
-	interval    time.Duration
+	repo             repository.CurrencyRepository
+	cache            cache.Cache
+	externalAPI      ExternalAPIClient
+	interval         time.Duration
+	scheduleInterval time.Duration
+	jumpThreshold    decimal.Decimal
+	publisher        cache.RateChangePublisher
+
+	mu     sync.Mutex
+	status model.RateUpdaterStatus
+}
+
+type RateUpdaterOption func(*RateUpdater)
+
+func WithJumpThreshold(threshold decimal.Decimal) RateUpdaterOption {
+	return func(ru *RateUpdater) {
+		ru.jumpThreshold = threshold
+	}
 }
 
-func NewRateUpdater(repo repository.CurrencyRepository, cache cache.Cache, externalAPI ExternalAPIClient, interval time.Duration) *RateUpdater {
-	return &RateUpdater{
-		repo:        repo,
-		cache:       cache,
-		externalAPI: externalAPI,
-		interval:    interval,
+func WithRateChangePublisher(publisher cache.RateChangePublisher) RateUpdaterOption {
+	return func(ru *RateUpdater) {
+		ru.publisher = publisher
+	}
+}
+
+func NewRateUpdater(repo repository.CurrencyRepository, cache cache.Cache, externalAPI ExternalAPIClient, interval time.Duration, options ...RateUpdaterOption) *RateUpdater {
+	updater := &RateUpdater{
+		repo:             repo,
+		cache:            cache,
+		externalAPI:      externalAPI,
+		interval:         interval,
+		scheduleInterval: commons.ScheduledRateCheckInterval,
+		jumpThreshold:    decimal.NewFromFloat(commons.DefaultRateJumpThreshold),
+	}
+	for _, option := range options {
+		option(updater)
 	}
+	return updater
 }
 
 func (ru *RateUpdater) Start(ctx context.Context) {
 	ticker := time.NewTicker(ru.interval)
-	if err := ru.populateRates(ctx); err != nil {
+	ru.backfillMissed(ctx)
+	if err := ru.updateRates(ctx); err != nil {
 		logger.Errorf("error updating rates on startup: %v", err)
 	}
+	ru.markStarted(time.Now().Add(ru.interval))
+	ru.applyScheduledChanges(ctx)
 	defer ticker.Stop()
 
+	scheduleTicker := time.NewTicker(ru.scheduleInterval)
+	defer scheduleTicker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -45,6 +80,38 @@ func (ru *RateUpdater) Start(ctx context.Context) {
 			if err := ru.updateRates(ctx); err != nil {
 				logger.Errorf("error updating rates: %v", err)
 			}
+			ru.scheduleNext(time.Now().Add(ru.interval))
+		case <-scheduleTicker.C:
+			ru.applyScheduledChanges(ctx)
+		}
+	}
+}
+
+func (ru *RateUpdater) applyScheduledChanges(ctx context.Context) {
+	changes, err := ru.repo.ListDueRateChanges(ctx, time.Now().UTC())
+	if err != nil {
+		logger.Errorf("failed to list due rate changes: %v", err)
+		return
+	}
+	if len(changes) == 0 {
+		return
+	}
+
+	pegs := ru.loadPegs(ctx)
+	for _, change := range changes {
+		if err := ru.repo.ApplyScheduledRateChange(ctx, change); err != nil {
+			logger.Errorf("failed to apply scheduled rate change %s for %s: %v", change.ID, change.Code, err)
+			continue
+		}
+		ru.cacheRates(ctx, []model.RateChangeEvent{{Code: change.Code, Rate: change.Rate}})
+		logger.Infof("applied scheduled rate change %s: %s set to %s", change.ID, change.Code, change.Rate)
+
+		pegged := peggedCurrencies(pegs, &model.ExchangeRates{
+			Timestamp: change.EffectiveAt.Unix(),
+			Rates:     map[string]decimal.Decimal{change.Code: change.Rate},
+		})
+		if _, err := ru.saveRates(ctx, nil, pegged); err != nil {
+			logger.Errorf("failed to update currencies pegged to %s: %v", change.Code, err)
 		}
 	}
 }
@@ -52,65 +119,357 @@ func (ru *RateUpdater) Start(ctx context.Context) {
 func (ru *RateUpdater) updateRates(ctx context.Context) error {
 	rates, err := ru.externalAPI.FetchRates(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to fetch rates: %w", err)
+		return ru.recordFailure(fmt.Errorf("failed to fetch rates: %w", err))
+	}
+
+	pegs := ru.loadPegs(ctx)
+	existing, err := ru.loadCurrencies(ctx, rates)
+	if err != nil {
+		return ru.recordFailure(err)
 	}
 
+	updatedAt := time.Unix(rates.Timestamp, 0)
+	currencies := make([]model.Currency, 0, len(rates.Rates)+len(pegs))
 	for code, rate := range rates.Rates {
-		currency := &model.Currency{
-			Code:      code,
-			Rate:      rate,
-			UpdatedAt: time.Unix(rates.Timestamp, 0),
+		if !rate.IsPositive() {
+			logger.Infof("skipping currency %s: provider proposed non-positive rate %s", code, rate)
+			delete(rates.Rates, code)
+			continue
 		}
-
-		if err := ru.repo.Update(ctx, currency); err != nil {
-			logger.Errorf("failed to update currency %s in repository: %v", code, err)
+		if _, pegged := pegs[code]; pegged {
+			continue
 		}
-
-		if err := ru.cache.Set(ctx, code, rate, 1*time.Hour); err != nil {
-			logger.Errorf("failed to update currency %s in cache: %v", code, err)
+		if current, ok := existing[code]; ok {
+			if current.Locked {
+				logSkippedLocked(current, rate)
+				delete(rates.Rates, code)
+				continue
+			}
+			if ru.quarantineJump(ctx, current, rate, rates) {
+				delete(rates.Rates, code)
+				continue
+			}
 		}
+		currencies = append(currencies, model.Currency{
+			Code:             code,
+			Rate:             rate,
+			UpdatedAt:        updatedAt,
+			CreatedAt:        updatedAt,
+			Source:           model.CurrencySourceProvider,
+			Providers:        rates.Providers[code],
+			CurrencyMetadata: model.KnownCurrencyMetadata(code),
+		})
 	}
 
+	written, err := ru.saveRates(ctx, currencies, peggedCurrencies(pegs, rates))
+	if err != nil {
+		return ru.recordFailure(err)
+	}
+	ru.recordSync(ctx, rates)
+	ru.recordSuccess(written)
+
 	log.Println("rates updated successfully")
 	return nil
 }
 
-func (ru *RateUpdater) populateRates(ctx context.Context) error {
-	rates, err := ru.externalAPI.FetchRates(ctx)
+func (ru *RateUpdater) saveRates(ctx context.Context, currencies, pegged []model.Currency) (int, error) {
+	var written int
+	if len(currencies) > 0 {
+		var err error
+		written, err = ru.repo.UpsertRates(ctx, currencies)
+		if err != nil {
+			return 0, fmt.Errorf("failed to save rates: %w", err)
+		}
+	}
+	if len(pegged) > 0 {
+		if err := ru.repo.UpdatePeggedRates(ctx, pegged); err != nil {
+			return 0, fmt.Errorf("failed to save pegged rates: %w", err)
+		}
+	}
+
+	events := make([]model.RateChangeEvent, 0, len(currencies)+len(pegged))
+	for _, currency := range currencies {
+		events = append(events, model.RateChangeEvent{Code: currency.Code, Rate: currency.Rate})
+	}
+	for _, currency := range pegged {
+		events = append(events, model.RateChangeEvent{Code: currency.Code, Rate: currency.Rate})
+	}
+	if len(events) > 0 {
+		ru.cacheRates(ctx, events)
+	}
+	return written, nil
+}
+
+func (ru *RateUpdater) cacheRates(ctx context.Context, events []model.RateChangeEvent) {
+	codes := make([]string, 0, len(events))
+	for _, event := range events {
+		codes = append(codes, event.Code)
+	}
+
+	var version uint64
+	stored, err := ru.repo.GetByCodes(ctx, codes)
 	if err != nil {
-		return fmt.Errorf("failed to fetch rates: %w", err)
+		logger.Errorf("failed to reload currencies: %v", err)
+		version, err = ru.cache.UpdateSnapshot(ctx, nil, codes, commons.RateUpdaterCacheExipiration)
+	} else {
+		version, err = ru.cache.UpdateSnapshot(ctx, stored, nil, commons.RateUpdaterCacheExipiration)
 	}
+	if err != nil {
+		logger.Errorf("failed to update rates in cache: %v", err)
+	}
+	ru.publishRateChanges(ctx, version, events)
+}
 
-	for code, rate := range rates.Rates {
-		currency := &model.Currency{
-			Code:      code,
-			Rate:      rate,
-			UpdatedAt: time.Unix(rates.Timestamp, 0),
+func (ru *RateUpdater) publishRateChanges(ctx context.Context, version uint64, events []model.RateChangeEvent) {
+	if ru.publisher == nil || len(events) == 0 {
+		return
+	}
+	changedAt := time.Now().UTC()
+	for i := range events {
+		events[i].Version = version
+		events[i].ChangedAt = changedAt
+	}
+	if err := ru.publisher.PublishRateChanges(ctx, events); err != nil {
+		logger.Errorf("failed to publish rate changes: %v", err)
+	}
+}
+
+func (ru *RateUpdater) Status() model.RateUpdaterStatus {
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	return ru.status
+}
+
+func (ru *RateUpdater) recordSuccess(updated int) {
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	fetchedAt := time.Now().UTC()
+	ru.status.LastFetchAt = &fetchedAt
+	ru.status.CurrenciesUpdated = updated
+}
+
+func (ru *RateUpdater) recordFailure(err error) error {
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	failedAt := time.Now().UTC()
+	ru.status.LastError = err.Error()
+	ru.status.LastErrorAt = &failedAt
+	return err
+}
+
+func (ru *RateUpdater) markStarted(nextRunAt time.Time) {
+	ru.mu.Lock()
+	ru.status.Started = true
+	ru.mu.Unlock()
+	ru.scheduleNext(nextRunAt)
+}
+
+func (ru *RateUpdater) scheduleNext(nextRunAt time.Time) {
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	nextRunAt = nextRunAt.UTC()
+	ru.status.NextRunAt = &nextRunAt
+}
+
+func (ru *RateUpdater) recordSync(ctx context.Context, rates *model.ExchangeRates) {
+	if err := ru.repo.SetLastRateSync(ctx, time.Unix(rates.Timestamp, 0).UTC()); err != nil {
+		logger.Errorf("failed to record last rate sync: %v", err)
+	}
+}
+
+func (ru *RateUpdater) backfillMissed(ctx context.Context) {
+	lastSync, err := ru.repo.GetLastRateSync(ctx)
+	if err != nil {
+		logger.Errorf("failed to get last rate sync: %v", err)
+		return
+	}
+	if lastSync.IsZero() {
+		return
+	}
+
+	from := truncateToDay(lastSync).AddDate(0, 0, 1)
+	to := truncateToDay(time.Now().UTC()).AddDate(0, 0, -1)
+	if from.After(to) {
+		return
+	}
+	if earliest := to.AddDate(0, 0, 1-commons.MaxBackfillDays); from.Before(earliest) {
+		logger.Infof("rate gap since %s exceeds %d days, backfilling from %s", from.Format(time.DateOnly), commons.MaxBackfillDays, earliest.Format(time.DateOnly))
+		from = earliest
+	}
+
+	logger.Infof("backfilling missed rates from %s to %s", from.Format(time.DateOnly), to.Format(time.DateOnly))
+	if err := ru.Backfill(ctx, from, to); err != nil {
+		logger.Errorf("error backfilling missed rates: %v", err)
+	}
+}
+
+func (ru *RateUpdater) Backfill(ctx context.Context, from, to time.Time) error {
+	historical, ok := ru.externalAPI.(HistoricalRatesClient)
+	if !ok {
+		return ErrHistoricalRatesUnsupported
+	}
+
+	pegs := ru.loadPegs(ctx)
+	days, failed := 0, 0
+	for day := truncateToDay(from); !day.After(truncateToDay(to)); day = day.AddDate(0, 0, 1) {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		days++
+
+		rates, err := historical.FetchHistoricalRates(ctx, day)
+		if err != nil {
+			logger.Errorf("failed to fetch historical rates for %s: %v", day.Format(time.DateOnly), err)
+			failed++
+			continue
 		}
-		_, err := ru.repo.GetByCode(ctx, code)
+		entries, err := ru.historyEntries(ctx, pegs, rates)
 		if err != nil {
-			if err.Error() == "currency not found" {
-				err = ru.repo.Create(ctx, currency)
-				if err != nil {
-					logger.Errorf("failed to create currency %s in repository: %v", code, err)
-					continue
-				}
-			} else {
-				logger.Errorf("failed to get currency %s in repository: %v", code, err)
+			logger.Errorf("failed to backfill rates for %s: %v", day.Format(time.DateOnly), err)
+			failed++
+			continue
+		}
+		inserted, err := ru.repo.InsertRateHistory(ctx, entries)
+		if err != nil {
+			logger.Errorf("failed to backfill rates for %s: %v", day.Format(time.DateOnly), err)
+			failed++
+			continue
+		}
+		logger.Infof("backfilled %d rates for %s", inserted, day.Format(time.DateOnly))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to backfill %d of %d days", failed, days)
+	}
+	return nil
+}
+
+func (ru *RateUpdater) historyEntries(ctx context.Context, pegs map[string]model.CurrencyPeg, rates *model.ExchangeRates) ([]model.RateHistory, error) {
+	codes := make([]string, 0, len(rates.Rates)+len(pegs))
+	for code := range rates.Rates {
+		codes = append(codes, code)
+	}
+	for code := range pegs {
+		codes = append(codes, code)
+	}
+	currencies, err := ru.repo.GetByCodes(ctx, codes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load currencies: %w", err)
+	}
+
+	recordedAt := time.Unix(rates.Timestamp, 0).UTC()
+	entries := make([]model.RateHistory, 0, len(currencies))
+	for _, currency := range currencies {
+		entry := model.RateHistory{Code: currency.Code, RecordedAt: recordedAt}
+		if peg, pegged := pegs[currency.Code]; pegged {
+			anchorRate, ok := rates.Rates[peg.Anchor]
+			if !ok {
 				continue
 			}
+			entry.Rate = peg.RateFrom(anchorRate, commons.DivisionPrecision)
 		} else {
-			err = ru.repo.Update(ctx, currency)
-			if err != nil {
-				logger.Errorf("failed to update currency %s in repository: %v", code, err)
+			rate, ok := rates.Rates[currency.Code]
+			if !ok || currency.Locked {
 				continue
 			}
+			entry.Rate = rate
+			entry.Providers = rates.Providers[currency.Code]
 		}
-		if err := ru.cache.Set(ctx, code, rate, commons.RateUpdaterCacheExipiration); err != nil {
-			logger.Errorf("failed to update currency %s in cache: %v", code, err)
-		}
+		entries = append(entries, entry)
 	}
+	return entries, nil
+}
 
-	log.Println("rates updated successfully")
-	return nil
+func truncateToDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (ru *RateUpdater) loadPegs(ctx context.Context) map[string]model.CurrencyPeg {
+	pegs, err := ru.repo.ListPegs(ctx)
+	if err != nil {
+		logger.Errorf("failed to list currency pegs: %v", err)
+		return nil
+	}
+
+	pegsByCode := make(map[string]model.CurrencyPeg, len(pegs))
+	for _, peg := range pegs {
+		pegsByCode[peg.Code] = peg
+	}
+	return pegsByCode
+}
+
+func (ru *RateUpdater) loadCurrencies(ctx context.Context, rates *model.ExchangeRates) (map[string]model.Currency, error) {
+	codes := make([]string, 0, len(rates.Rates))
+	for code := range rates.Rates {
+		codes = append(codes, code)
+	}
+
+	currencies, err := ru.repo.GetByCodes(ctx, codes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load currencies: %w", err)
+	}
+
+	currenciesByCode := make(map[string]model.Currency, len(currencies))
+	for _, currency := range currencies {
+		currenciesByCode[currency.Code] = currency
+	}
+	return currenciesByCode, nil
+}
+
+func logSkippedLocked(current model.Currency, proposed decimal.Decimal) {
+	logger.Infof("skipping %s currency %s: locked at rate %s, provider proposed %s", current.Source, current.Code, current.Rate, proposed)
+}
+
+func (ru *RateUpdater) quarantineJump(ctx context.Context, current model.Currency, rate decimal.Decimal, rates *model.ExchangeRates) bool {
+	if !current.Rate.IsPositive() {
+		return false
+	}
+
+	threshold := ru.jumpThreshold
+	if current.JumpThreshold != nil {
+		threshold = *current.JumpThreshold
+	}
+	change := rate.Sub(current.Rate).DivRound(current.Rate, commons.DivisionPrecision)
+	if change.Abs().LessThanOrEqual(threshold) {
+		return false
+	}
+
+	quarantined := &model.QuarantinedRate{
+		ID:           uuid.New(),
+		Code:         current.Code,
+		PreviousRate: current.Rate,
+		Rate:         rate,
+		Change:       change,
+		Providers:    rates.Providers[current.Code],
+		RecordedAt:   time.Unix(rates.Timestamp, 0).UTC(),
+		CreatedAt:    time.Now().UTC(),
+	}
+	if err := ru.repo.QuarantineRate(ctx, quarantined); err != nil {
+		logger.Errorf("failed to quarantine rate for currency %s: %v", current.Code, err)
+		return true
+	}
+	logger.Infof("quarantined currency %s: provider rate %s moves %s from %s, beyond threshold %s", current.Code, rate, change, current.Rate, threshold)
+	return true
+}
+
+func peggedCurrencies(pegs map[string]model.CurrencyPeg, rates *model.ExchangeRates) []model.Currency {
+	var currencies []model.Currency
+	for code, peg := range pegs {
+		anchorRate, ok := rates.Rates[peg.Anchor]
+		if !ok {
+			continue
+		}
+		if _, pegged := pegs[peg.Anchor]; pegged {
+			continue
+		}
+
+		currencies = append(currencies, model.Currency{
+			Code:      code,
+			Rate:      peg.RateFrom(anchorRate, commons.DivisionPrecision),
+			UpdatedAt: time.Unix(rates.Timestamp, 0),
+			Source:    model.CurrencySourceManual,
+		})
+	}
+	return currencies
 }
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/Lutefd/challenge-bravo/internal/model"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -23,6 +25,22 @@ func (m *MockCurrencyRepository) GetByCode(ctx context.Context, code string) (*m
 	return nil, args.Error(1)
 }
 
+func (m *MockCurrencyRepository) GetByCodes(ctx context.Context, codes []string) ([]model.Currency, error) {
+	args := m.Called(ctx, codes)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.Currency), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCurrencyRepository) List(ctx context.Context, opts model.CurrencyListOptions) ([]model.Currency, int, error) {
+	args := m.Called(ctx, opts)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.Currency), args.Int(1), args.Error(2)
+	}
+	return nil, args.Int(1), args.Error(2)
+}
+
 func (m *MockCurrencyRepository) Create(ctx context.Context, currency *model.Currency) error {
 	args := m.Called(ctx, currency)
 	return args.Error(0)
@@ -33,11 +51,145 @@ func (m *MockCurrencyRepository) Update(ctx context.Context, currency *model.Cur
 	return args.Error(0)
 }
 
+func (m *MockCurrencyRepository) UpsertRates(ctx context.Context, currencies []model.Currency) (int, error) {
+	args := m.Called(ctx, currencies)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockCurrencyRepository) UpdatePeggedRates(ctx context.Context, currencies []model.Currency) error {
+	args := m.Called(ctx, currencies)
+	return args.Error(0)
+}
+
+func (m *MockCurrencyRepository) Replace(ctx context.Context, currency *model.Currency) error {
+	args := m.Called(ctx, currency)
+	return args.Error(0)
+}
+
+func (m *MockCurrencyRepository) UpdateMetadata(ctx context.Context, code string, metadata model.CurrencyMetadata) error {
+	args := m.Called(ctx, code, metadata)
+	return args.Error(0)
+}
+
+func (m *MockCurrencyRepository) SetLocked(ctx context.Context, code string, locked bool) error {
+	args := m.Called(ctx, code, locked)
+	return args.Error(0)
+}
+
+func (m *MockCurrencyRepository) SetJumpThreshold(ctx context.Context, code string, threshold *decimal.Decimal) error {
+	args := m.Called(ctx, code, threshold)
+	return args.Error(0)
+}
+
 func (m *MockCurrencyRepository) Delete(ctx context.Context, code string) error {
 	args := m.Called(ctx, code)
 	return args.Error(0)
 }
 
+func (m *MockCurrencyRepository) GetPeg(ctx context.Context, code string) (*model.CurrencyPeg, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.CurrencyPeg), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCurrencyRepository) ListPegs(ctx context.Context) ([]model.CurrencyPeg, error) {
+	args := m.Called(ctx)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.CurrencyPeg), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCurrencyRepository) GetRateHistory(ctx context.Context, code string, from, to time.Time) ([]model.RateHistory, error) {
+	args := m.Called(ctx, code, from, to)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.RateHistory), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCurrencyRepository) GetRatesBefore(ctx context.Context, codes []string, before time.Time) ([]model.RateHistory, error) {
+	args := m.Called(ctx, codes, before)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.RateHistory), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCurrencyRepository) InsertRateHistory(ctx context.Context, entries []model.RateHistory) (int, error) {
+	args := m.Called(ctx, entries)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockCurrencyRepository) GetLastRateSync(ctx context.Context) (time.Time, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockCurrencyRepository) SetLastRateSync(ctx context.Context, at time.Time) error {
+	args := m.Called(ctx, at)
+	return args.Error(0)
+}
+
+func (m *MockCurrencyRepository) ScheduleRateChange(ctx context.Context, change *model.ScheduledRateChange) error {
+	args := m.Called(ctx, change)
+	return args.Error(0)
+}
+
+func (m *MockCurrencyRepository) ListScheduledRateChanges(ctx context.Context, code string) ([]model.ScheduledRateChange, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.ScheduledRateChange), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCurrencyRepository) ListDueRateChanges(ctx context.Context, at time.Time) ([]model.ScheduledRateChange, error) {
+	args := m.Called(ctx, at)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.ScheduledRateChange), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCurrencyRepository) CancelScheduledRateChange(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockCurrencyRepository) ApplyScheduledRateChange(ctx context.Context, change model.ScheduledRateChange) error {
+	args := m.Called(ctx, change)
+	return args.Error(0)
+}
+
+func (m *MockCurrencyRepository) QuarantineRate(ctx context.Context, rate *model.QuarantinedRate) error {
+	args := m.Called(ctx, rate)
+	return args.Error(0)
+}
+
+func (m *MockCurrencyRepository) ListQuarantinedRates(ctx context.Context, code string) ([]model.QuarantinedRate, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.QuarantinedRate), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCurrencyRepository) ApproveQuarantinedRate(ctx context.Context, id, approvedBy uuid.UUID) (*model.QuarantinedRate, error) {
+	args := m.Called(ctx, id, approvedBy)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.QuarantinedRate), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCurrencyRepository) RejectQuarantinedRate(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
 func (m *MockCurrencyRepository) Close() error {
 	args := m.Called()
 	return args.Error(0)
@@ -47,21 +199,49 @@ type MockCache struct {
 	mock.Mock
 }
 
-func (m *MockCache) Get(ctx context.Context, key string) (float64, error) {
-	args := m.Called(ctx, key)
-	return args.Get(0).(float64), args.Error(1)
+func (m *MockCache) Get(ctx context.Context, code string) (model.Currency, error) {
+	args := m.Called(ctx, code)
+	return args.Get(0).(model.Currency), args.Error(1)
+}
+
+func (m *MockCache) GetMany(ctx context.Context, codes []string) (map[string]model.Currency, error) {
+	args := m.Called(ctx, codes)
+	if args.Get(0) != nil {
+		return args.Get(0).(map[string]model.Currency), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCache) GetSnapshot(ctx context.Context, codes []string) (model.RateSnapshot, error) {
+	args := m.Called(ctx, codes)
+	return args.Get(0).(model.RateSnapshot), args.Error(1)
 }
 
-func (m *MockCache) Set(ctx context.Context, key string, value float64, expiration time.Duration) error {
-	args := m.Called(ctx, key, value, expiration)
+func (m *MockCache) Set(ctx context.Context, currency model.Currency, expiration time.Duration) error {
+	args := m.Called(ctx, currency, expiration)
 	return args.Error(0)
 }
 
-func (m *MockCache) Delete(ctx context.Context, key string) error {
-	args := m.Called(ctx, key)
+func (m *MockCache) SetMany(ctx context.Context, currencies []model.Currency, expiration time.Duration) error {
+	args := m.Called(ctx, currencies, expiration)
 	return args.Error(0)
 }
 
+func (m *MockCache) Delete(ctx context.Context, code string) error {
+	args := m.Called(ctx, code)
+	return args.Error(0)
+}
+
+func (m *MockCache) UpdateSnapshot(ctx context.Context, currencies []model.Currency, deleted []string, expiration time.Duration) (uint64, error) {
+	args := m.Called(ctx, currencies, deleted, expiration)
+	return args.Get(0).(uint64), args.Error(1)
+}
+
+func (m *MockCache) FillSnapshot(ctx context.Context, version uint64, currencies []model.Currency) (bool, error) {
+	args := m.Called(ctx, version, currencies)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockCache) Close() error {
 	args := m.Called()
 	return args.Error(0)
@@ -99,6 +279,10 @@ func TestNewRateUpdater(t *testing.T) {
 	assert.Equal(t, cache, updater.cache, "expected cache to be correctly assigned")
 	assert.Equal(t, externalAPI, updater.externalAPI, "expected externalAPI to be correctly assigned")
 	assert.Equal(t, interval, updater.interval, "expected interval to be correctly assigned")
+	assert.Equal(t, "0.25", updater.jumpThreshold.String())
+
+	updater = NewRateUpdater(repo, cache, externalAPI, interval, WithJumpThreshold(decimal.RequireFromString("0.1")))
+	assert.Equal(t, "0.1", updater.jumpThreshold.String())
 }
 
 func TestRateUpdater_updateRates(t *testing.T) {
@@ -107,15 +291,36 @@ func TestRateUpdater_updateRates(t *testing.T) {
 	ctx := context.Background()
 	mockRates := &model.ExchangeRates{
 		Timestamp: time.Now().Unix(),
-		Rates: map[string]float64{
-			"USD": 1.0,
-			"EUR": 0.85,
+		Rates: map[string]decimal.Decimal{
+			"USD": decimal.NewFromInt(1),
+			"EUR": decimal.RequireFromString("0.85"),
+		},
+		Providers: map[string][]string{
+			"USD": {"ecb", "openexchangerates"},
+			"EUR": {"ecb", "openexchangerates"},
 		},
 	}
 
 	externalAPI.On("FetchRates", ctx).Return(mockRates, nil)
-	repo.On("Update", ctx, mock.AnythingOfType("*model.Currency")).Return(nil)
-	cache.On("Set", ctx, mock.AnythingOfType("string"), mock.AnythingOfType("float64"), 1*time.Hour).Return(nil)
+	repo.On("SetLastRateSync", ctx, time.Unix(mockRates.Timestamp, 0).UTC()).Return(nil).Once()
+	repo.On("ListPegs", ctx).Return([]model.CurrencyPeg{}, nil)
+	repo.On("GetByCodes", ctx, mock.Anything).Return([]model.Currency{}, nil).Once()
+	stored := []model.Currency{
+		{Code: "USD", Rate: decimal.NewFromInt(1), CurrencyMetadata: model.CurrencyMetadata{Name: "US Dollar"}},
+		{Code: "EUR", Rate: decimal.RequireFromString("0.85"), CurrencyMetadata: model.CurrencyMetadata{Name: "Euro"}},
+	}
+	repo.On("GetByCodes", ctx, mock.MatchedBy(func(codes []string) bool {
+		return len(codes) == 2
+	})).Return(stored, nil).Once()
+	repo.On("UpsertRates", ctx, mock.MatchedBy(func(currencies []model.Currency) bool {
+		for _, c := range currencies {
+			if c.Source != model.CurrencySourceProvider || !assert.ObjectsAreEqual([]string{"ecb", "openexchangerates"}, c.Providers) {
+				return false
+			}
+		}
+		return len(currencies) == 2
+	})).Return(2, nil).Once()
+	cache.On("UpdateSnapshot", ctx, stored, []string(nil), 1*time.Hour).Return(uint64(1), nil).Once()
 
 	err := updater.updateRates(ctx)
 
@@ -123,6 +328,315 @@ func TestRateUpdater_updateRates(t *testing.T) {
 	externalAPI.AssertExpectations(t)
 	repo.AssertExpectations(t)
 	cache.AssertExpectations(t)
+
+	status := updater.Status()
+	assert.NotNil(t, status.LastFetchAt)
+	assert.Equal(t, 2, status.CurrenciesUpdated)
+	assert.Empty(t, status.LastError)
+}
+
+func TestRateUpdater_updateRates_Pegs(t *testing.T) {
+	updater, repo, cache, externalAPI := newTestRateUpdater()
+
+	ctx := context.Background()
+	mockRates := &model.ExchangeRates{
+		Timestamp: time.Now().Unix(),
+		Rates: map[string]decimal.Decimal{
+			"USD": decimal.NewFromInt(1),
+			"EUR": decimal.RequireFromString("0.85"),
+			"DKK": decimal.RequireFromString("6.4"),
+		},
+	}
+
+	externalAPI.On("FetchRates", ctx).Return(mockRates, nil)
+	repo.On("SetLastRateSync", ctx, time.Unix(mockRates.Timestamp, 0).UTC()).Return(nil).Once()
+	repo.On("ListPegs", ctx).Return([]model.CurrencyPeg{
+		{Code: "HURB", Anchor: "EUR", Ratio: decimal.RequireFromString("0.5")},
+		{Code: "DKK", Anchor: "EUR", Ratio: decimal.RequireFromString("0.134")},
+		{Code: "ARS", Anchor: "XYZ", Ratio: decimal.NewFromInt(2)},
+	}, nil)
+	repo.On("GetByCodes", ctx, mock.Anything).Return([]model.Currency{}, nil).Once()
+	dkkRate := decimal.RequireFromString("0.85").DivRound(decimal.RequireFromString("0.134"), 20)
+	stored := []model.Currency{
+		{Code: "USD", Rate: decimal.NewFromInt(1)},
+		{Code: "EUR", Rate: decimal.RequireFromString("0.85")},
+		{Code: "HURB", Rate: decimal.RequireFromString("1.7"), Source: model.CurrencySourceManual},
+		{Code: "DKK", Rate: dkkRate, Source: model.CurrencySourceManual},
+	}
+	repo.On("GetByCodes", ctx, mock.Anything).Return(stored, nil).Once()
+	repo.On("UpsertRates", ctx, mock.MatchedBy(func(currencies []model.Currency) bool {
+		byCode := make(map[string]model.Currency)
+		for _, c := range currencies {
+			byCode[c.Code] = c
+		}
+		return len(currencies) == 2 &&
+			byCode["USD"].Source == model.CurrencySourceProvider &&
+			byCode["EUR"].Source == model.CurrencySourceProvider
+	})).Return(2, nil).Once()
+	repo.On("UpdatePeggedRates", ctx, mock.MatchedBy(func(currencies []model.Currency) bool {
+		byCode := make(map[string]model.Currency)
+		for _, c := range currencies {
+			byCode[c.Code] = c
+		}
+		return len(currencies) == 2 &&
+			byCode["HURB"].Source == model.CurrencySourceManual &&
+			byCode["HURB"].Rate.Equal(decimal.RequireFromString("1.7")) &&
+			byCode["DKK"].Rate.Equal(dkkRate)
+	})).Return(nil).Once()
+	cache.On("UpdateSnapshot", ctx, stored, []string(nil), 1*time.Hour).Return(uint64(1), nil).Once()
+
+	err := updater.updateRates(ctx)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+	cache.AssertExpectations(t)
+	assert.Equal(t, 2, updater.Status().CurrenciesUpdated)
+}
+
+func TestRateUpdater_updateRates_Locked(t *testing.T) {
+	updater, repo, cache, externalAPI := newTestRateUpdater()
+
+	ctx := context.Background()
+	mockRates := &model.ExchangeRates{
+		Timestamp: time.Now().Unix(),
+		Rates: map[string]decimal.Decimal{
+			"EUR": decimal.RequireFromString("0.85"),
+			"ARS": decimal.RequireFromString("350"),
+		},
+	}
+
+	externalAPI.On("FetchRates", ctx).Return(mockRates, nil)
+	repo.On("SetLastRateSync", ctx, time.Unix(mockRates.Timestamp, 0).UTC()).Return(nil).Once()
+	repo.On("ListPegs", ctx).Return([]model.CurrencyPeg{}, nil)
+	repo.On("GetByCodes", ctx, mock.Anything).Return([]model.Currency{
+		{Code: "EUR", Rate: decimal.RequireFromString("0.8"), Source: model.CurrencySourceProvider},
+		{Code: "ARS", Rate: decimal.RequireFromString("900"), Source: model.CurrencySourceManual, Locked: true},
+	}, nil).Once()
+	stored := []model.Currency{{Code: "EUR", Rate: decimal.RequireFromString("0.85"), Source: model.CurrencySourceProvider}}
+	repo.On("GetByCodes", ctx, []string{"EUR"}).Return(stored, nil).Once()
+	repo.On("UpsertRates", ctx, mock.MatchedBy(func(currencies []model.Currency) bool {
+		return len(currencies) == 1 && currencies[0].Code == "EUR" && currencies[0].Source == model.CurrencySourceProvider
+	})).Return(1, nil).Once()
+	cache.On("UpdateSnapshot", ctx, stored, []string(nil), 1*time.Hour).Return(uint64(1), nil).Once()
+
+	err := updater.updateRates(ctx)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+	cache.AssertExpectations(t)
+	assert.Equal(t, 1, updater.Status().CurrenciesUpdated)
+}
+
+func TestRateUpdater_updateRates_LockedConcurrently(t *testing.T) {
+	updater, repo, cache, externalAPI := newTestRateUpdater()
+
+	ctx := context.Background()
+	mockRates := &model.ExchangeRates{
+		Timestamp: time.Now().Unix(),
+		Rates: map[string]decimal.Decimal{
+			"EUR": decimal.RequireFromString("0.85"),
+			"GBP": decimal.RequireFromString("0.75"),
+		},
+	}
+
+	externalAPI.On("FetchRates", ctx).Return(mockRates, nil)
+	repo.On("SetLastRateSync", ctx, time.Unix(mockRates.Timestamp, 0).UTC()).Return(nil).Once()
+	repo.On("ListPegs", ctx).Return([]model.CurrencyPeg{}, nil)
+	repo.On("GetByCodes", ctx, mock.Anything).Return([]model.Currency{}, nil)
+	repo.On("UpsertRates", ctx, mock.MatchedBy(func(currencies []model.Currency) bool {
+		return len(currencies) == 2
+	})).Return(1, nil).Once()
+	cache.On("UpdateSnapshot", ctx, mock.Anything, []string(nil), 1*time.Hour).Return(uint64(1), nil).Once()
+
+	err := updater.updateRates(ctx)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+	assert.Equal(t, 1, updater.Status().CurrenciesUpdated)
+}
+
+func TestRateUpdater_updateRates_LockedAnchor(t *testing.T) {
+	updater, repo, cache, externalAPI := newTestRateUpdater()
+
+	ctx := context.Background()
+	mockRates := &model.ExchangeRates{
+		Timestamp: time.Now().Unix(),
+		Rates: map[string]decimal.Decimal{
+			"USD": decimal.NewFromInt(1),
+			"EUR": decimal.RequireFromString("0.95"),
+		},
+	}
+
+	externalAPI.On("FetchRates", ctx).Return(mockRates, nil)
+	repo.On("SetLastRateSync", ctx, time.Unix(mockRates.Timestamp, 0).UTC()).Return(nil).Once()
+	repo.On("ListPegs", ctx).Return([]model.CurrencyPeg{
+		{Code: "HURB", Anchor: "EUR", Ratio: decimal.RequireFromString("0.5")},
+	}, nil)
+	repo.On("GetByCodes", ctx, mock.Anything).Return([]model.Currency{
+		{Code: "USD", Rate: decimal.NewFromInt(1), Source: model.CurrencySourceProvider},
+		{Code: "EUR", Rate: decimal.RequireFromString("0.8"), Source: model.CurrencySourceManual, Locked: true},
+	}, nil).Once()
+	stored := []model.Currency{{Code: "USD", Rate: decimal.NewFromInt(1), Source: model.CurrencySourceProvider}}
+	repo.On("GetByCodes", ctx, []string{"USD"}).Return(stored, nil).Once()
+	repo.On("UpsertRates", ctx, mock.MatchedBy(func(currencies []model.Currency) bool {
+		return len(currencies) == 1 && currencies[0].Code == "USD"
+	})).Return(1, nil).Once()
+	cache.On("UpdateSnapshot", ctx, stored, []string(nil), 1*time.Hour).Return(uint64(1), nil).Once()
+
+	err := updater.updateRates(ctx)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+	cache.AssertExpectations(t)
+	assert.Equal(t, 1, updater.Status().CurrenciesUpdated)
+}
+
+func TestRateUpdater_updateRates_NonPositiveRate(t *testing.T) {
+	updater, repo, cache, externalAPI := newTestRateUpdater()
+
+	ctx := context.Background()
+	mockRates := &model.ExchangeRates{
+		Timestamp: time.Now().Unix(),
+		Rates: map[string]decimal.Decimal{
+			"USD": decimal.NewFromInt(1),
+			"EUR": decimal.Zero,
+		},
+	}
+
+	externalAPI.On("FetchRates", ctx).Return(mockRates, nil)
+	repo.On("SetLastRateSync", ctx, time.Unix(mockRates.Timestamp, 0).UTC()).Return(nil).Once()
+	repo.On("ListPegs", ctx).Return([]model.CurrencyPeg{
+		{Code: "HURB", Anchor: "EUR", Ratio: decimal.RequireFromString("0.5")},
+	}, nil)
+	repo.On("GetByCodes", ctx, mock.Anything).Return([]model.Currency{
+		{Code: "USD", Rate: decimal.NewFromInt(1), Source: model.CurrencySourceProvider},
+		{Code: "EUR", Rate: decimal.RequireFromString("0.9"), Source: model.CurrencySourceProvider},
+	}, nil).Once()
+	stored := []model.Currency{{Code: "USD", Rate: decimal.NewFromInt(1), Source: model.CurrencySourceProvider}}
+	repo.On("GetByCodes", ctx, []string{"USD"}).Return(stored, nil).Once()
+	repo.On("UpsertRates", ctx, mock.MatchedBy(func(currencies []model.Currency) bool {
+		return len(currencies) == 1 && currencies[0].Code == "USD"
+	})).Return(1, nil).Once()
+	cache.On("UpdateSnapshot", ctx, stored, []string(nil), 1*time.Hour).Return(uint64(1), nil).Once()
+
+	err := updater.updateRates(ctx)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+	cache.AssertExpectations(t)
+	assert.Equal(t, 1, updater.Status().CurrenciesUpdated)
+}
+
+func TestRateUpdater_updateRates_Quarantine(t *testing.T) {
+	updater, repo, cache, externalAPI := newTestRateUpdater()
+
+	ctx := context.Background()
+	mockRates := &model.ExchangeRates{
+		Timestamp: time.Now().Unix(),
+		Rates: map[string]decimal.Decimal{
+			"EUR": decimal.RequireFromString("0.85"),
+			"ARS": decimal.RequireFromString("1350"),
+			"GBP": decimal.RequireFromString("0.8"),
+		},
+		Providers: map[string][]string{
+			"ARS": {"ecb", "frankfurter"},
+		},
+	}
+	gbpThreshold := decimal.RequireFromString("0.01")
+
+	externalAPI.On("FetchRates", ctx).Return(mockRates, nil)
+	repo.On("SetLastRateSync", ctx, time.Unix(mockRates.Timestamp, 0).UTC()).Return(nil).Once()
+	repo.On("ListPegs", ctx).Return([]model.CurrencyPeg{
+		{Code: "HURB", Anchor: "ARS", Ratio: decimal.NewFromInt(2)},
+	}, nil)
+	repo.On("GetByCodes", ctx, mock.Anything).Return([]model.Currency{
+		{Code: "EUR", Rate: decimal.RequireFromString("0.8")},
+		{Code: "ARS", Rate: decimal.RequireFromString("900")},
+		{Code: "GBP", Rate: decimal.RequireFromString("0.78"), JumpThreshold: &gbpThreshold},
+	}, nil).Once()
+	stored := []model.Currency{{Code: "EUR", Rate: decimal.RequireFromString("0.85")}}
+	repo.On("GetByCodes", ctx, []string{"EUR"}).Return(stored, nil).Once()
+	repo.On("QuarantineRate", ctx, mock.MatchedBy(func(q *model.QuarantinedRate) bool {
+		return q.Code == "ARS" && q.PreviousRate.Equal(decimal.NewFromInt(900)) &&
+			q.Change.Equal(decimal.RequireFromString("0.5")) &&
+			assert.ObjectsAreEqual([]string{"ecb", "frankfurter"}, q.Providers)
+	})).Return(nil).Once()
+	repo.On("QuarantineRate", ctx, mock.MatchedBy(func(q *model.QuarantinedRate) bool {
+		return q.Code == "GBP" && q.Rate.Equal(decimal.RequireFromString("0.8"))
+	})).Return(nil).Once()
+	repo.On("UpsertRates", ctx, mock.MatchedBy(func(currencies []model.Currency) bool {
+		return len(currencies) == 1 && currencies[0].Code == "EUR"
+	})).Return(1, nil).Once()
+	cache.On("UpdateSnapshot", ctx, stored, []string(nil), 1*time.Hour).Return(uint64(1), nil).Once()
+
+	err := updater.updateRates(ctx)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+	cache.AssertExpectations(t)
+}
+
+type MockRateChangePublisher struct {
+	mock.Mock
+}
+
+func (m *MockRateChangePublisher) PublishRateChanges(ctx context.Context, events []model.RateChangeEvent) error {
+	args := m.Called(ctx, events)
+	return args.Error(0)
+}
+
+func TestRateUpdater_updateRates_PublishesChanges(t *testing.T) {
+	updater, repo, cache, externalAPI := newTestRateUpdater()
+	publisher := &MockRateChangePublisher{}
+	WithRateChangePublisher(publisher)(updater)
+
+	ctx := context.Background()
+	mockRates := &model.ExchangeRates{
+		Timestamp: time.Now().Unix(),
+		Rates: map[string]decimal.Decimal{
+			"EUR": decimal.RequireFromString("0.85"),
+			"ARS": decimal.RequireFromString("350"),
+		},
+	}
+
+	externalAPI.On("FetchRates", ctx).Return(mockRates, nil)
+	repo.On("SetLastRateSync", ctx, time.Unix(mockRates.Timestamp, 0).UTC()).Return(nil).Once()
+	repo.On("ListPegs", ctx).Return([]model.CurrencyPeg{}, nil)
+	repo.On("GetByCodes", ctx, mock.Anything).Return([]model.Currency{
+		{Code: "ARS", Rate: decimal.RequireFromString("900"), Locked: true},
+	}, nil)
+	repo.On("UpsertRates", ctx, mock.Anything).Return(1, nil).Once()
+	cache.On("UpdateSnapshot", ctx, mock.Anything, []string(nil), 1*time.Hour).Return(uint64(42), nil).Once()
+	publisher.On("PublishRateChanges", ctx, mock.MatchedBy(func(events []model.RateChangeEvent) bool {
+		return len(events) == 1 && events[0].Code == "EUR" && events[0].Rate.Equal(decimal.RequireFromString("0.85")) && events[0].Version == 42 && !events[0].ChangedAt.IsZero()
+	})).Return(errors.New("redis down")).Once()
+
+	err := updater.updateRates(ctx)
+
+	assert.NoError(t, err)
+	publisher.AssertExpectations(t)
+}
+
+func TestRateUpdater_updateRates_LoadError(t *testing.T) {
+	updater, repo, _, externalAPI := newTestRateUpdater()
+
+	ctx := context.Background()
+	mockRates := &model.ExchangeRates{
+		Timestamp: time.Now().Unix(),
+		Rates: map[string]decimal.Decimal{
+			"EUR": decimal.RequireFromString("0.85"),
+		},
+	}
+
+	externalAPI.On("FetchRates", ctx).Return(mockRates, nil)
+	repo.On("ListPegs", ctx).Return([]model.CurrencyPeg{}, nil)
+	repo.On("GetByCodes", ctx, mock.Anything).Return(nil, errors.New("db down"))
+
+	err := updater.updateRates(ctx)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to load currencies")
+	repo.AssertNotCalled(t, "UpsertRates", mock.Anything, mock.Anything)
 }
 
 func TestRateUpdater_updateRates_Error(t *testing.T) {
@@ -137,31 +651,152 @@ func TestRateUpdater_updateRates_Error(t *testing.T) {
 	assert.Error(t, err, "expected an error due to failed API fetch")
 	assert.Contains(t, err.Error(), "failed to fetch rates", "expected error to contain specific message")
 	externalAPI.AssertExpectations(t)
+
+	status := updater.Status()
+	assert.Nil(t, status.LastFetchAt)
+	assert.Equal(t, "failed to fetch rates: API error", status.LastError)
+	assert.NotNil(t, status.LastErrorAt)
 }
 
-func TestRateUpdater_populateRates(t *testing.T) {
+func TestRateUpdater_updateRates_CreatesMissing(t *testing.T) {
 	updater, repo, cache, externalAPI := newTestRateUpdater()
 
 	ctx := context.Background()
 	mockRates := &model.ExchangeRates{
 		Timestamp: time.Now().Unix(),
-		Rates: map[string]float64{
-			"USD": 1.0,
-			"EUR": 0.85,
+		Rates: map[string]decimal.Decimal{
+			"EUR": decimal.RequireFromString("0.85"),
+			"JPY": decimal.RequireFromString("150"),
+			"KWD": decimal.RequireFromString("0.31"),
+			"BTC": decimal.RequireFromString("0.000016"),
+			"XAU": decimal.RequireFromString("0.0004"),
+			"XDR": decimal.RequireFromString("0.75"),
 		},
 	}
 
 	externalAPI.On("FetchRates", ctx).Return(mockRates, nil)
-	repo.On("GetByCode", ctx, mock.AnythingOfType("string")).Return((*model.Currency)(nil), errors.New("currency not found"))
-	repo.On("Create", ctx, mock.AnythingOfType("*model.Currency")).Return(nil)
-	cache.On("Set", ctx, mock.AnythingOfType("string"), mock.AnythingOfType("float64"), 1*time.Hour).Return(nil)
+	repo.On("SetLastRateSync", ctx, time.Unix(mockRates.Timestamp, 0).UTC()).Return(nil).Once()
+	repo.On("ListPegs", ctx).Return([]model.CurrencyPeg{}, nil)
+	repo.On("GetByCodes", ctx, mock.Anything).Return([]model.Currency{}, nil)
+	repo.On("UpsertRates", ctx, mock.MatchedBy(func(currencies []model.Currency) bool {
+		byCode := make(map[string]model.Currency)
+		for _, c := range currencies {
+			if !c.CreatedAt.Equal(time.Unix(mockRates.Timestamp, 0)) {
+				return false
+			}
+			byCode[c.Code] = c
+		}
+		return len(currencies) == 6 &&
+			*byCode["EUR"].MinorUnits == 2 && byCode["EUR"].Kind == model.CurrencyKindFiat &&
+			*byCode["JPY"].MinorUnits == 0 &&
+			*byCode["KWD"].MinorUnits == 3 &&
+			*byCode["BTC"].MinorUnits == 8 && byCode["BTC"].Kind == model.CurrencyKindCrypto &&
+			byCode["XAU"].MinorUnits == nil && byCode["XAU"].Kind == model.CurrencyKindCommodity &&
+			byCode["XDR"].MinorUnits == nil && byCode["XDR"].Kind == ""
+	})).Return(6, nil).Once()
+	cache.On("UpdateSnapshot", ctx, mock.Anything, []string(nil), 1*time.Hour).Return(uint64(1), nil).Once()
 
-	err := updater.populateRates(ctx)
+	err := updater.updateRates(ctx)
 
-	assert.NoError(t, err, "expected no error during populateRates")
-	externalAPI.AssertExpectations(t)
+	assert.NoError(t, err)
 	repo.AssertExpectations(t)
 	cache.AssertExpectations(t)
+	repo.AssertNotCalled(t, "GetByCode", mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestRateUpdater_updateRates_ReloadError(t *testing.T) {
+	updater, repo, cache, externalAPI := newTestRateUpdater()
+
+	ctx := context.Background()
+	mockRates := &model.ExchangeRates{
+		Timestamp: time.Now().Unix(),
+		Rates: map[string]decimal.Decimal{
+			"EUR": decimal.RequireFromString("0.85"),
+		},
+	}
+
+	externalAPI.On("FetchRates", ctx).Return(mockRates, nil)
+	repo.On("SetLastRateSync", ctx, time.Unix(mockRates.Timestamp, 0).UTC()).Return(nil).Once()
+	repo.On("ListPegs", ctx).Return([]model.CurrencyPeg{}, nil)
+	repo.On("GetByCodes", ctx, []string{"EUR"}).Return([]model.Currency{}, nil).Once()
+	repo.On("GetByCodes", ctx, []string{"EUR"}).Return(nil, errors.New("db down")).Once()
+	repo.On("UpsertRates", ctx, mock.Anything).Return(1, nil).Once()
+	cache.On("UpdateSnapshot", ctx, []model.Currency(nil), []string{"EUR"}, 1*time.Hour).Return(uint64(1), nil).Once()
+
+	err := updater.updateRates(ctx)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+	cache.AssertExpectations(t)
+}
+
+func TestRateUpdater_updateRates_SaveError(t *testing.T) {
+	updater, repo, cache, externalAPI := newTestRateUpdater()
+
+	ctx := context.Background()
+	mockRates := &model.ExchangeRates{
+		Timestamp: time.Now().Unix(),
+		Rates: map[string]decimal.Decimal{
+			"EUR": decimal.RequireFromString("0.85"),
+			"BRL": decimal.RequireFromString("5.4"),
+		},
+	}
+
+	externalAPI.On("FetchRates", ctx).Return(mockRates, nil)
+	repo.On("ListPegs", ctx).Return([]model.CurrencyPeg{}, nil)
+	repo.On("GetByCodes", ctx, mock.Anything).Return([]model.Currency{}, nil)
+	repo.On("UpsertRates", ctx, mock.Anything).Return(0, errors.New("failed to commit transaction: connection reset"))
+
+	err := updater.updateRates(ctx)
+
+	assert.EqualError(t, err, "failed to save rates: failed to commit transaction: connection reset")
+	assert.Equal(t, err.Error(), updater.Status().LastError)
+	assert.Nil(t, updater.Status().LastFetchAt)
+	repo.AssertNotCalled(t, "SetLastRateSync", mock.Anything, mock.Anything)
+	cache.AssertNotCalled(t, "UpdateSnapshot", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRateUpdater_applyScheduledChanges(t *testing.T) {
+	updater, repo, cache, _ := newTestRateUpdater()
+
+	ctx := context.Background()
+	applied := model.ScheduledRateChange{ID: uuid.New(), Code: "HURB", Rate: decimal.NewFromInt(4), EffectiveAt: time.Now().Add(-time.Minute)}
+	canceled := model.ScheduledRateChange{ID: uuid.New(), Code: "GOLD", Rate: decimal.NewFromInt(2), EffectiveAt: time.Now().Add(-time.Minute)}
+
+	repo.On("ListDueRateChanges", ctx, mock.AnythingOfType("time.Time")).Return([]model.ScheduledRateChange{applied, canceled}, nil)
+	repo.On("ListPegs", ctx).Return([]model.CurrencyPeg{
+		{Code: "MINI", Anchor: "HURB", Ratio: decimal.NewFromInt(10)},
+	}, nil)
+	repo.On("ApplyScheduledRateChange", ctx, applied).Return(nil).Once()
+	repo.On("ApplyScheduledRateChange", ctx, canceled).Return(model.ErrScheduledChangeNotFound).Once()
+	repo.On("UpdatePeggedRates", ctx, mock.MatchedBy(func(currencies []model.Currency) bool {
+		return len(currencies) == 1 && currencies[0].Code == "MINI" && currencies[0].Rate.Equal(decimal.RequireFromString("0.4"))
+	})).Return(nil).Once()
+	hurb := []model.Currency{{Code: "HURB", Rate: decimal.NewFromInt(4), Source: model.CurrencySourceManual, Locked: true}}
+	mini := []model.Currency{{Code: "MINI", Rate: decimal.RequireFromString("0.4"), Source: model.CurrencySourceManual}}
+	repo.On("GetByCodes", ctx, []string{"HURB"}).Return(hurb, nil).Once()
+	repo.On("GetByCodes", ctx, []string{"MINI"}).Return(mini, nil).Once()
+	cache.On("UpdateSnapshot", ctx, hurb, []string(nil), 1*time.Hour).Return(uint64(1), nil).Once()
+	cache.On("UpdateSnapshot", ctx, mini, []string(nil), 1*time.Hour).Return(uint64(2), nil).Once()
+
+	updater.applyScheduledChanges(ctx)
+
+	repo.AssertExpectations(t)
+	cache.AssertExpectations(t)
+	repo.AssertNotCalled(t, "GetByCodes", ctx, []string{"GOLD"})
+}
+
+func TestRateUpdater_applyScheduledChanges_NoneDue(t *testing.T) {
+	updater, repo, _, _ := newTestRateUpdater()
+
+	ctx := context.Background()
+	repo.On("ListDueRateChanges", ctx, mock.AnythingOfType("time.Time")).Return([]model.ScheduledRateChange{}, nil)
+
+	updater.applyScheduledChanges(ctx)
+
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "ListPegs", mock.Anything)
 }
 
 func TestRateUpdater_Start(t *testing.T) {
@@ -173,17 +808,20 @@ func TestRateUpdater_Start(t *testing.T) {
 
 	mockRates := &model.ExchangeRates{
 		Timestamp: time.Now().Unix(),
-		Rates: map[string]float64{
-			"USD": 1.0,
-			"EUR": 0.85,
+		Rates: map[string]decimal.Decimal{
+			"USD": decimal.NewFromInt(1),
+			"EUR": decimal.RequireFromString("0.85"),
 		},
 	}
 
 	externalAPI.On("FetchRates", mock.Anything).Return(mockRates, nil)
-	repo.On("GetByCode", mock.Anything, mock.AnythingOfType("string")).Return((*model.Currency)(nil), errors.New("currency not found"))
-	repo.On("Create", mock.Anything, mock.AnythingOfType("*model.Currency")).Return(nil)
-	repo.On("Update", mock.Anything, mock.AnythingOfType("*model.Currency")).Return(nil)
-	cache.On("Set", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("float64"), 1*time.Hour).Return(nil)
+	repo.On("ListPegs", mock.Anything).Return([]model.CurrencyPeg{}, nil)
+	repo.On("GetByCodes", mock.Anything, mock.Anything).Return([]model.Currency{}, nil)
+	repo.On("UpsertRates", mock.Anything, mock.AnythingOfType("[]model.Currency")).Return(2, nil)
+	repo.On("ListDueRateChanges", mock.Anything, mock.AnythingOfType("time.Time")).Return([]model.ScheduledRateChange{}, nil)
+	repo.On("GetLastRateSync", mock.Anything).Return(time.Time{}, nil).Once()
+	repo.On("SetLastRateSync", mock.Anything, time.Unix(mockRates.Timestamp, 0).UTC()).Return(nil)
+	cache.On("UpdateSnapshot", mock.Anything, mock.Anything, []string(nil), 1*time.Hour).Return(uint64(1), nil)
 
 	doneChan := make(chan struct{})
 
@@ -200,4 +838,127 @@ func TestRateUpdater_Start(t *testing.T) {
 	externalAPI.AssertNumberOfCalls(t, "FetchRates", 4)
 	repo.AssertExpectations(t)
 	cache.AssertExpectations(t)
+
+	status := updater.Status()
+	assert.True(t, status.Started)
+	assert.NotNil(t, status.NextRunAt)
+	assert.Equal(t, 2, status.CurrenciesUpdated)
+}
+
+type MockHistoricalAPIClient struct {
+	MockExternalAPIClient
+}
+
+func (m *MockHistoricalAPIClient) FetchHistoricalRates(ctx context.Context, date time.Time) (*model.ExchangeRates, error) {
+	args := m.Called(ctx, date)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.ExchangeRates), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func newTestBackfillUpdater() (*RateUpdater, *MockCurrencyRepository, *MockHistoricalAPIClient) {
+	repo := &MockCurrencyRepository{}
+	externalAPI := &MockHistoricalAPIClient{}
+	updater := NewRateUpdater(repo, &MockCache{}, externalAPI, time.Hour)
+	return updater, repo, externalAPI
+}
+
+func TestRateUpdater_Backfill(t *testing.T) {
+	updater, repo, externalAPI := newTestBackfillUpdater()
+
+	ctx := context.Background()
+	first := time.Date(2024, 8, 9, 0, 0, 0, 0, time.UTC)
+	second := first.AddDate(0, 0, 1)
+	recordedAt := first.Add(23*time.Hour + 59*time.Minute)
+
+	externalAPI.On("FetchHistoricalRates", ctx, first).Return(&model.ExchangeRates{
+		Timestamp: recordedAt.Unix(),
+		Rates: map[string]decimal.Decimal{
+			"EUR": decimal.RequireFromString("0.9"),
+			"ARS": decimal.RequireFromString("350"),
+			"XYZ": decimal.RequireFromString("3"),
+		},
+		Providers: map[string][]string{"EUR": {"ecb"}},
+	}, nil)
+	externalAPI.On("FetchHistoricalRates", ctx, second).Return(nil, errors.New("API error"))
+	repo.On("ListPegs", ctx).Return([]model.CurrencyPeg{
+		{Code: "HURB", Anchor: "EUR", Ratio: decimal.RequireFromString("0.5")},
+	}, nil)
+	repo.On("GetByCodes", ctx, mock.Anything).Return([]model.Currency{
+		{Code: "EUR"},
+		{Code: "ARS", Locked: true},
+		{Code: "HURB", Locked: true},
+	}, nil)
+	repo.On("InsertRateHistory", ctx, mock.MatchedBy(func(entries []model.RateHistory) bool {
+		byCode := make(map[string]model.RateHistory)
+		for _, entry := range entries {
+			byCode[entry.Code] = entry
+		}
+		return len(entries) == 2 &&
+			byCode["EUR"].Rate.Equal(decimal.RequireFromString("0.9")) &&
+			byCode["EUR"].RecordedAt.Equal(recordedAt) &&
+			assert.ObjectsAreEqual([]string{"ecb"}, byCode["EUR"].Providers) &&
+			byCode["HURB"].Rate.Equal(decimal.RequireFromString("1.8"))
+	})).Return(2, nil).Once()
+
+	err := updater.Backfill(ctx, first, second)
+
+	assert.EqualError(t, err, "failed to backfill 1 of 2 days")
+	externalAPI.AssertExpectations(t)
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestRateUpdater_Backfill_Unsupported(t *testing.T) {
+	updater, _, _, _ := newTestRateUpdater()
+
+	err := updater.Backfill(context.Background(), time.Now().AddDate(0, 0, -1), time.Now())
+
+	assert.ErrorIs(t, err, ErrHistoricalRatesUnsupported)
+}
+
+func TestRateUpdater_backfillMissed(t *testing.T) {
+	updater, repo, externalAPI := newTestBackfillUpdater()
+
+	ctx := context.Background()
+	today := truncateToDay(time.Now())
+	repo.On("GetLastRateSync", ctx).Return(today.AddDate(0, 0, -3).Add(10*time.Hour), nil)
+	repo.On("ListPegs", ctx).Return([]model.CurrencyPeg{}, nil)
+	repo.On("GetByCodes", ctx, mock.Anything).Return([]model.Currency{{Code: "EUR"}}, nil)
+	repo.On("InsertRateHistory", ctx, mock.Anything).Return(1, nil)
+	for _, day := range []time.Time{today.AddDate(0, 0, -2), today.AddDate(0, 0, -1)} {
+		externalAPI.On("FetchHistoricalRates", ctx, day).Return(&model.ExchangeRates{
+			Timestamp: day.Unix(),
+			Rates:     map[string]decimal.Decimal{"EUR": decimal.RequireFromString("0.9")},
+		}, nil).Once()
+	}
+
+	updater.backfillMissed(ctx)
+
+	externalAPI.AssertExpectations(t)
+	repo.AssertNumberOfCalls(t, "InsertRateHistory", 2)
+}
+
+func TestRateUpdater_backfillMissed_NoGap(t *testing.T) {
+	tests := []struct {
+		name     string
+		lastSync time.Time
+	}{
+		{name: "Never synced", lastSync: time.Time{}},
+		{name: "Synced yesterday", lastSync: time.Now().AddDate(0, 0, -1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updater, repo, externalAPI := newTestBackfillUpdater()
+
+			ctx := context.Background()
+			repo.On("GetLastRateSync", ctx).Return(tt.lastSync, nil)
+
+			updater.backfillMissed(ctx)
+
+			externalAPI.AssertNotCalled(t, "FetchHistoricalRates", mock.Anything, mock.Anything)
+		})
+	}
 }
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE currency_rate_history (
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(5) NOT NULL,
+    rate DECIMAL(10, 4) NOT NULL,
+    recorded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_by UUID
+);
+
+CREATE INDEX idx_currency_rate_history_code_recorded_at ON currency_rate_history (code, recorded_at);
+
+INSERT INTO currency_rate_history (code, rate, recorded_at, updated_by)
+SELECT code, rate, updated_at, updated_by FROM currencies;
+
+-- +goose Down
+DROP TABLE currency_rate_history;
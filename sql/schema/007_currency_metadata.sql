@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE currencies
+ADD COLUMN name VARCHAR(100) NOT NULL DEFAULT '',
+ADD COLUMN symbol VARCHAR(10) NOT NULL DEFAULT '',
+ADD COLUMN minor_units SMALLINT NOT NULL DEFAULT 2,
+ADD COLUMN countries TEXT[] NOT NULL DEFAULT '{}',
+ADD COLUMN kind VARCHAR(20) NOT NULL DEFAULT 'fiat',
+ADD CONSTRAINT currencies_kind_check CHECK (kind IN ('fiat', 'crypto', 'fictional', 'commodity'));
+
+-- +goose Down
+ALTER TABLE currencies
+DROP CONSTRAINT currencies_kind_check,
+DROP COLUMN name,
+DROP COLUMN symbol,
+DROP COLUMN minor_units,
+DROP COLUMN countries,
+DROP COLUMN kind;
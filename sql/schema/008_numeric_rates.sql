@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE currencies
+ALTER COLUMN rate TYPE NUMERIC;
+
+ALTER TABLE currency_rate_history
+ALTER COLUMN rate TYPE NUMERIC;
+
+-- +goose Down
+ALTER TABLE currency_rate_history
+ALTER COLUMN rate TYPE DECIMAL(10, 4);
+
+ALTER TABLE currencies
+ALTER COLUMN rate TYPE DECIMAL(10, 4);
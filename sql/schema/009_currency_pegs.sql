@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE currency_pegs (
+    code CHAR(5) PRIMARY KEY REFERENCES currencies (code) ON DELETE CASCADE,
+    anchor CHAR(5) NOT NULL REFERENCES currencies (code),
+    ratio NUMERIC NOT NULL CHECK (ratio > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (code <> anchor)
+);
+
+CREATE INDEX idx_currency_pegs_anchor ON currency_pegs (anchor);
+
+-- +goose Down
+DROP TABLE currency_pegs;
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE currencies
+ADD COLUMN source VARCHAR(10) NOT NULL DEFAULT 'provider',
+ADD COLUMN locked BOOLEAN NOT NULL DEFAULT FALSE,
+ADD CONSTRAINT currencies_source_check CHECK (source IN ('provider', 'manual'));
+
+UPDATE currencies
+SET source = 'manual', locked = TRUE
+WHERE updated_by IS NOT NULL AND updated_by <> '00000000-0000-0000-0000-000000000000';
+
+-- +goose Down
+ALTER TABLE currencies
+DROP CONSTRAINT currencies_source_check,
+DROP COLUMN source,
+DROP COLUMN locked;
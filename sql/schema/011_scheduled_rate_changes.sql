@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE scheduled_rate_changes (
+    id UUID PRIMARY KEY,
+    code CHAR(5) NOT NULL REFERENCES currencies (code) ON DELETE CASCADE,
+    rate NUMERIC NOT NULL CHECK (rate > 0),
+    effective_at TIMESTAMP NOT NULL,
+    created_by UUID,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_scheduled_rate_changes_effective_at ON scheduled_rate_changes (effective_at);
+
+-- +goose Down
+DROP TABLE scheduled_rate_changes;
@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE currencies
+ADD COLUMN providers TEXT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE currency_rate_history
+ADD COLUMN providers TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE currency_rate_history
+DROP COLUMN providers;
+
+ALTER TABLE currencies
+DROP COLUMN providers;
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE provider_status (
+    provider VARCHAR(50) PRIMARY KEY,
+    state VARCHAR(10) NOT NULL CHECK (state IN ('closed', 'open', 'half_open')),
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    opened_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE provider_status;
@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE currencies
+ADD COLUMN jump_threshold NUMERIC CHECK (jump_threshold > 0);
+
+CREATE TABLE quarantined_rates (
+    id UUID PRIMARY KEY,
+    code CHAR(5) NOT NULL UNIQUE REFERENCES currencies (code) ON DELETE CASCADE,
+    previous_rate NUMERIC NOT NULL,
+    rate NUMERIC NOT NULL CHECK (rate > 0),
+    change NUMERIC NOT NULL,
+    providers TEXT[] NOT NULL DEFAULT '{}',
+    recorded_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE quarantined_rates;
+
+ALTER TABLE currencies
+DROP COLUMN jump_threshold;
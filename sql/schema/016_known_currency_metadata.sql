@@ -0,0 +1,212 @@
+-- +goose Up
+ALTER TABLE currencies
+ALTER COLUMN minor_units DROP NOT NULL,
+ALTER COLUMN minor_units DROP DEFAULT,
+ALTER COLUMN kind DROP NOT NULL,
+ALTER COLUMN kind DROP DEFAULT;
+
+CREATE TEMPORARY TABLE known_currencies (code VARCHAR(5) PRIMARY KEY, minor_units SMALLINT, kind VARCHAR(20)) ON COMMIT DROP;
+INSERT INTO known_currencies (code, minor_units, kind) VALUES
+    ('BTC', 8, 'crypto'),
+    ('ETH', 18, 'crypto'),
+    ('XAU', NULL, 'commodity'),
+    ('XAG', NULL, 'commodity'),
+    ('XPT', NULL, 'commodity'),
+    ('XPD', NULL, 'commodity'),
+    ('BIF', 0, 'fiat'),
+    ('BYR', 0, 'fiat'),
+    ('CLP', 0, 'fiat'),
+    ('DJF', 0, 'fiat'),
+    ('GNF', 0, 'fiat'),
+    ('ISK', 0, 'fiat'),
+    ('JPY', 0, 'fiat'),
+    ('KMF', 0, 'fiat'),
+    ('KRW', 0, 'fiat'),
+    ('PYG', 0, 'fiat'),
+    ('RWF', 0, 'fiat'),
+    ('UGX', 0, 'fiat'),
+    ('UYI', 0, 'fiat'),
+    ('VND', 0, 'fiat'),
+    ('VUV', 0, 'fiat'),
+    ('XAF', 0, 'fiat'),
+    ('XOF', 0, 'fiat'),
+    ('XPF', 0, 'fiat'),
+    ('AED', 2, 'fiat'),
+    ('AFN', 2, 'fiat'),
+    ('ALL', 2, 'fiat'),
+    ('AMD', 2, 'fiat'),
+    ('ANG', 2, 'fiat'),
+    ('AOA', 2, 'fiat'),
+    ('ARS', 2, 'fiat'),
+    ('AUD', 2, 'fiat'),
+    ('AWG', 2, 'fiat'),
+    ('AZN', 2, 'fiat'),
+    ('BAM', 2, 'fiat'),
+    ('BBD', 2, 'fiat'),
+    ('BDT', 2, 'fiat'),
+    ('BGN', 2, 'fiat'),
+    ('BMD', 2, 'fiat'),
+    ('BND', 2, 'fiat'),
+    ('BOB', 2, 'fiat'),
+    ('BOV', 2, 'fiat'),
+    ('BRL', 2, 'fiat'),
+    ('BSD', 2, 'fiat'),
+    ('BTN', 2, 'fiat'),
+    ('BWP', 2, 'fiat'),
+    ('BYN', 2, 'fiat'),
+    ('BZD', 2, 'fiat'),
+    ('CAD', 2, 'fiat'),
+    ('CDF', 2, 'fiat'),
+    ('CHE', 2, 'fiat'),
+    ('CHF', 2, 'fiat'),
+    ('CHW', 2, 'fiat'),
+    ('CNH', 2, 'fiat'),
+    ('CNY', 2, 'fiat'),
+    ('COP', 2, 'fiat'),
+    ('COU', 2, 'fiat'),
+    ('CRC', 2, 'fiat'),
+    ('CUC', 2, 'fiat'),
+    ('CUP', 2, 'fiat'),
+    ('CVE', 2, 'fiat'),
+    ('CZK', 2, 'fiat'),
+    ('DKK', 2, 'fiat'),
+    ('DOP', 2, 'fiat'),
+    ('DZD', 2, 'fiat'),
+    ('EGP', 2, 'fiat'),
+    ('ERN', 2, 'fiat'),
+    ('ETB', 2, 'fiat'),
+    ('EUR', 2, 'fiat'),
+    ('FJD', 2, 'fiat'),
+    ('FKP', 2, 'fiat'),
+    ('GBP', 2, 'fiat'),
+    ('GEL', 2, 'fiat'),
+    ('GGP', 2, 'fiat'),
+    ('GHS', 2, 'fiat'),
+    ('GIP', 2, 'fiat'),
+    ('GMD', 2, 'fiat'),
+    ('GTQ', 2, 'fiat'),
+    ('GYD', 2, 'fiat'),
+    ('HKD', 2, 'fiat'),
+    ('HNL', 2, 'fiat'),
+    ('HTG', 2, 'fiat'),
+    ('HUF', 2, 'fiat'),
+    ('IDR', 2, 'fiat'),
+    ('ILS', 2, 'fiat'),
+    ('IMP', 2, 'fiat'),
+    ('INR', 2, 'fiat'),
+    ('IRR', 2, 'fiat'),
+    ('JEP', 2, 'fiat'),
+    ('JMD', 2, 'fiat'),
+    ('KES', 2, 'fiat'),
+    ('KGS', 2, 'fiat'),
+    ('KHR', 2, 'fiat'),
+    ('KPW', 2, 'fiat'),
+    ('KYD', 2, 'fiat'),
+    ('KZT', 2, 'fiat'),
+    ('LAK', 2, 'fiat'),
+    ('LBP', 2, 'fiat'),
+    ('LKR', 2, 'fiat'),
+    ('LRD', 2, 'fiat'),
+    ('LSL', 2, 'fiat'),
+    ('MAD', 2, 'fiat'),
+    ('MDL', 2, 'fiat'),
+    ('MGA', 2, 'fiat'),
+    ('MKD', 2, 'fiat'),
+    ('MMK', 2, 'fiat'),
+    ('MNT', 2, 'fiat'),
+    ('MOP', 2, 'fiat'),
+    ('MRO', 2, 'fiat'),
+    ('MRU', 2, 'fiat'),
+    ('MUR', 2, 'fiat'),
+    ('MVR', 2, 'fiat'),
+    ('MWK', 2, 'fiat'),
+    ('MXN', 2, 'fiat'),
+    ('MXV', 2, 'fiat'),
+    ('MYR', 2, 'fiat'),
+    ('MZN', 2, 'fiat'),
+    ('NAD', 2, 'fiat'),
+    ('NGN', 2, 'fiat'),
+    ('NIO', 2, 'fiat'),
+    ('NOK', 2, 'fiat'),
+    ('NPR', 2, 'fiat'),
+    ('NZD', 2, 'fiat'),
+    ('PAB', 2, 'fiat'),
+    ('PEN', 2, 'fiat'),
+    ('PGK', 2, 'fiat'),
+    ('PHP', 2, 'fiat'),
+    ('PKR', 2, 'fiat'),
+    ('PLN', 2, 'fiat'),
+    ('QAR', 2, 'fiat'),
+    ('RON', 2, 'fiat'),
+    ('RSD', 2, 'fiat'),
+    ('RUB', 2, 'fiat'),
+    ('SAR', 2, 'fiat'),
+    ('SBD', 2, 'fiat'),
+    ('SCR', 2, 'fiat'),
+    ('SDG', 2, 'fiat'),
+    ('SEK', 2, 'fiat'),
+    ('SGD', 2, 'fiat'),
+    ('SHP', 2, 'fiat'),
+    ('SLE', 2, 'fiat'),
+    ('SLL', 2, 'fiat'),
+    ('SOS', 2, 'fiat'),
+    ('SRD', 2, 'fiat'),
+    ('SSP', 2, 'fiat'),
+    ('STD', 2, 'fiat'),
+    ('STN', 2, 'fiat'),
+    ('SVC', 2, 'fiat'),
+    ('SYP', 2, 'fiat'),
+    ('SZL', 2, 'fiat'),
+    ('THB', 2, 'fiat'),
+    ('TJS', 2, 'fiat'),
+    ('TMT', 2, 'fiat'),
+    ('TOP', 2, 'fiat'),
+    ('TRY', 2, 'fiat'),
+    ('TTD', 2, 'fiat'),
+    ('TWD', 2, 'fiat'),
+    ('TZS', 2, 'fiat'),
+    ('UAH', 2, 'fiat'),
+    ('USD', 2, 'fiat'),
+    ('USN', 2, 'fiat'),
+    ('UYU', 2, 'fiat'),
+    ('UZS', 2, 'fiat'),
+    ('VED', 2, 'fiat'),
+    ('VEF', 2, 'fiat'),
+    ('VES', 2, 'fiat'),
+    ('WST', 2, 'fiat'),
+    ('XCD', 2, 'fiat'),
+    ('YER', 2, 'fiat'),
+    ('ZAR', 2, 'fiat'),
+    ('ZMK', 2, 'fiat'),
+    ('ZMW', 2, 'fiat'),
+    ('ZWL', 2, 'fiat'),
+    ('BHD', 3, 'fiat'),
+    ('IQD', 3, 'fiat'),
+    ('JOD', 3, 'fiat'),
+    ('KWD', 3, 'fiat'),
+    ('LYD', 3, 'fiat'),
+    ('OMR', 3, 'fiat'),
+    ('TND', 3, 'fiat'),
+    ('CLF', 4, 'fiat'),
+    ('UYW', 4, 'fiat');
+
+UPDATE currencies
+SET minor_units = known_currencies.minor_units, kind = known_currencies.kind
+FROM known_currencies
+WHERE TRIM(currencies.code) = known_currencies.code AND currencies.source = 'provider'
+  AND currencies.minor_units = 2 AND currencies.kind = 'fiat';
+
+UPDATE currencies
+SET minor_units = NULL, kind = NULL
+WHERE source = 'provider' AND name = '' AND minor_units = 2 AND kind = 'fiat'
+  AND TRIM(code) NOT IN (SELECT code FROM known_currencies);
+
+-- +goose Down
+UPDATE currencies SET minor_units = 2 WHERE minor_units IS NULL;
+UPDATE currencies SET kind = 'fiat' WHERE kind IS NULL;
+
+ALTER TABLE currencies
+ALTER COLUMN minor_units SET DEFAULT 2,
+ALTER COLUMN minor_units SET NOT NULL,
+ALTER COLUMN kind SET DEFAULT 'fiat',
+ALTER COLUMN kind SET NOT NULL;
@@ -275,7 +275,7 @@ func TestEndToEnd(t *testing.T) {
 				expectedBody: map[string]interface{}{
 					"from":   "USD",
 					"to":     "EUR",
-					"amount": float64(100),
+					"amount": "100",
 				},
 			},
 			{